@@ -1,5 +1,7 @@
 package main
 
+import "fmt"
+
 // BitPermutation выполняет перестановку битов в соответствии с P-блоком
 func BitPermutation(data []byte, permutationTable []int, bitIndexingFromLSB bool, startBitIndex int) []byte {
 	var bits []int
@@ -47,3 +49,203 @@ func BitPermutation(data []byte, permutationTable []int, bitIndexingFromLSB bool
 	}
 	return result
 }
+
+// zeroBytes затирает содержимое среза нулями
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
+
+// zeroRoundKeys затирает все раундовые ключи
+func zeroRoundKeys(roundKeys [][]byte) {
+	for _, rk := range roundKeys {
+		zeroBytes(rk)
+	}
+}
+
+// CompiledPermutation предвычисленная перестановка битов. Таблица перестановки
+// один раз превращается в набор таблиц поиска: для каждого байта входа и каждого
+// его значения хранится готовая маска выходных битов, поэтому применение
+// перестановки сводится к нескольким операциям OR.
+type CompiledPermutation struct {
+	table              []int
+	inputBits          int
+	outputBits         int
+	bitIndexingFromLSB bool
+	startBitIndex      int
+	lookup             [][256]uint64
+}
+
+// CompilePermutation компилирует таблицу перестановки для входа длиной inputBits бит.
+// Индексация битов совпадает с BitPermutation: bitIndexingFromLSB задает направление
+// нумерации внутри байта (и внутри uint64), startBitIndex - номер первого бита.
+// При requireBijection таблица обязана быть перестановкой всех inputBits битов.
+func CompilePermutation(permutationTable []int, inputBits int, bitIndexingFromLSB bool, startBitIndex int, requireBijection bool) (*CompiledPermutation, error) {
+	if inputBits <= 0 || inputBits > 64 {
+		return nil, fmt.Errorf("размер входа перестановки должен быть от 1 до 64 бит, получено %d", inputBits)
+	}
+	if len(permutationTable) == 0 || len(permutationTable) > 64 {
+		return nil, fmt.Errorf("размер таблицы перестановки должен быть от 1 до 64, получено %d", len(permutationTable))
+	}
+
+	if err := validatePermutationTable(permutationTable, inputBits, startBitIndex, requireBijection); err != nil {
+		return nil, err
+	}
+
+	cp := &CompiledPermutation{
+		table:              append([]int{}, permutationTable...),
+		inputBits:          inputBits,
+		outputBits:         len(permutationTable),
+		bitIndexingFromLSB: bitIndexingFromLSB,
+		startBitIndex:      startBitIndex,
+		lookup:             make([][256]uint64, (inputBits+7)/8),
+	}
+
+	for outIndex, pos := range permutationTable {
+		inPhys := cp.physicalBit(pos-startBitIndex, inputBits)
+		outMask := uint64(1) << uint(cp.physicalBit(outIndex, cp.outputBits))
+
+		chunk := inPhys / 8
+		bit := byte(1) << uint(inPhys%8)
+		for v := 0; v < 256; v++ {
+			if byte(v)&bit != 0 {
+				cp.lookup[chunk][v] |= outMask
+			}
+		}
+	}
+
+	return cp, nil
+}
+
+// MustCompilePermutation то же, что CompilePermutation, но паникует при ошибке.
+// Предназначена для инициализации таблиц на уровне пакета.
+func MustCompilePermutation(permutationTable []int, inputBits int, bitIndexingFromLSB bool, startBitIndex int, requireBijection bool) *CompiledPermutation {
+	cp, err := CompilePermutation(permutationTable, inputBits, bitIndexingFromLSB, startBitIndex, requireBijection)
+	if err != nil {
+		panic(err)
+	}
+	return cp
+}
+
+// validatePermutationTable проверяет, что все позиции лежат во входе, а при
+// requireBijection - что каждая позиция встречается ровно один раз
+func validatePermutationTable(permutationTable []int, inputBits int, startBitIndex int, requireBijection bool) error {
+	if requireBijection && len(permutationTable) != inputBits {
+		return fmt.Errorf("таблица не является перестановкой: %d позиций для %d бит", len(permutationTable), inputBits)
+	}
+
+	seen := make([]bool, inputBits)
+	for i, pos := range permutationTable {
+		sourceIndex := pos - startBitIndex
+		if sourceIndex < 0 || sourceIndex >= inputBits {
+			return fmt.Errorf("позиция %d в элементе %d выходит за пределы входа [%d, %d]",
+				pos, i, startBitIndex, startBitIndex+inputBits-1)
+		}
+		if requireBijection && seen[sourceIndex] {
+			return fmt.Errorf("таблица не является перестановкой: позиция %d повторяется", pos)
+		}
+		seen[sourceIndex] = true
+	}
+	return nil
+}
+
+// InversePermutationTable строит обратную таблицу перестановки
+func InversePermutationTable(permutationTable []int, startBitIndex int) ([]int, error) {
+	if err := validatePermutationTable(permutationTable, len(permutationTable), startBitIndex, true); err != nil {
+		return nil, err
+	}
+
+	inverse := make([]int, len(permutationTable))
+	for i, pos := range permutationTable {
+		inverse[pos-startBitIndex] = i + startBitIndex
+	}
+	return inverse, nil
+}
+
+// physicalBit переводит логический номер бита (с нуля) в номер разряда uint64
+func (cp *CompiledPermutation) physicalBit(index, width int) int {
+	if cp.bitIndexingFromLSB {
+		return index
+	}
+	return width - 1 - index
+}
+
+// InputBits возвращает размер входа в битах
+func (cp *CompiledPermutation) InputBits() int {
+	return cp.inputBits
+}
+
+// OutputBits возвращает размер выхода в битах
+func (cp *CompiledPermutation) OutputBits() int {
+	return cp.outputBits
+}
+
+// Table возвращает копию исходной таблицы перестановки
+func (cp *CompiledPermutation) Table() []int {
+	return append([]int{}, cp.table...)
+}
+
+// Inverse возвращает скомпилированную обратную перестановку
+func (cp *CompiledPermutation) Inverse() (*CompiledPermutation, error) {
+	if cp.inputBits != cp.outputBits {
+		return nil, fmt.Errorf("обратная перестановка не существует: %d бит на входе, %d на выходе", cp.inputBits, cp.outputBits)
+	}
+	inverse, err := InversePermutationTable(cp.table, cp.startBitIndex)
+	if err != nil {
+		return nil, err
+	}
+	return CompilePermutation(inverse, cp.inputBits, cp.bitIndexingFromLSB, cp.startBitIndex, true)
+}
+
+// Apply применяет перестановку к значению из InputBits младших бит. При индексации
+// от старшего бита бит с номером startBitIndex - старший из InputBits, иначе - младший.
+// Результат занимает OutputBits младших бит.
+func (cp *CompiledPermutation) Apply(value uint64) uint64 {
+	var result uint64
+	for chunk := range cp.lookup {
+		result |= cp.lookup[chunk][byte(value>>(8*uint(chunk)))]
+	}
+	return result
+}
+
+// ApplyBytes применяет перестановку к байтовому представлению так же, как
+// BitPermutation: выход дополняется нулевыми битами до целого числа байт
+func (cp *CompiledPermutation) ApplyBytes(data []byte) []byte {
+	inBytes := (cp.inputBits + 7) / 8
+	if len(data) < inBytes {
+		panic(fmt.Sprintf("для перестановки нужно %d байт, получено %d", inBytes, len(data)))
+	}
+
+	var value uint64
+	if cp.bitIndexingFromLSB {
+		for i := inBytes - 1; i >= 0; i-- {
+			value = value<<8 | uint64(data[i])
+		}
+		if cp.inputBits < 64 {
+			value &= (uint64(1) << uint(cp.inputBits)) - 1
+		}
+	} else {
+		for i := 0; i < inBytes; i++ {
+			value = value<<8 | uint64(data[i])
+		}
+		value >>= uint(inBytes*8 - cp.inputBits)
+	}
+
+	permuted := cp.Apply(value)
+
+	outBytes := (cp.outputBits + 7) / 8
+	result := make([]byte, outBytes)
+	if cp.bitIndexingFromLSB {
+		for i := 0; i < outBytes; i++ {
+			result[i] = byte(permuted >> (8 * uint(i)))
+		}
+	} else {
+		permuted <<= uint(outBytes*8 - cp.outputBits)
+		for i := outBytes - 1; i >= 0; i-- {
+			result[i] = byte(permuted)
+			permuted >>= 8
+		}
+	}
+	return result
+}
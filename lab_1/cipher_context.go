@@ -1,12 +1,15 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"sync"
 )
 
+var errContextClosed = errors.New("контекст шифрования закрыт")
+
 type CipherContext struct {
 	cipher         SymmetricCipher
 	key            []byte
@@ -16,6 +19,7 @@ type CipherContext struct {
 	blockSize      int
 	paddingHandler *PaddingHandler
 	cipherModes    *CipherModes
+	closed         bool
 	mutex          sync.RWMutex
 }
 
@@ -34,6 +38,8 @@ func NewCipherContext(cipher SymmetricCipher, key []byte, cipherMode CipherMode,
 		ctx.iv = make([]byte, blockSize)
 	}
 	if err := cipher.SetupKeys(key); err != nil {
+		zeroBytes(ctx.key)
+		zeroBytes(ctx.iv)
 		return nil, fmt.Errorf("ошибка настройки ключей: %w", err)
 	}
 	ctx.cipherModes = NewCipherModes(cipher, blockSize)
@@ -44,6 +50,10 @@ func (ctx *CipherContext) Encrypt(data []byte) ([]byte, error) {
 	ctx.mutex.RLock()
 	defer ctx.mutex.RUnlock()
 
+	if ctx.closed {
+		return nil, errContextClosed
+	}
+
 	// Сохраняем исходную длину для поточных режимов
 	originalLen := len(data)
 
@@ -123,6 +133,10 @@ func (ctx *CipherContext) Decrypt(data []byte) ([]byte, error) {
 	ctx.mutex.RLock()
 	defer ctx.mutex.RUnlock()
 
+	if ctx.closed {
+		return nil, errContextClosed
+	}
+
 	isStreamMode := ctx.cipherMode == CFB ||
 		ctx.cipherMode == OFB ||
 		ctx.cipherMode == CTR
@@ -219,3 +233,27 @@ func (ctx *CipherContext) DecryptFile(inputPath, outputPath string) error {
 	}
 	return os.WriteFile(outputPath, dec, 0644)
 }
+
+// Close затирает ключ, IV и раундовые ключи шифра. После закрытия контекст
+// и связанный с ним шифр использовать нельзя. Повторный вызов безопасен.
+func (ctx *CipherContext) Close() error {
+	ctx.mutex.Lock()
+	defer ctx.mutex.Unlock()
+
+	if ctx.closed {
+		return nil
+	}
+
+	zeroBytes(ctx.key)
+	zeroBytes(ctx.iv)
+	if d, ok := ctx.cipher.(Destroyable); ok {
+		d.Destroy()
+	}
+	ctx.closed = true
+	return nil
+}
+
+// Destroy синоним Close для единообразия с шифрами
+func (ctx *CipherContext) Destroy() {
+	_ = ctx.Close()
+}
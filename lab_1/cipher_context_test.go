@@ -0,0 +1,141 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+// requireZeroRoundKeys проверяет, что все раундовые ключи затерты
+func requireZeroRoundKeys(t *testing.T, name string, roundKeys [][]byte) {
+	t.Helper()
+	if len(roundKeys) == 0 {
+		t.Fatalf("%s: no round keys captured", name)
+	}
+	for i, rk := range roundKeys {
+		if !allZero(rk) {
+			t.Errorf("%s: round key %d not zeroed: %x", name, i, rk)
+		}
+	}
+}
+
+// requirePanic проверяет, что f паникует (шифр после Destroy непригоден)
+func requirePanic(t *testing.T, name string, f func()) {
+	t.Helper()
+	defer func() {
+		if recover() == nil {
+			t.Errorf("%s: expected panic after Destroy", name)
+		}
+	}()
+	f()
+}
+
+func TestDESDestroyZeroesRoundKeys(t *testing.T) {
+	des := NewDESCipher()
+	if err := des.SetupKeys([]byte("8bytekey")); err != nil {
+		t.Fatal(err)
+	}
+	roundKeys := des.feistelNetwork.roundKeys
+	if allZero(roundKeys[0]) {
+		t.Fatal("round key is zero before Destroy")
+	}
+
+	des.Destroy()
+	requireZeroRoundKeys(t, "DES", roundKeys)
+	requirePanic(t, "DES", func() { des.EncryptBlock(make([]byte, 8)) })
+	if err := des.SetupKeys([]byte("8bytekey")); err == nil {
+		t.Error("SetupKeys succeeded after Destroy")
+	}
+}
+
+func TestTripleDESDestroyZeroesAllRoundKeys(t *testing.T) {
+	tdes := NewTripleDESCipher()
+	if err := tdes.SetupKeys([]byte("0123456789abcdefFEDCBA98")); err != nil {
+		t.Fatal(err)
+	}
+	schedules := [][][]byte{
+		tdes.des1.feistelNetwork.roundKeys,
+		tdes.des2.feistelNetwork.roundKeys,
+		tdes.des3.feistelNetwork.roundKeys,
+	}
+
+	tdes.Destroy()
+	for _, rk := range schedules {
+		requireZeroRoundKeys(t, "3DES", rk)
+	}
+	requirePanic(t, "3DES", func() { tdes.EncryptBlock(make([]byte, 8)) })
+}
+
+func TestDEALDestroyZeroesRoundKeys(t *testing.T) {
+	deal := NewDEALCipher()
+	if err := deal.SetupKeys([]byte("0123456789abcdef")); err != nil {
+		t.Fatal(err)
+	}
+	roundKeys := deal.roundKeys
+
+	deal.Destroy()
+	requireZeroRoundKeys(t, "DEAL", roundKeys)
+	requirePanic(t, "DEAL", func() { deal.EncryptBlock(make([]byte, 16)) })
+	if err := deal.SetupKeys([]byte("0123456789abcdef")); err == nil {
+		t.Error("SetupKeys succeeded after Destroy")
+	}
+}
+
+func TestSetupKeysZeroesPreviousSchedule(t *testing.T) {
+	des := NewDESCipher()
+	if err := des.SetupKeys([]byte("firstkey")); err != nil {
+		t.Fatal(err)
+	}
+	old := des.feistelNetwork.roundKeys
+	if err := des.SetupKeys([]byte("secondky")); err != nil {
+		t.Fatal(err)
+	}
+	requireZeroRoundKeys(t, "DES rekey", old)
+}
+
+func TestCipherContextCloseWipesKeyAndIV(t *testing.T) {
+	key := []byte("8bytekey")
+	iv := []byte("initvect")
+	des := NewDESCipher()
+	ctx, err := NewCipherContext(des, key, CBC, PKCS7, iv, 8)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	plaintext := []byte("zeroization lifecycle")
+	ciphertext, err := ctx.Encrypt(plaintext)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decrypted, err := ctx.Decrypt(ciphertext)
+	if err != nil || !bytes.Equal(decrypted, plaintext) {
+		t.Fatalf("round trip failed: %q, %v", decrypted, err)
+	}
+
+	ctxKey, ctxIV := ctx.key, ctx.iv
+	roundKeys := des.feistelNetwork.roundKeys
+	if err := ctx.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if !allZero(ctxKey) {
+		t.Errorf("context key not zeroed: %x", ctxKey)
+	}
+	if !allZero(ctxIV) {
+		t.Errorf("context IV not zeroed: %x", ctxIV)
+	}
+	requireZeroRoundKeys(t, "context cipher", roundKeys)
+	if !bytes.Equal(key, []byte("8bytekey")) {
+		t.Error("caller's key slice was modified")
+	}
+
+	if _, err := ctx.Encrypt(plaintext); !errors.Is(err, errContextClosed) {
+		t.Errorf("Encrypt after Close: %v, want errContextClosed", err)
+	}
+	if _, err := ctx.Decrypt(ciphertext); !errors.Is(err, errContextClosed) {
+		t.Errorf("Decrypt after Close: %v, want errContextClosed", err)
+	}
+	if err := ctx.Close(); err != nil {
+		t.Errorf("second Close: %v", err)
+	}
+}
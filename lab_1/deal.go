@@ -10,6 +10,7 @@ import (
 type DEALCipher struct {
 	numRounds int
 	roundKeys [][]byte
+	destroyed bool
 	mutex     sync.Mutex
 }
 
@@ -18,6 +19,14 @@ func NewDEALCipher() *DEALCipher {
 }
 
 func (deal *DEALCipher) SetupKeys(key []byte) error {
+	deal.mutex.Lock()
+	defer deal.mutex.Unlock()
+
+	if deal.destroyed {
+		return fmt.Errorf("шифр DEAL уничтожен, ключи не могут быть настроены")
+	}
+	zeroRoundKeys(deal.roundKeys)
+
 	keyLen := len(key)
 
 	switch keyLen {
@@ -67,6 +76,7 @@ func (deal *DEALCipher) SetupKeys(key []byte) error {
 		// Шифруем DES с константным ключом
 		roundKey := make([]byte, 8)
 		desBlockForKeySchedule.Encrypt(roundKey, temp)
+		zeroBytes(temp)
 
 		deal.roundKeys[round] = roundKey
 		prevRoundKey = roundKey
@@ -82,6 +92,7 @@ func (deal *DEALCipher) EncryptBlock(block []byte) []byte {
 
 	deal.mutex.Lock()
 	defer deal.mutex.Unlock()
+	deal.checkUsable()
 
 	left := make([]byte, 8)
 	right := make([]byte, 8)
@@ -98,6 +109,7 @@ func (deal *DEALCipher) EncryptBlock(block []byte) []byte {
 		copy(newLeft, right)
 
 		f := localDES.EncryptBlock(right)
+		localDES.Destroy()
 		newRight := deal.xorBytes(left, f)
 
 		left, right = newLeft, newRight
@@ -116,6 +128,7 @@ func (deal *DEALCipher) DecryptBlock(block []byte) []byte {
 
 	deal.mutex.Lock()
 	defer deal.mutex.Unlock()
+	deal.checkUsable()
 
 	left := make([]byte, 8)
 	right := make([]byte, 8)
@@ -132,6 +145,7 @@ func (deal *DEALCipher) DecryptBlock(block []byte) []byte {
 		copy(newRight, left)
 
 		f := localDES.EncryptBlock(left)
+		localDES.Destroy()
 		newLeft := deal.xorBytes(right, f)
 
 		left, right = newLeft, newRight
@@ -143,6 +157,24 @@ func (deal *DEALCipher) DecryptBlock(block []byte) []byte {
 	return result
 }
 
+// Destroy затирает раундовые ключи DEAL и запрещает дальнейшее использование шифра
+func (deal *DEALCipher) Destroy() {
+	deal.mutex.Lock()
+	defer deal.mutex.Unlock()
+
+	zeroRoundKeys(deal.roundKeys)
+	deal.destroyed = true
+}
+
+func (deal *DEALCipher) checkUsable() {
+	if deal.destroyed {
+		panic("шифр DEAL уничтожен, использование невозможно")
+	}
+	if deal.roundKeys == nil {
+		panic("раундовые ключи DEAL не настроены")
+	}
+}
+
 func (deal *DEALCipher) xorBytes(a, b []byte) []byte {
 	result := make([]byte, len(a))
 	for i := 0; i < len(a) && i < len(b); i++ {
@@ -187,6 +219,10 @@ func (adapter *DESAdapter) EncryptBlock(block []byte) []byte {
 	return adapter.desCipher.EncryptBlock(block)
 }
 
+func (adapter *DESAdapter) Destroy() {
+	adapter.desCipher.Destroy()
+}
+
 func GenerateDEALKey(keyBits int) ([]byte, error) {
 	switch keyBits {
 	case 128:
@@ -2,6 +2,7 @@ package main
 
 import (
 	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 )
 
@@ -50,8 +51,8 @@ var pBox = []int{
 	19, 13, 30, 6, 22, 11, 4, 25,
 }
 
-// S-блоки
-var sBoxes = [8][4][16]int{
+// Стандартные S-блоки
+var sBoxes = SBoxSet{
 	{
 		{14, 4, 13, 1, 2, 15, 11, 8, 3, 10, 6, 12, 5, 9, 0, 7},
 		{0, 15, 7, 4, 14, 2, 13, 1, 10, 6, 12, 11, 9, 5, 3, 8},
@@ -129,6 +130,16 @@ var pc2 = []int{
 // Количество левых сдвигов для каждого раунда
 var shiftTable = []int{1, 1, 2, 2, 2, 2, 2, 2, 1, 2, 2, 2, 2, 2, 2, 1}
 
+// Скомпилированные перестановки DES (индексация с 1, от старшего бита)
+var (
+	ipPermutation        = MustCompilePermutation(initialPermutation, 64, false, 1, true)
+	fpPermutation        = MustCompilePermutation(finalPermutation, 64, false, 1, true)
+	expansionPermutation = MustCompilePermutation(expansionTable, 32, false, 1, false)
+	pBoxPermutation      = MustCompilePermutation(pBox, 32, false, 1, true)
+	pc1Permutation       = MustCompilePermutation(pc1, 64, false, 1, false)
+	pc2Permutation       = MustCompilePermutation(pc2, 56, false, 1, false)
+)
+
 // rotateLeft28 выполняет циклический левый сдвиг 28-битного числа на shifts позиций
 func rotateLeft28(n uint32, shifts uint) uint32 {
 	shifts = shifts % 28
@@ -136,24 +147,23 @@ func rotateLeft28(n uint32, shifts uint) uint32 {
 	return ((n << shifts) | (n >> (28 - shifts))) & mask
 }
 
-// bitsToUint32 преобразует массив битов в uint32
-func bitsToUint32(bits []int) uint32 {
-	var result uint32
-	for i := 0; i < len(bits) && i < 32; i++ {
-		if bits[i] != 0 {
-			result |= 1 << uint(len(bits)-1-i)
-		}
+// uint48ToBytes записывает 48 младших бит в 6 байт (big-endian)
+func uint48ToBytes(n uint64) []byte {
+	result := make([]byte, 6)
+	for i := 5; i >= 0; i-- {
+		result[i] = byte(n)
+		n >>= 8
 	}
 	return result
 }
 
-// uint32ToBits преобразует uint32 в массив битов указанной длины
-func uint32ToBits(n uint32, bitCount int) []int {
-	bits := make([]int, bitCount)
-	for i := 0; i < bitCount; i++ {
-		bits[bitCount-1-i] = int((n >> uint(i)) & 1)
+// bytesToUint48 читает 6 байт (big-endian) в 48 младших бит
+func bytesToUint48(b []byte) uint64 {
+	var n uint64
+	for i := 0; i < 6; i++ {
+		n = n<<8 | uint64(b[i])
 	}
-	return bits
+	return n
 }
 
 // DESKeyExpansion реализация расширения ключа для DES
@@ -165,24 +175,10 @@ func (ke *DESKeyExpansion) ExpandKey(key []byte) [][]byte {
 		panic(fmt.Sprintf("ключ DES должен быть 64 бита (8 байт), получено %d", len(key)))
 	}
 
-	// Применяем PC-1 к ключу
-	pc1Key := BitPermutation(key, pc1, false, 1)
-	var pc1Bits []int
-	for _, b := range pc1Key {
-		for i := 7; i >= 0; i-- {
-			pc1Bits = append(pc1Bits, int((b>>i)&1))
-		}
-	}
-	if len(pc1Bits) > 56 {
-		pc1Bits = pc1Bits[:56]
-	}
-
-	// Преобразуем левую и правую половины в uint32 для битовых операций
-	leftBits := pc1Bits[:28]
-	rightBits := pc1Bits[28:56]
-
-	leftHalf := bitsToUint32(leftBits)
-	rightHalf := bitsToUint32(rightBits)
+	// Применяем PC-1 к ключу и делим результат на две 28-битные половины
+	pc1Key := pc1Permutation.Apply(binary.BigEndian.Uint64(key))
+	leftHalf := uint32(pc1Key>>28) & 0x0FFFFFFF
+	rightHalf := uint32(pc1Key) & 0x0FFFFFFF
 
 	var roundKeys [][]byte
 	for roundNum := 0; roundNum < 16; roundNum++ {
@@ -191,33 +187,18 @@ func (ke *DESKeyExpansion) ExpandKey(key []byte) [][]byte {
 		leftHalf = rotateLeft28(leftHalf, shiftCount)
 		rightHalf = rotateLeft28(rightHalf, shiftCount)
 
-		// Преобразуем обратно в биты и объединяем
-		leftBitsRotated := uint32ToBits(leftHalf, 28)
-		rightBitsRotated := uint32ToBits(rightHalf, 28)
-		combined := append(leftBitsRotated, rightBitsRotated...)
-
-		var combinedBytes []byte
-		for i := 0; i < len(combined); i += 8 {
-			var byteValue byte
-			for j := 0; j < 8 && i+j < len(combined); j++ {
-				byteValue |= byte(combined[i+j] << (7 - j))
-			}
-			combinedBytes = append(combinedBytes, byteValue)
-		}
-
 		// Применяем PC-2 для получения раундового ключа
-		rk := BitPermutation(combinedBytes, pc2, false, 1)
-		if len(rk) > 6 {
-			rk = rk[:6]
-		}
-		roundKeys = append(roundKeys, rk)
+		combined := uint64(leftHalf)<<28 | uint64(rightHalf)
+		roundKeys = append(roundKeys, uint48ToBytes(pc2Permutation.Apply(combined)))
 	}
 
 	return roundKeys
 }
 
 // DESRoundFunction реализация раундовой функции DES
-type DESRoundFunction struct{}
+type DESRoundFunction struct {
+	sBoxes *SBoxSet // nil - стандартные S-блоки DES
+}
 
 // Apply применяет раундовую функцию DES к 32-битному блоку
 func (rf *DESRoundFunction) Apply(block []byte, roundKey []byte) []byte {
@@ -229,75 +210,67 @@ func (rf *DESRoundFunction) Apply(block []byte, roundKey []byte) []byte {
 		panic(fmt.Sprintf("раундовый ключ должен быть 48 бит (6 байт), получено %d", len(roundKey)))
 	}
 
-	// Расширение E
-	expanded := BitPermutation(block, expansionTable, false, 1)
-	if len(expanded) > 6 {
-		expanded = expanded[:6]
-	}
+	// Расширение E и XOR с раундовым ключом
+	xored := expansionPermutation.Apply(uint64(binary.BigEndian.Uint32(block))) ^ bytesToUint48(roundKey)
 
-	// XOR с раундовым ключом
-	xored := make([]byte, len(expanded))
-	for i := 0; i < len(expanded) && i < len(roundKey); i++ {
-		xored[i] = expanded[i] ^ roundKey[i]
+	boxes := rf.sBoxes
+	if boxes == nil {
+		boxes = &sBoxes
 	}
 
-	// Преобразование в биты
-	var xoredBits []int
-	for _, b := range xored {
-		for i := 7; i >= 0; i-- {
-			xoredBits = append(xoredBits, int((b>>i)&1))
-		}
-	}
-	if len(xoredBits) > 48 {
-		xoredBits = xoredBits[:48]
-	}
-
-	// S-блоки
-	var sboxOutput []int
+	// S-блоки: каждые 6 бит превращаются в 4
+	var sboxOutput uint64
 	for i := 0; i < 8; i++ {
-		start := i * 6
-		end := start + 6
-		if end > len(xoredBits) {
-			end = len(xoredBits)
-		}
-
-		block6bit := make([]int, 6)
-		copy(block6bit, xoredBits[start:end])
-		for len(block6bit) < 6 {
-			block6bit = append(block6bit, 0)
-		}
+		block6bit := (xored >> uint(42-6*i)) & 0x3F
+		row := (block6bit>>4)&0x2 | block6bit&0x1
+		col := (block6bit >> 1) & 0xF
+		sboxOutput = sboxOutput<<4 | uint64(boxes[i][row][col])
+	}
 
-		row := (block6bit[0] << 1) | block6bit[5]
-		col := (block6bit[1] << 3) | (block6bit[2] << 2) | (block6bit[3] << 1) | block6bit[4]
-		val := sBoxes[i][row][col]
+	// P-блок
+	result := make([]byte, 4)
+	binary.BigEndian.PutUint32(result, uint32(pBoxPermutation.Apply(sboxOutput)))
+	return result
+}
 
-		for j := 3; j >= 0; j-- {
-			sboxOutput = append(sboxOutput, (val>>j)&1)
-		}
-	}
+// DESVariant вариант алгоритма DES
+type DESVariant int
 
-	// Преобразование обратно в байты
-	var sboxBytes []byte
-	for i := 0; i < 32; i += 8 {
-		var byteValue byte
-		for j := 0; j < 8 && i+j < len(sboxOutput); j++ {
-			byteValue |= byte(sboxOutput[i+j] << (7 - j))
-		}
-		sboxBytes = append(sboxBytes, byteValue)
-	}
+const (
+	DESStandard DESVariant = iota // классический DES
+	DESX                          // DES с пред- и постотбеливанием ключами K1, K2
+	GDES                          // обобщенный DES с несколькими 32-битными подблоками, без IP/FP
+)
 
-	// P-блок
-	finalResult := BitPermutation(sboxBytes, pBox, false, 1)
-	if len(finalResult) > 4 {
-		finalResult = finalResult[:4]
+func (v DESVariant) String() string {
+	switch v {
+	case DESStandard:
+		return "DES"
+	case DESX:
+		return "DESX"
+	case GDES:
+		return "GDES"
+	default:
+		return "Unknown"
 	}
+}
 
-	return finalResult
+// DESOptions параметры построения шифра семейства DES
+type DESOptions struct {
+	Variant DESVariant
+	// SBoxes пользовательские S-блоки (например, s²DES); nil - стандартные.
+	// Перед использованием проверяются на критерии проектирования DES.
+	SBoxes *SBoxSet
+	// SubBlocks число 32-битных подблоков для GDES (не менее 2, по умолчанию 4)
+	SubBlocks int
 }
 
-// DESCipher реализация алгоритма DES
+// DESCipher реализация алгоритма DES и его вариантов
 type DESCipher struct {
 	feistelNetwork *FeistelNetwork
+	variant        DESVariant
+	preWhitening   []byte // K1 для DESX
+	postWhitening  []byte // K2 для DESX
 }
 
 // NewDESCipher создает новый шифр DES
@@ -305,27 +278,140 @@ func NewDESCipher() *DESCipher {
 	keyExpansion := &DESKeyExpansion{}
 	roundFunction := &DESRoundFunction{}
 	feistelNetwork := NewFeistelNetwork(keyExpansion, roundFunction, 16)
-	return &DESCipher{feistelNetwork: feistelNetwork}
+	return &DESCipher{feistelNetwork: feistelNetwork, variant: DESStandard}
+}
+
+// NewDESCipherWithOptions создает шифр выбранного варианта DES.
+// Все варианты используют общую сеть Фейстеля и раундовую функцию DES.
+func NewDESCipherWithOptions(opts DESOptions) (*DESCipher, error) {
+	if opts.SBoxes != nil {
+		if err := ValidateSBoxes(opts.SBoxes); err != nil {
+			return nil, fmt.Errorf("некорректные S-блоки: %w", err)
+		}
+		boxes := *opts.SBoxes
+		opts.SBoxes = &boxes
+	}
+
+	keyExpansion := &DESKeyExpansion{}
+	roundFunction := &DESRoundFunction{sBoxes: opts.SBoxes}
+
+	switch opts.Variant {
+	case DESStandard, DESX:
+		feistelNetwork := NewFeistelNetwork(keyExpansion, roundFunction, 16)
+		return &DESCipher{feistelNetwork: feistelNetwork, variant: opts.Variant}, nil
+	case GDES:
+		subBlocks := opts.SubBlocks
+		if subBlocks == 0 {
+			subBlocks = 4
+		}
+		if subBlocks < 2 {
+			return nil, fmt.Errorf("число подблоков GDES должно быть не менее 2, получено %d", subBlocks)
+		}
+		feistelNetwork := NewGeneralizedFeistelNetwork(keyExpansion, roundFunction, 16, subBlocks)
+		return &DESCipher{feistelNetwork: feistelNetwork, variant: GDES}, nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемый вариант DES: %v", opts.Variant)
+	}
+}
+
+// Variant возвращает вариант алгоритма
+func (des *DESCipher) Variant() DESVariant {
+	return des.variant
+}
+
+// BlockSize возвращает размер блока в байтах (8 для DES и DESX, 4*SubBlocks для GDES)
+func (des *DESCipher) BlockSize() int {
+	return des.feistelNetwork.BlockSize()
 }
 
+// KeySize возвращает ожидаемый размер ключа в байтах
+func (des *DESCipher) KeySize() int {
+	if des.variant == DESX {
+		return 24
+	}
+	return 8
+}
+
+// SetupKeys настраивает ключи. Для DESX ключ имеет длину 24 байта: K || K1 || K2,
+// где K - ключ DES, K1 и K2 - ключи пред- и постотбеливания.
 func (des *DESCipher) SetupKeys(key []byte) error {
-	return des.feistelNetwork.SetupKeys(key)
+	if des.variant != DESX {
+		return des.feistelNetwork.SetupKeys(key)
+	}
+
+	if len(key) != 24 {
+		return fmt.Errorf("ключ DESX должен быть 192 бита (24 байта), получено %d", len(key))
+	}
+	if err := des.feistelNetwork.SetupKeys(key[:8]); err != nil {
+		return err
+	}
+	zeroBytes(des.preWhitening)
+	zeroBytes(des.postWhitening)
+	des.preWhitening = append([]byte{}, key[8:16]...)
+	des.postWhitening = append([]byte{}, key[16:24]...)
+	return nil
 }
 
 func (des *DESCipher) EncryptBlock(block []byte) []byte {
-	afterIP := BitPermutation(block, initialPermutation, false, 1)
+	switch des.variant {
+	case GDES:
+		return des.feistelNetwork.EncryptBlock(block)
+	case DESX:
+		whitened := xorBlock(block, des.preWhitening)
+		return xorBlock(des.encryptDES(whitened), des.postWhitening)
+	default:
+		return des.encryptDES(block)
+	}
+}
+
+func (des *DESCipher) DecryptBlock(block []byte) []byte {
+	switch des.variant {
+	case GDES:
+		return des.feistelNetwork.DecryptBlock(block)
+	case DESX:
+		whitened := xorBlock(block, des.postWhitening)
+		return xorBlock(des.decryptDES(whitened), des.preWhitening)
+	default:
+		return des.decryptDES(block)
+	}
+}
+
+func (des *DESCipher) encryptDES(block []byte) []byte {
+	afterIP := ipPermutation.ApplyBytes(block)
 	afterFeistel := des.feistelNetwork.EncryptBlock(afterIP)
-	finalResult := BitPermutation(afterFeistel, finalPermutation, false, 1)
+	finalResult := fpPermutation.ApplyBytes(afterFeistel)
 	return finalResult
 }
 
-func (des *DESCipher) DecryptBlock(block []byte) []byte {
-	afterIP := BitPermutation(block, initialPermutation, false, 1)
+func (des *DESCipher) decryptDES(block []byte) []byte {
+	afterIP := ipPermutation.ApplyBytes(block)
 	afterFeistel := des.feistelNetwork.DecryptBlock(afterIP)
-	finalResult := BitPermutation(afterFeistel, finalPermutation, false, 1)
+	finalResult := fpPermutation.ApplyBytes(afterFeistel)
 	return finalResult
 }
 
+// xorBlock складывает блок с ключом отбеливания
+func xorBlock(block, whitening []byte) []byte {
+	if len(block) != 8 {
+		panic(fmt.Sprintf("блок DESX должен быть 64 бита (8 байт), получено %d", len(block)))
+	}
+	if len(whitening) != 8 {
+		panic("ключи отбеливания DESX не настроены")
+	}
+	result := make([]byte, 8)
+	for i := range result {
+		result[i] = block[i] ^ whitening[i]
+	}
+	return result
+}
+
+// Destroy затирает раундовые ключи DES и ключи отбеливания
+func (des *DESCipher) Destroy() {
+	des.feistelNetwork.Destroy()
+	zeroBytes(des.preWhitening)
+	zeroBytes(des.postWhitening)
+}
+
 // GenerateDESKey генерирует случайный 64-битный ключ для DES
 func GenerateDESKey() ([]byte, error) {
 	key := make([]byte, 8)
@@ -335,3 +421,12 @@ func GenerateDESKey() ([]byte, error) {
 	}
 	return key, nil
 }
+
+// GenerateDESXKey генерирует случайный 192-битный ключ DESX (K || K1 || K2)
+func GenerateDESXKey() ([]byte, error) {
+	key := make([]byte, 24)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("ошибка генерации случайного ключа DESX: %w", err)
+	}
+	return key, nil
+}
@@ -2,22 +2,46 @@ package main
 
 import "fmt"
 
+// FeistelNetwork обобщенная сеть Фейстеля над 32-битными подблоками. При двух
+// подблоках это классическая схема DES; при большем числе подблоков - схема
+// GDES: результат раундовой функции от последнего подблока складывается
+// со всеми остальными, после чего подблоки циклически сдвигаются.
 type FeistelNetwork struct {
 	keyExpansion  KeyExpansion
 	roundFunction RoundFunction
 	numRounds     int
+	subBlocks     int
 	roundKeys     [][]byte
+	destroyed     bool
 }
 
 func NewFeistelNetwork(keyExpansion KeyExpansion, roundFunction RoundFunction, numRounds int) *FeistelNetwork {
+	return NewGeneralizedFeistelNetwork(keyExpansion, roundFunction, numRounds, 2)
+}
+
+// NewGeneralizedFeistelNetwork создает сеть с subBlocks 32-битными подблоками (блок 4*subBlocks байт)
+func NewGeneralizedFeistelNetwork(keyExpansion KeyExpansion, roundFunction RoundFunction, numRounds int, subBlocks int) *FeistelNetwork {
+	if subBlocks < 2 {
+		panic(fmt.Sprintf("число подблоков сети Фейстеля должно быть не менее 2, получено %d", subBlocks))
+	}
 	return &FeistelNetwork{
 		keyExpansion:  keyExpansion,
 		roundFunction: roundFunction,
 		numRounds:     numRounds,
+		subBlocks:     subBlocks,
 	}
 }
 
+// BlockSize возвращает размер блока сети в байтах
+func (fn *FeistelNetwork) BlockSize() int {
+	return 4 * fn.subBlocks
+}
+
 func (fn *FeistelNetwork) SetupKeys(key []byte) error {
+	if fn.destroyed {
+		return fmt.Errorf("сеть Фейстеля уничтожена, ключи не могут быть настроены")
+	}
+	zeroRoundKeys(fn.roundKeys)
 	fn.roundKeys = fn.keyExpansion.ExpandKey(key)
 	if len(fn.roundKeys) != fn.numRounds {
 		return fmt.Errorf("ожидается %d раундовых ключей, получено %d", fn.numRounds, len(fn.roundKeys))
@@ -26,49 +50,78 @@ func (fn *FeistelNetwork) SetupKeys(key []byte) error {
 }
 
 func (fn *FeistelNetwork) EncryptBlock(block []byte) []byte {
-	if len(block) != 8 {
-		panic(fmt.Sprintf("блок должен быть 64 бита (8 байт), получено %d", len(block)))
-	}
+	return fn.process(block, true)
+}
 
-	L := make([]byte, 4)
-	R := make([]byte, 4)
-	copy(L, block[:4])
-	copy(R, block[4:])
+func (fn *FeistelNetwork) DecryptBlock(block []byte) []byte {
+	return fn.process(block, false)
+}
 
-	for i := 0; i < fn.numRounds; i++ {
-		tempR := fn.xorBytes(L, fn.roundFunction.Apply(R, fn.roundKeys[i]))
-		L = R
-		R = tempR
+// process выполняет раунды сети. Расшифрование отличается от зашифрования
+// обратным порядком раундовых ключей и направлением циклического сдвига
+// подблоков (для двух подблоков оба сдвига совпадают с обменом L и R).
+func (fn *FeistelNetwork) process(block []byte, encrypt bool) []byte {
+	fn.checkUsable()
+	if len(block) != fn.BlockSize() {
+		panic(fmt.Sprintf("блок должен быть %d бит (%d байт), получено %d", fn.BlockSize()*8, fn.BlockSize(), len(block)))
 	}
 
-	result := make([]byte, 8)
-	copy(result[:4], R)
-	copy(result[4:], L)
-	return result
-}
-
-func (fn *FeistelNetwork) DecryptBlock(block []byte) []byte {
-	if len(block) != 8 {
-		panic(fmt.Sprintf("блок должен быть 64 бита (8 байт), получено %d", len(block)))
+	last := fn.subBlocks - 1
+	parts := make([][]byte, fn.subBlocks)
+	for i := range parts {
+		parts[i] = make([]byte, 4)
+		copy(parts[i], block[4*i:4*i+4])
 	}
 
-	L := make([]byte, 4)
-	R := make([]byte, 4)
-	copy(L, block[:4])
-	copy(R, block[4:])
+	for round := 0; round < fn.numRounds; round++ {
+		keyIndex := round
+		if !encrypt {
+			keyIndex = fn.numRounds - 1 - round
+		}
+
+		f := fn.roundFunction.Apply(parts[last], fn.roundKeys[keyIndex])
+		for i := 0; i < last; i++ {
+			parts[i] = fn.xorBytes(parts[i], f)
+		}
 
-	for i := fn.numRounds - 1; i >= 0; i-- {
-		tempR := fn.xorBytes(L, fn.roundFunction.Apply(R, fn.roundKeys[i]))
-		L = R
-		R = tempR
+		// После последнего раунда сдвиг не выполняется
+		if round == fn.numRounds-1 {
+			break
+		}
+
+		rotated := make([][]byte, fn.subBlocks)
+		for i := range parts {
+			if encrypt {
+				rotated[(i+1)%fn.subBlocks] = parts[i]
+			} else {
+				rotated[i] = parts[(i+1)%fn.subBlocks]
+			}
+		}
+		parts = rotated
 	}
 
-	result := make([]byte, 8)
-	copy(result[:4], R)
-	copy(result[4:], L)
+	result := make([]byte, 0, fn.BlockSize())
+	for _, part := range parts {
+		result = append(result, part...)
+	}
 	return result
 }
 
+// Destroy затирает раундовые ключи и запрещает дальнейшее использование сети
+func (fn *FeistelNetwork) Destroy() {
+	zeroRoundKeys(fn.roundKeys)
+	fn.destroyed = true
+}
+
+func (fn *FeistelNetwork) checkUsable() {
+	if fn.destroyed {
+		panic("сеть Фейстеля уничтожена, использование невозможно")
+	}
+	if fn.roundKeys == nil {
+		panic("раундовые ключи не настроены")
+	}
+}
+
 func (fn *FeistelNetwork) xorBytes(a, b []byte) []byte {
 	result := make([]byte, len(a))
 	for i := 0; i < len(a) && i < len(b); i++ {
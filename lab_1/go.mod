@@ -1,3 +1,3 @@
-module main
+module lab_1
 
 go 1.25.1
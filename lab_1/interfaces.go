@@ -23,3 +23,9 @@ type SymmetricCipher interface {
 	// DecryptBlock дешифрует блок данных
 	DecryptBlock(block []byte) []byte
 }
+
+// Destroyable интерфейс для шифров, умеющих затирать ключевой материал
+type Destroyable interface {
+	// Destroy обнуляет ключи и раундовые ключи; после вызова шифр непригоден к использованию
+	Destroy()
+}
@@ -12,6 +12,7 @@ func main() {
 	demonstrateBitPermutation()
 	demonstrateKeyGeneration()
 	demonstrateDES()
+	demonstrateDESVariants()
 	demonstrateDEAL()
 	demonstratePaddingModes()
 
@@ -21,6 +22,7 @@ func main() {
 	encryptDES_ECB()
 	testStandardDES()
 	demonstrateMyFileEncryption()
+	demonstrateKeyDestruction()
 }
 
 func demonstrateKeyGeneration() {
@@ -74,6 +76,36 @@ func demonstrateBitPermutation() {
 	restored := BitPermutation(result, reverseTable, false, 1)
 	fmt.Printf("Восстановленные: %08b %08b\n", restored[0], restored[1])
 	fmt.Printf("Корректность: %v\n\n", string(data) == string(restored))
+
+	fmt.Println("СКОМПИЛИРОВАННАЯ ПЕРЕСТАНОВКА")
+	compiled, err := CompilePermutation(permTable, 16, false, 1, true)
+	if err != nil {
+		fmt.Printf("Ошибка компиляции перестановки: %v\n", err)
+		return
+	}
+	compiledResult := compiled.ApplyBytes(data)
+	fmt.Printf("ApplyBytes: %08b %08b (совпадает с BitPermutation: %v)\n",
+		compiledResult[0], compiledResult[1], string(compiledResult) == string(result))
+	fmt.Printf("Apply(0x%04X) = 0x%04X\n", 0xABCD, compiled.Apply(0xABCD))
+
+	inverse, err := compiled.Inverse()
+	if err != nil {
+		fmt.Printf("Ошибка построения обратной перестановки: %v\n", err)
+		return
+	}
+	fmt.Printf("Обратная перестановка восстанавливает данные: %v\n",
+		string(inverse.ApplyBytes(compiledResult)) == string(data))
+
+	fpFromIP, err := ipPermutation.Inverse()
+	if err != nil {
+		fmt.Printf("Ошибка обращения IP: %v\n", err)
+		return
+	}
+	fmt.Printf("IP^-1 совпадает с таблицей FP DES: %v\n",
+		fmt.Sprint(fpFromIP.Table()) == fmt.Sprint(finalPermutation))
+
+	_, err = CompilePermutation([]int{1, 2, 2, 4}, 4, false, 1, true)
+	fmt.Printf("Проверка таблицы с повтором: %v\n\n", err)
 }
 
 func demonstrateDES() {
@@ -136,6 +168,97 @@ func demonstrateDES() {
 	fmt.Println()
 }
 
+func demonstrateDESVariants() {
+	fmt.Println("ВАРИАНТЫ DES")
+
+	key := []byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}
+	plaintext := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xAB, 0xCD, 0xEF}
+
+	standard := NewDESCipher()
+	if err := standard.SetupKeys(key); err != nil {
+		fmt.Printf("Ошибка настройки ключей: %v\n", err)
+		return
+	}
+	standardCiphertext := standard.EncryptBlock(plaintext)
+
+	// DESX: с нулевыми ключами отбеливания совпадает с DES
+	fmt.Println("\n--- DESX ---")
+	desx, err := NewDESCipherWithOptions(DESOptions{Variant: DESX})
+	if err != nil {
+		fmt.Printf("Ошибка создания DESX: %v\n", err)
+		return
+	}
+	if err := desx.SetupKeys(append(append([]byte{}, key...), make([]byte, 16)...)); err != nil {
+		fmt.Printf("Ошибка настройки ключей DESX: %v\n", err)
+		return
+	}
+	fmt.Printf("DESX с K1 = K2 = 0 совпадает с DES: %v\n", string(desx.EncryptBlock(plaintext)) == string(standardCiphertext))
+
+	desxKey, _ := GenerateDESXKey()
+	desxCtx, err := NewCipherContext(desx, desxKey, CBC, PKCS7, nil, desx.BlockSize())
+	if err != nil {
+		fmt.Printf("Ошибка создания контекста DESX: %v\n", err)
+		return
+	}
+	message := []byte("DESX whitening keys protect against exhaustive key search")
+	enc, _ := desxCtx.Encrypt(message)
+	dec, _ := desxCtx.Decrypt(enc)
+	fmt.Printf("Ключ DESX: %X\n", desxKey)
+	fmt.Printf("DESX CBC корректность: %v\n", string(dec) == string(message))
+
+	// Пользовательские S-блоки из файла
+	fmt.Println("\n--- Пользовательские S-блоки ---")
+	custom := sBoxes
+	for i := 0; i < 4; i++ {
+		custom[i], custom[7-i] = custom[7-i], custom[i]
+	}
+	sboxFile, err := os.CreateTemp("", "sboxes-*.txt")
+	if err != nil {
+		fmt.Printf("Ошибка создания файла S-блоков: %v\n", err)
+		return
+	}
+	defer os.Remove(sboxFile.Name())
+	sboxFile.WriteString(FormatSBoxes(&custom))
+	sboxFile.Close()
+
+	loaded, err := LoadSBoxesFromFile(sboxFile.Name())
+	if err != nil {
+		fmt.Printf("Ошибка загрузки S-блоков: %v\n", err)
+		return
+	}
+	customDES, err := NewDESCipherWithOptions(DESOptions{Variant: DESStandard, SBoxes: loaded})
+	if err != nil {
+		fmt.Printf("Ошибка создания DES с пользовательскими S-блоками: %v\n", err)
+		return
+	}
+	customDES.SetupKeys(key)
+	customCiphertext := customDES.EncryptBlock(plaintext)
+	fmt.Printf("S-блоки в обратном порядке: %X (стандартный DES: %X)\n", customCiphertext, standardCiphertext)
+	fmt.Printf("Корректность: %v\n", string(customDES.DecryptBlock(customCiphertext)) == string(plaintext))
+
+	weak := sBoxes
+	for col := range weak[0][0] {
+		weak[0][0][col] = col
+	}
+	fmt.Printf("Проверка слабых S-блоков: %v\n", ValidateSBoxes(&weak))
+
+	// GDES с четырьмя подблоками (блок 128 бит)
+	fmt.Println("\n--- GDES ---")
+	gdes, err := NewDESCipherWithOptions(DESOptions{Variant: GDES, SubBlocks: 4})
+	if err != nil {
+		fmt.Printf("Ошибка создания GDES: %v\n", err)
+		return
+	}
+	gdesCtx, err := NewCipherContext(gdes, key, CBC, PKCS7, nil, gdes.BlockSize())
+	if err != nil {
+		fmt.Printf("Ошибка создания контекста GDES: %v\n", err)
+		return
+	}
+	enc, _ = gdesCtx.Encrypt(message)
+	dec, _ = gdesCtx.Decrypt(enc)
+	fmt.Printf("GDES (q = 4, блок %d байт) CBC корректность: %v\n\n", gdes.BlockSize(), string(dec) == string(message))
+}
+
 func demonstrateDEAL() {
 	fmt.Println("ДЕМОНСТРАЦИЯ РАБОТЫ DEAL")
 
@@ -550,3 +673,82 @@ func demonstrate3DESWithModes() {
 	fmt.Println()
 }
 
+
+// allZero проверяет, что все байты среза равны нулю
+func allZero(b []byte) bool {
+	for _, v := range b {
+		if v != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// allRoundKeysZero проверяет, что все раундовые ключи затерты
+func allRoundKeysZero(roundKeys [][]byte) bool {
+	for _, rk := range roundKeys {
+		if !allZero(rk) {
+			return false
+		}
+	}
+	return true
+}
+
+func demonstrateKeyDestruction() {
+	fmt.Println("\nЗАТИРАНИЕ КЛЮЧЕВОГО МАТЕРИАЛА")
+
+	key := []byte{0x13, 0x34, 0x57, 0x79, 0x9B, 0xBC, 0xDF, 0xF1}
+	iv := []byte{0x12, 0x34, 0x56, 0x78, 0x9A, 0xBC, 0xDE, 0xF0}
+
+	// DES через контекст
+	desCipher := NewDESCipher()
+	ctx, err := NewCipherContext(desCipher, key, CBC, PKCS7, iv, 8)
+	if err != nil {
+		fmt.Printf("Ошибка создания контекста: %v\n", err)
+		return
+	}
+	if _, err := ctx.Encrypt([]byte("secret data")); err != nil {
+		fmt.Printf("Ошибка шифрования: %v\n", err)
+		return
+	}
+	if err := ctx.Close(); err != nil {
+		fmt.Printf("Ошибка закрытия контекста: %v\n", err)
+		return
+	}
+	fmt.Printf("DES: ключ контекста обнулен: %v\n", allZero(ctx.key))
+	fmt.Printf("DES: IV контекста обнулен: %v\n", allZero(ctx.iv))
+	fmt.Printf("DES: раундовые ключи обнулены: %v\n", allRoundKeysZero(desCipher.feistelNetwork.roundKeys))
+	_, err = ctx.Encrypt([]byte("more data"))
+	fmt.Printf("DES: повторное использование отклонено: %v (%v)\n", err != nil, err)
+	fmt.Printf("DES: повторная настройка ключей отклонена: %v\n", desCipher.SetupKeys(key) != nil)
+
+	// 3DES
+	tdesKey, _ := Generate3DESKey(1)
+	tdes := NewTripleDESCipher()
+	if err := tdes.SetupKeys(tdesKey); err != nil {
+		fmt.Printf("Ошибка настройки ключей 3DES: %v\n", err)
+		return
+	}
+	tdes.Destroy()
+	fmt.Printf("3DES: раундовые ключи обнулены: %v\n",
+		allRoundKeysZero(tdes.des1.feistelNetwork.roundKeys) &&
+			allRoundKeysZero(tdes.des2.feistelNetwork.roundKeys) &&
+			allRoundKeysZero(tdes.des3.feistelNetwork.roundKeys))
+
+	// DEAL через контекст
+	dealKey, _ := GenerateDEALKey(128)
+	dealIV := make([]byte, 16)
+	dealCtx := NewDEALCipherContext(dealKey, CBC, PKCS7, dealIV)
+	dealCipher := dealCtx.cipher.(*DEALCipher)
+	dealCtx.Destroy()
+	fmt.Printf("DEAL: ключ контекста обнулен: %v\n", allZero(dealCtx.key))
+	fmt.Printf("DEAL: раундовые ключи обнулены: %v\n", allRoundKeysZero(dealCipher.roundKeys))
+
+	func() {
+		defer func() {
+			r := recover()
+			fmt.Printf("DEAL: шифрование блока после уничтожения отклонено: %v\n", r != nil)
+		}()
+		dealCipher.EncryptBlock(make([]byte, 16))
+	}()
+}
@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"math/bits"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SBoxSet набор из восьми S-блоков DES (4 строки по 16 значений)
+type SBoxSet [8][4][16]int
+
+// maxLinearDeviation наибольшее допустимое отклонение числа совпадений выходного
+// бита S-блока с линейной функцией входа от 32 (из 64). Это максимум среди
+// стандартных S-блоков DES.
+const maxLinearDeviation = 18
+
+// maxDifferentialCount наибольшее допустимое число входов x (из 64), дающих
+// одинаковую разность выходов при фиксированной ненулевой разности входов,
+// то есть не более 8 пар (критерий S-7)
+const maxDifferentialCount = 16
+
+// sBoxLookup возвращает значение S-блока для 6-битного входа b1..b6:
+// строка задается битами b1b6, столбец - битами b2..b5
+func sBoxLookup(box *[4][16]int, x int) int {
+	return box[(x>>4)&0x2|x&0x1][(x>>1)&0xF]
+}
+
+// ValidateSBoxes проверяет набор S-блоков на критерии проектирования DES
+// (S-1...S-7, опубликованные Копперсмитом). Возвращает ошибку с номером
+// S-блока и первым нарушенным критерием.
+func ValidateSBoxes(sboxes *SBoxSet) error {
+	for i := range sboxes {
+		if err := validateSBox(&sboxes[i]); err != nil {
+			return fmt.Errorf("S-блок %d: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+func validateSBox(box *[4][16]int) error {
+	// S-1: 6 входных и 4 выходных бита
+	for row := range box {
+		for col, v := range box[row] {
+			if v < 0 || v > 15 {
+				return fmt.Errorf("S-1: значение %d в строке %d, столбце %d не помещается в 4 бита", v, row, col)
+			}
+		}
+	}
+
+	// S-3: каждая строка - перестановка чисел 0..15
+	for row := range box {
+		var seen [16]bool
+		for _, v := range box[row] {
+			if seen[v] {
+				return fmt.Errorf("S-3: строка %d не является перестановкой 0..15", row)
+			}
+			seen[v] = true
+		}
+	}
+
+	// S-2: ни один выходной бит не близок к линейной функции входа
+	for outBit := 0; outBit < 4; outBit++ {
+		for mask := 1; mask < 64; mask++ {
+			agree := 0
+			for x := 0; x < 64; x++ {
+				if (sBoxLookup(box, x)>>uint(outBit))&1 == bits.OnesCount(uint(x&mask))&1 {
+					agree++
+				}
+			}
+			if deviation := agree - 32; deviation > maxLinearDeviation || -deviation > maxLinearDeviation {
+				return fmt.Errorf("S-2: выходной бит %d совпадает с линейной функцией входа (маска %06b) в %d случаях из 64",
+					outBit, mask, agree)
+			}
+		}
+	}
+
+	for x := 0; x < 64; x++ {
+		y := sBoxLookup(box, x)
+
+		// S-4: изменение одного входного бита меняет не менее двух выходных
+		for bit := 0; bit < 6; bit++ {
+			if bits.OnesCount(uint(y^sBoxLookup(box, x^(1<<uint(bit))))) < 2 {
+				return fmt.Errorf("S-4: изменение бита %d входа %06b меняет менее двух выходных бит", 6-bit, x)
+			}
+		}
+
+		// S-5: S(x) и S(x ^ 001100) различаются не менее чем в двух битах
+		if bits.OnesCount(uint(y^sBoxLookup(box, x^0x0C))) < 2 {
+			return fmt.Errorf("S-5: S(%06b) и S(%06b) различаются менее чем в двух битах", x, x^0x0C)
+		}
+
+		// S-6: S(x) != S(x ^ 11ef00) для любых e, f
+		for ef := 0; ef < 4; ef++ {
+			diff := 0x30 | ef<<2
+			if y == sBoxLookup(box, x^diff) {
+				return fmt.Errorf("S-6: S(%06b) = S(%06b)", x, x^diff)
+			}
+		}
+	}
+
+	// S-7: для любой ненулевой входной разности не более 8 пар дают одну выходную разность
+	for inDiff := 1; inDiff < 64; inDiff++ {
+		var counts [16]int
+		for x := 0; x < 64; x++ {
+			counts[sBoxLookup(box, x)^sBoxLookup(box, x^inDiff)]++
+		}
+		for outDiff, c := range counts {
+			if c > maxDifferentialCount {
+				return fmt.Errorf("S-7: разность %06b -> %04b встречается у %d пар", inDiff, outDiff, c/2)
+			}
+		}
+	}
+
+	return nil
+}
+
+// ParseSBoxes разбирает текстовое описание 8 S-блоков: 512 чисел, разделенных
+// пробелами, запятыми или переводами строк, в порядке S1 (строки 0..3), S2, ...
+// Текст после '#' до конца строки считается комментарием.
+func ParseSBoxes(text string) (*SBoxSet, error) {
+	var values []int
+	for lineNum, line := range strings.Split(text, "\n") {
+		if idx := strings.Index(line, "#"); idx != -1 {
+			line = line[:idx]
+		}
+		fields := strings.FieldsFunc(line, func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t' || r == '\r' || r == '{' || r == '}'
+		})
+		for _, field := range fields {
+			v, err := strconv.Atoi(field)
+			if err != nil {
+				return nil, fmt.Errorf("строка %d: некорректное значение %q", lineNum+1, field)
+			}
+			values = append(values, v)
+		}
+	}
+
+	if len(values) != 8*4*16 {
+		return nil, fmt.Errorf("ожидается %d значений S-блоков, получено %d", 8*4*16, len(values))
+	}
+
+	sboxes := &SBoxSet{}
+	for i, v := range values {
+		sboxes[i/64][(i/16)%4][i%16] = v
+	}
+	return sboxes, nil
+}
+
+// LoadSBoxesFromFile загружает набор S-блоков из файла и проверяет его
+// на критерии проектирования DES
+func LoadSBoxesFromFile(path string) (*SBoxSet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка чтения файла S-блоков: %w", err)
+	}
+
+	sboxes, err := ParseSBoxes(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка разбора файла S-блоков: %w", err)
+	}
+
+	if err := ValidateSBoxes(sboxes); err != nil {
+		return nil, fmt.Errorf("S-блоки не удовлетворяют критериям DES: %w", err)
+	}
+	return sboxes, nil
+}
+
+// FormatSBoxes записывает набор S-блоков в текстовом формате, понятном ParseSBoxes
+func FormatSBoxes(sboxes *SBoxSet) string {
+	var sb strings.Builder
+	for i := range sboxes {
+		fmt.Fprintf(&sb, "# S%d\n", i+1)
+		for row := range sboxes[i] {
+			for col, v := range sboxes[i][row] {
+				if col > 0 {
+					sb.WriteString(" ")
+				}
+				fmt.Fprintf(&sb, "%2d", v)
+			}
+			sb.WriteString("\n")
+		}
+	}
+	return sb.String()
+}
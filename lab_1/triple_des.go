@@ -110,6 +110,14 @@ func (tdes *TripleDESCipher) DecryptBlock(block []byte) []byte {
 	return result
 }
 
+// Destroy затирает раундовые ключи всех трех экземпляров DES
+func (tdes *TripleDESCipher) Destroy() {
+	tdes.des1.Destroy()
+	tdes.des2.Destroy()
+	tdes.des3.Destroy()
+	tdes.keyOption = 0
+}
+
 // Generate3DESKey генерирует случайный ключ для 3DES
 // keySize: 1 (24 байта, 3 ключа), 2 (16 байт, 2 ключа), 3 (8 байт, 1 ключ)
 func Generate3DESKey(keyOption int) ([]byte, error) {
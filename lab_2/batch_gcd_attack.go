@@ -0,0 +1,108 @@
+package main
+
+import "math/big"
+
+// WeakKey ключ, модуль которого разложен из-за общего множителя с другим ключом
+type WeakKey struct {
+	Index int // индекс ключа во входном списке
+	P     *big.Int
+	Q     *big.Int
+}
+
+type BatchGCDResult struct {
+	WeakKeys []WeakKey
+	Success  bool
+}
+
+// BatchGCDAttackService пакетный НОД Бернштейна: для множества модулей за
+// квазилинейное время находит те, что делят простой множитель с каким-либо
+// другим модулем (типичное следствие плохого генератора случайных чисел)
+type BatchGCDAttackService struct {
+	mathService *MathService
+}
+
+func NewBatchGCDAttackService() *BatchGCDAttackService {
+	return &BatchGCDAttackService{
+		mathService: NewMathService(),
+	}
+}
+
+func (bgs *BatchGCDAttackService) Attack(publicKeys []*RSAPublicKey) *BatchGCDResult {
+	result := &BatchGCDResult{
+		Success: false,
+	}
+	if len(publicKeys) < 2 {
+		return result
+	}
+
+	moduli := make([]*big.Int, len(publicKeys))
+	for i, key := range publicKeys {
+		moduli[i] = key.N
+	}
+
+	// Дерево произведений: levels[0] - модули, последний уровень - их произведение
+	levels := [][]*big.Int{moduli}
+	for current := moduli; len(current) > 1; {
+		next := make([]*big.Int, (len(current)+1)/2)
+		for i := range next {
+			if 2*i+1 < len(current) {
+				next[i] = new(big.Int).Mul(current[2*i], current[2*i+1])
+			} else {
+				next[i] = current[2*i]
+			}
+		}
+		levels = append(levels, next)
+		current = next
+	}
+
+	// Дерево остатков: спуск P mod x^2 от корня к листьям
+	remainders := levels[len(levels)-1]
+	for level := len(levels) - 2; level >= 0; level-- {
+		nodes := levels[level]
+		next := make([]*big.Int, len(nodes))
+		for i, node := range nodes {
+			square := new(big.Int).Mul(node, node)
+			next[i] = new(big.Int).Mod(remainders[i/2], square)
+		}
+		remainders = next
+	}
+
+	one := big.NewInt(1)
+	for i, n := range moduli {
+		// gcd(P/N_i mod N_i, N_i)
+		z := new(big.Int).Div(remainders[i], n)
+		factor := bgs.mathService.GCD(z, n)
+
+		if factor.Cmp(n) == 0 {
+			// Оба множителя общие с другими ключами: попарный НОД
+			factor = bgs.pairwiseFactor(moduli, i)
+		}
+		if factor == nil || factor.Cmp(one) == 0 {
+			continue
+		}
+
+		result.WeakKeys = append(result.WeakKeys, WeakKey{
+			Index: i,
+			P:     factor,
+			Q:     new(big.Int).Div(n, factor),
+		})
+	}
+
+	result.Success = len(result.WeakKeys) > 0
+	return result
+}
+
+// pairwiseFactor ищет нетривиальный делитель moduli[i] попарным НОД
+func (bgs *BatchGCDAttackService) pairwiseFactor(moduli []*big.Int, i int) *big.Int {
+	one := big.NewInt(1)
+	for j, other := range moduli {
+		if j == i {
+			continue
+		}
+		g := bgs.mathService.GCD(moduli[i], other)
+		if g.Cmp(one) != 0 && g.Cmp(moduli[i]) != 0 {
+			return g
+		}
+	}
+	return nil
+}
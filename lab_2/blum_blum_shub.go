@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// BlumBlumShub криптостойкий генератор x_{i+1} = x_i^2 mod n для числа Блюма
+// n = pq. С каждого шага берутся log2(log2 n) младших бит; предсказание
+// следующего бита так же трудно, как различение квадратичных вычетов по
+// модулю n. Реализует io.Reader и подходит как источник для rand.Int и т.п.
+type BlumBlumShub struct {
+	n           *big.Int
+	state       *big.Int
+	bitsPerStep int
+
+	buffer   uint64 // еще не выданные биты
+	buffered int
+}
+
+// NewBlumBlumShub создает генератор по модулю n и начальному значению seed.
+// Разложение n не требуется; проверяются лишь необходимые условия для числа
+// Блюма: n = 1 (mod 4) и (-1/n) = 1.
+func NewBlumBlumShub(n, seed *big.Int) (*BlumBlumShub, error) {
+	ms := NewMathService()
+	if n == nil || n.BitLen() < 16 || new(big.Int).Mod(n, big.NewInt(4)).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("modulus must be a Blum integer")
+	}
+	if ms.JacobiSymbol(new(big.Int).Sub(n, big.NewInt(1)), n) != 1 {
+		return nil, errors.New("modulus must be a Blum integer")
+	}
+	if seed == nil || ms.GCD(new(big.Int).Mod(seed, n), n).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("seed must be coprime to the modulus")
+	}
+
+	// x_0 = seed^2 лежит в подгруппе квадратов, где возведение в квадрат - биекция
+	state := new(big.Int).Mul(seed, seed)
+	state.Mod(state, n)
+	if state.Cmp(big.NewInt(1)) == 0 {
+		return nil, errors.New("seed generates a constant sequence")
+	}
+
+	return &BlumBlumShub{
+		n:           new(big.Int).Set(n),
+		state:       state,
+		bitsPerStep: max(1, bits.Len(uint(n.BitLen()))-1),
+	}, nil
+}
+
+// GenerateBlumBlumShub строит генератор с новым числом Блюма длины bitLength
+// и случайным начальным значением
+func GenerateBlumBlumShub(testType PrimalityTestType, minProbability float64, bitLength int) (*BlumBlumShub, error) {
+	ms := NewMathService()
+	p, q, err := generateBlumPrimes(NewRSAKeyGenerator(testType, minProbability, bitLength/2, ms))
+	if err != nil {
+		return nil, err
+	}
+	n := new(big.Int).Mul(p, q)
+	for {
+		seed, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if g, err := NewBlumBlumShub(n, seed); err == nil {
+			return g, nil
+		}
+	}
+}
+
+// Modulus возвращает модуль генератора
+func (g *BlumBlumShub) Modulus() *big.Int {
+	return new(big.Int).Set(g.n)
+}
+
+// BitsPerStep число бит, извлекаемых на каждом возведении в квадрат
+func (g *BlumBlumShub) BitsPerStep() int {
+	return g.bitsPerStep
+}
+
+// Read заполняет p псевдослучайными байтами; ошибок не возвращает
+func (g *BlumBlumShub) Read(p []byte) (int, error) {
+	for i := range p {
+		for g.buffered < 8 {
+			g.step()
+		}
+		g.buffered -= 8
+		p[i] = byte(g.buffer >> g.buffered)
+	}
+	return len(p), nil
+}
+
+// step делает один шаг x = x^2 mod n и добавляет младшие биты x в буфер
+func (g *BlumBlumShub) step() {
+	g.state.Mul(g.state, g.state).Mod(g.state, g.n)
+	mask := uint64(1)<<g.bitsPerStep - 1
+	var low uint64
+	if words := g.state.Bits(); len(words) > 0 {
+		low = uint64(words[0])
+	}
+	g.buffer = g.buffer<<g.bitsPerStep | low&mask
+	g.buffered += g.bitsPerStep
+}
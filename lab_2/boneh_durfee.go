@@ -0,0 +1,536 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"sort"
+)
+
+// BonehDurfeeExponent асимптотическая граница атаки Боне-Дерфи: ключи с
+// d < n^0.292 считаются уязвимыми
+const BonehDurfeeExponent = 0.292
+
+type BonehDurfeeAttackResult struct {
+	D                *big.Int
+	P                *big.Int
+	Q                *big.Int
+	LatticeDimension int
+	Success          bool
+}
+
+// BonehDurfeeAttackService атака Боне-Дерфи на малую секретную экспоненту.
+// Из ed = 1 + k*phi(n) при A = (n+1)/2 следует, что f(x, y) = 1 + x(A + y)
+// имеет малый корень x0 = 2k, y0 = -(p+q)/2 по модулю e. Метод Копперсмита:
+// из сдвигов f строится решетка, LLL дает многочлены с тем же корнем над Z,
+// а корень y0 находится как целый корень их результанта по x.
+// Решетка строится с линеаризацией Херрманна-Мэя u = xy + 1 (f = u + Ax),
+// что дает ту же асимптотическую границу d < n^0.292 при меньшей размерности.
+// При конечном m достижимая граница ниже: для 256-битного n m = 4, t = 2
+// (размерность 19) восстанавливает d ~ n^0.26, m = 5 - d ~ n^0.265.
+type BonehDurfeeAttackService struct {
+	mathService *MathService
+	m           int // наибольшая степень f в сдвигах
+	t           int // наибольшая степень y в y-сдвигах (0 - выбирается по delta)
+}
+
+func NewBonehDurfeeAttackService(m, t int) *BonehDurfeeAttackService {
+	return &BonehDurfeeAttackService{
+		mathService: NewMathService(),
+		m:           m,
+		t:           t,
+	}
+}
+
+// log2Big приближенно вычисляет log2(x) для x > 0
+func log2Big(x *big.Int) float64 {
+	shift := max(0, x.BitLen()-64)
+	top := new(big.Int).Rsh(x, uint(shift))
+	return float64(shift) + math.Log2(float64(top.Uint64()))
+}
+
+// BelowBonehDurfeeBound сообщает, что d < n^0.292, то есть ключ уязвим к атаке
+// Боне-Дерфи при достаточно большой решетке
+func BelowBonehDurfeeBound(n, d *big.Int) bool {
+	return log2Big(d) < BonehDurfeeExponent*log2Big(n)
+}
+
+// monomial одночлен u^U * x^X * y^Y
+type monomial struct{ U, X, Y int }
+
+// latticePoly многочлен от u, x, y с целыми коэффициентами
+type latticePoly map[monomial]*big.Int
+
+func (p latticePoly) add(mono monomial, c *big.Int) {
+	if p[mono] == nil {
+		p[mono] = new(big.Int)
+	}
+	p[mono].Add(p[mono], c)
+}
+
+func (p latticePoly) mul(q latticePoly) latticePoly {
+	result := make(latticePoly)
+	for m1, c1 := range p {
+		for m2, c2 := range q {
+			result.add(monomial{m1.U + m2.U, m1.X + m2.X, m1.Y + m2.Y}, new(big.Int).Mul(c1, c2))
+		}
+	}
+	return result
+}
+
+func (p latticePoly) scale(c *big.Int) latticePoly {
+	result := make(latticePoly, len(p))
+	for m, coef := range p {
+		result[m] = new(big.Int).Mul(coef, c)
+	}
+	return result
+}
+
+func binomial(n, k int) *big.Int {
+	return new(big.Int).Binomial(int64(n), int64(k))
+}
+
+// reduceXY заменяет каждое произведение xy на u - 1, так что в результате
+// ни один одночлен не содержит x и y одновременно
+func (p latticePoly) reduceXY() latticePoly {
+	result := make(latticePoly)
+	for mono, c := range p {
+		s := min(mono.X, mono.Y)
+		// (xy)^s = (u - 1)^s = sum C(s, i) u^i (-1)^(s-i)
+		for i := 0; i <= s; i++ {
+			term := new(big.Int).Mul(c, binomial(s, i))
+			if (s-i)%2 == 1 {
+				term.Neg(term)
+			}
+			result.add(monomial{mono.U + i, mono.X - s, mono.Y - s}, term)
+		}
+	}
+	return result
+}
+
+// substituteU подставляет u = xy + 1 и возвращает многочлен только от x и y
+func (p latticePoly) substituteU() latticePoly {
+	result := make(latticePoly)
+	for mono, c := range p {
+		// u^a = (xy + 1)^a = sum C(a, i) (xy)^i
+		for i := 0; i <= mono.U; i++ {
+			term := new(big.Int).Mul(c, binomial(mono.U, i))
+			result.add(monomial{0, mono.X + i, mono.Y + i}, term)
+		}
+	}
+	return result
+}
+
+// degrees возвращает степени многочлена от x и y по x и по y
+func (p latticePoly) degrees() (int, int) {
+	degX, degY := 0, 0
+	for m, c := range p {
+		if c.Sign() == 0 {
+			continue
+		}
+		degX = max(degX, m.X)
+		degY = max(degY, m.Y)
+	}
+	return degX, degY
+}
+
+// coefficientsInX возвращает коэффициенты многочлена по x после подстановки y
+func (p latticePoly) coefficientsInX(y *big.Int, degX int) []*big.Int {
+	coeffs := make([]*big.Int, degX+1)
+	for i := range coeffs {
+		coeffs[i] = new(big.Int)
+	}
+	for m, c := range p {
+		term := new(big.Int).Exp(y, big.NewInt(int64(m.Y)), nil)
+		coeffs[m.X].Add(coeffs[m.X], term.Mul(term, c))
+	}
+	return coeffs
+}
+
+func (bds *BonehDurfeeAttackService) Attack(publicKey *RSAPublicKey, delta float64) (*BonehDurfeeAttackResult, error) {
+	result := &BonehDurfeeAttackResult{
+		Success: false,
+	}
+	if delta <= 0 || delta >= 0.5 {
+		return nil, errors.New("delta must be in (0, 0.5)")
+	}
+
+	n, e := publicKey.N, publicKey.E
+	m := bds.m
+	t := bds.t
+	if t <= 0 {
+		t = max(1, int((1-2*delta)*float64(m)))
+	}
+
+	// Границы корня: |x0| < X = 2 n^delta, |y0| < Y ~ sqrt(n), |u0| < U = XY + 1
+	X := new(big.Int).Lsh(big.NewInt(1), uint(math.Ceil(delta*float64(n.BitLen())))+1)
+	Y := new(big.Int).Sqrt(n)
+	U := new(big.Int).Mul(X, Y)
+	U.Add(U, big.NewInt(1))
+
+	A := new(big.Int).Add(n, big.NewInt(1))
+	A.Rsh(A, 1)
+	f := latticePoly{
+		monomial{1, 0, 0}: big.NewInt(1),
+		monomial{0, 1, 0}: A,
+	}
+
+	powers := []latticePoly{{monomial{}: big.NewInt(1)}}
+	for k := 1; k <= m; k++ {
+		powers = append(powers, powers[k-1].mul(f))
+	}
+	ePowers := make([]*big.Int, m+1)
+	for k := 0; k <= m; k++ {
+		ePowers[k] = new(big.Int).Exp(e, big.NewInt(int64(m-k)), nil)
+	}
+
+	// Сдвиги в порядке, при котором базис треугольный: x-сдвиги x^i f^k e^(m-k)
+	// со старшим одночленом u^k x^i, затем выбранные y-сдвиги y^j f^k e^(m-k)
+	// (k >= floor(m/t) j) со старшим одночленом u^k y^j
+	var shifts []latticePoly
+	var columns []monomial
+	for k := 0; k <= m; k++ {
+		for i := 0; i <= m-k; i++ {
+			shift := latticePoly{monomial{0, i, 0}: big.NewInt(1)}
+			shifts = append(shifts, shift.mul(powers[k]).scale(ePowers[k]))
+			columns = append(columns, monomial{k, i, 0})
+		}
+	}
+	for j := 1; j <= t; j++ {
+		for k := (m / t) * j; k <= m; k++ {
+			shift := latticePoly{monomial{0, 0, j}: big.NewInt(1)}
+			shifts = append(shifts, shift.mul(powers[k]).reduceXY().scale(ePowers[k]))
+			columns = append(columns, monomial{k, 0, j})
+		}
+	}
+	result.LatticeDimension = len(shifts)
+
+	columnIndex := make(map[monomial]int, len(columns))
+	weights := make([]*big.Int, len(columns))
+	for i, c := range columns {
+		columnIndex[c] = i
+		weights[i] = new(big.Int).Exp(U, big.NewInt(int64(c.U)), nil)
+		weights[i].Mul(weights[i], new(big.Int).Exp(X, big.NewInt(int64(c.X)), nil))
+		weights[i].Mul(weights[i], new(big.Int).Exp(Y, big.NewInt(int64(c.Y)), nil))
+	}
+
+	// Строка решетки - коэффициенты g(uU, xX, yY)
+	basis := make([][]*big.Int, len(shifts))
+	for r, g := range shifts {
+		row := make([]*big.Int, len(columns))
+		for i := range row {
+			row[i] = new(big.Int)
+		}
+		for mono, c := range g {
+			if c.Sign() == 0 {
+				continue
+			}
+			index, ok := columnIndex[mono]
+			if !ok {
+				return nil, errors.New("lattice basis is not triangular")
+			}
+			row[index].Mul(c, weights[index])
+		}
+		basis[r] = row
+	}
+
+	reduced, err := LLLReduce(basis, big.NewRat(3, 4))
+	if err != nil {
+		return nil, err
+	}
+
+	// Короткие векторы обратно в многочлены (деление на U^a X^b Y^c точное),
+	// затем подстановка u = xy + 1
+	candidates := make([]latticePoly, 0, 4)
+	for _, v := range reduced[:min(4, len(reduced))] {
+		poly := make(latticePoly)
+		for i, c := range v {
+			if c.Sign() != 0 {
+				poly[columns[i]] = new(big.Int).Quo(c, weights[i])
+			}
+		}
+		candidates = append(candidates, poly.substituteU())
+	}
+
+	bound := new(big.Int).Lsh(Y, 1)
+	for i := 0; i < len(candidates); i++ {
+		for j := i + 1; j < len(candidates); j++ {
+			res := resultantInX(candidates[i], candidates[j])
+			if res == nil {
+				continue
+			}
+			for _, y0 := range integerRoots(res, bound) {
+				if bds.recoverKey(publicKey, y0, result) {
+					return result, nil
+				}
+			}
+		}
+	}
+	return result, nil
+}
+
+// recoverKey по кандидату y0 = -(p+q)/2 восстанавливает p, q и d
+func (bds *BonehDurfeeAttackService) recoverKey(publicKey *RSAPublicKey, y0 *big.Int, result *BonehDurfeeAttackResult) bool {
+	n := publicKey.N
+	s := new(big.Int).Lsh(y0, 1)
+	s.Neg(s) // p + q
+	if s.Sign() <= 0 {
+		return false
+	}
+
+	// p, q - корни z^2 - s*z + n
+	discriminant := new(big.Int).Mul(s, s)
+	discriminant.Sub(discriminant, new(big.Int).Lsh(n, 2))
+	if discriminant.Sign() < 0 {
+		return false
+	}
+	root := new(big.Int).Sqrt(discriminant)
+	if new(big.Int).Mul(root, root).Cmp(discriminant) != 0 {
+		return false
+	}
+
+	p := new(big.Int).Add(s, root)
+	p.Rsh(p, 1)
+	q := new(big.Int).Sub(s, root)
+	q.Rsh(q, 1)
+	if new(big.Int).Mul(p, q).Cmp(n) != 0 {
+		return false
+	}
+
+	phi := new(big.Int).Sub(n, s)
+	phi.Add(phi, big.NewInt(1))
+	gcd, d, _ := bds.mathService.ExtendedGCD(publicKey.E, phi)
+	if gcd.Cmp(big.NewInt(1)) != 0 {
+		return false
+	}
+
+	result.D = d.Mod(d, phi)
+	result.P = p
+	result.Q = q
+	result.Success = true
+	return true
+}
+
+// resultantInX вычисляет Res_x(p, q) как многочлен от y (коэффициенты по
+// возрастанию степеней) вычислением в точках y = 0..D и интерполяцией.
+// Возвращает nil, если результант тождественно равен нулю.
+func resultantInX(p, q latticePoly) []*big.Int {
+	degXp, degYp := p.degrees()
+	degXq, degYq := q.degrees()
+
+	// Многочлен, не зависящий от x, сам является многочленом от y
+	if degXp == 0 || degXq == 0 {
+		poly := p
+		if degXp != 0 {
+			poly = q
+		}
+		_, degY := poly.degrees()
+		coeffs := make([]*big.Int, degY+1)
+		for i := range coeffs {
+			coeffs[i] = new(big.Int)
+		}
+		for mono, c := range poly {
+			coeffs[mono.Y].Add(coeffs[mono.Y], c)
+		}
+		return trimPoly(coeffs)
+	}
+
+	degree := degXp*degYq + degXq*degYp
+	values := make([]*big.Int, degree+1)
+	for k := range values {
+		y := big.NewInt(int64(k))
+		values[k] = sylvesterDeterminant(p.coefficientsInX(y, degXp), q.coefficientsInX(y, degXq))
+	}
+
+	return trimPoly(interpolate(values))
+}
+
+// sylvesterDeterminant вычисляет результант двух многочленов от x (коэффициенты
+// по возрастанию степеней) как определитель матрицы Сильвестра
+func sylvesterDeterminant(a, b []*big.Int) *big.Int {
+	degA, degB := len(a)-1, len(b)-1
+	size := degA + degB
+	matrix := make([][]*big.Int, size)
+	for i := range matrix {
+		matrix[i] = make([]*big.Int, size)
+		for j := range matrix[i] {
+			matrix[i][j] = new(big.Int)
+		}
+	}
+	for i := 0; i < degB; i++ {
+		for j := 0; j <= degA; j++ {
+			matrix[i][i+j].Set(a[degA-j])
+		}
+	}
+	for i := 0; i < degA; i++ {
+		for j := 0; j <= degB; j++ {
+			matrix[degB+i][i+j].Set(b[degB-j])
+		}
+	}
+	return bareissDeterminant(matrix)
+}
+
+// bareissDeterminant вычисляет определитель целочисленной матрицы методом
+// Барейса без дробей (матрица портится)
+func bareissDeterminant(matrix [][]*big.Int) *big.Int {
+	size := len(matrix)
+	sign := 1
+	prev := big.NewInt(1)
+	for k := 0; k < size-1; k++ {
+		if matrix[k][k].Sign() == 0 {
+			pivot := -1
+			for i := k + 1; i < size; i++ {
+				if matrix[i][k].Sign() != 0 {
+					pivot = i
+					break
+				}
+			}
+			if pivot < 0 {
+				return new(big.Int)
+			}
+			matrix[k], matrix[pivot] = matrix[pivot], matrix[k]
+			sign = -sign
+		}
+		for i := k + 1; i < size; i++ {
+			for j := k + 1; j < size; j++ {
+				v := new(big.Int).Mul(matrix[i][j], matrix[k][k])
+				v.Sub(v, new(big.Int).Mul(matrix[i][k], matrix[k][j]))
+				matrix[i][j] = v.Quo(v, prev)
+			}
+		}
+		prev = matrix[k][k]
+	}
+	det := new(big.Int).Set(matrix[size-1][size-1])
+	if sign < 0 {
+		det.Neg(det)
+	}
+	return det
+}
+
+// interpolate восстанавливает многочлен по значениям в точках 0..len(values)-1
+// (форма Ньютона с разделенными разностями, точная рациональная арифметика)
+func interpolate(values []*big.Int) []*big.Int {
+	n := len(values)
+	diffs := make([]*big.Rat, n)
+	for i, v := range values {
+		diffs[i] = new(big.Rat).SetInt(v)
+	}
+	for level := 1; level < n; level++ {
+		for i := n - 1; i >= level; i-- {
+			diffs[i].Sub(diffs[i], diffs[i-1])
+			diffs[i].Quo(diffs[i], new(big.Rat).SetInt64(int64(level)))
+		}
+	}
+
+	// Раскрытие сумм c_k (y)(y-1)...(y-k+1) схемой Горнера
+	coeffs := []*big.Rat{new(big.Rat).Set(diffs[n-1])}
+	for k := n - 2; k >= 0; k-- {
+		// coeffs = coeffs * (y - k) + diffs[k]
+		next := make([]*big.Rat, len(coeffs)+1)
+		for i := range next {
+			next[i] = new(big.Rat)
+		}
+		shift := new(big.Rat).SetInt64(int64(-k))
+		for i, c := range coeffs {
+			next[i+1].Add(next[i+1], c)
+			next[i].Add(next[i], new(big.Rat).Mul(c, shift))
+		}
+		next[0].Add(next[0], diffs[k])
+		coeffs = next
+	}
+
+	result := make([]*big.Int, len(coeffs))
+	for i, c := range coeffs {
+		// Результант целочисленный, поэтому знаменатели равны 1
+		result[i] = new(big.Int).Set(c.Num())
+	}
+	return result
+}
+
+// trimPoly отбрасывает нулевые старшие коэффициенты; nil для нулевого многочлена
+func trimPoly(coeffs []*big.Int) []*big.Int {
+	for len(coeffs) > 0 && coeffs[len(coeffs)-1].Sign() == 0 {
+		coeffs = coeffs[:len(coeffs)-1]
+	}
+	if len(coeffs) == 0 {
+		return nil
+	}
+	return coeffs
+}
+
+func evalPoly(coeffs []*big.Int, x, mod *big.Int) *big.Int {
+	result := new(big.Int)
+	for i := len(coeffs) - 1; i >= 0; i-- {
+		result.Mul(result, x)
+		result.Add(result, coeffs[i])
+		if mod != nil {
+			result.Mod(result, mod)
+		}
+	}
+	return result
+}
+
+func derivative(coeffs []*big.Int) []*big.Int {
+	if len(coeffs) <= 1 {
+		return []*big.Int{new(big.Int)}
+	}
+	result := make([]*big.Int, len(coeffs)-1)
+	for i := range result {
+		result[i] = new(big.Int).Mul(coeffs[i+1], big.NewInt(int64(i+1)))
+	}
+	return result
+}
+
+// integerRoots находит целые корни многочлена с |y| <= bound: простые корни по
+// малому простому модулю l поднимаются по Гензелю до l^(2^k) > 2*bound, и
+// каждый кандидат проверяется точной подстановкой
+func integerRoots(coeffs []*big.Int, bound *big.Int) []*big.Int {
+	deriv := derivative(coeffs)
+	limit := new(big.Int).Lsh(bound, 2)
+	found := make(map[string]*big.Int)
+
+	for _, l := range []int64{1009, 1013, 1019} {
+		lBig := big.NewInt(l)
+		if new(big.Int).Mod(coeffs[len(coeffs)-1], lBig).Sign() == 0 {
+			continue
+		}
+
+		for r := int64(0); r < l; r++ {
+			root := big.NewInt(r)
+			if evalPoly(coeffs, root, lBig).Sign() != 0 {
+				continue
+			}
+			if evalPoly(deriv, root, lBig).Sign() == 0 {
+				continue // кратный корень по модулю l
+			}
+
+			// Подъем Ньютона-Гензеля: r = r - f(r)/f'(r) mod M^2
+			modulus := new(big.Int).Set(lBig)
+			for modulus.Cmp(limit) <= 0 {
+				modulus.Mul(modulus, modulus)
+				inv := new(big.Int).ModInverse(evalPoly(deriv, root, modulus), modulus)
+				if inv == nil {
+					break
+				}
+				step := evalPoly(coeffs, root, modulus)
+				step.Mul(step, inv)
+				root.Sub(root, step).Mod(root, modulus)
+			}
+
+			// Симметричный представитель
+			if new(big.Int).Lsh(root, 1).Cmp(modulus) > 0 {
+				root.Sub(root, modulus)
+			}
+			if root.CmpAbs(bound) <= 0 && evalPoly(coeffs, root, nil).Sign() == 0 {
+				found[root.String()] = root
+			}
+		}
+	}
+
+	roots := make([]*big.Int, 0, len(found))
+	for _, r := range found {
+		roots = append(roots, r)
+	}
+	sort.Slice(roots, func(i, j int) bool { return roots[i].Cmp(roots[j]) < 0 })
+	return roots
+}
@@ -0,0 +1,204 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+)
+
+// runCLI выполняет подкоманду командной строки и возвращает код завершения
+func runCLI(args []string) int {
+	commands := map[string]func([]string) error{
+		"keygen":  cmdKeygen,
+		"encrypt": cmdHybridEncrypt,
+		"decrypt": cmdHybridDecrypt,
+		"audit":   cmdAudit,
+	}
+
+	if len(args) == 0 || commands[args[0]] == nil {
+		printUsage(os.Stderr)
+		return 2
+	}
+
+	if err := commands[args[0]](args[1:]); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", args[0], err)
+		return 1
+	}
+	return 0
+}
+
+func printUsage(w io.Writer) {
+	fmt.Fprintln(w, "Использование:")
+	fmt.Fprintln(w, "  go run . keygen  -bits 2048 -out key [-pass пароль] [-primes 2] [-e 65537]")
+	fmt.Fprintln(w, "  go run . encrypt -pub key.pub.pem -in файл -out файл.hyb")
+	fmt.Fprintln(w, "  go run . decrypt -key key.pem [-pass пароль] -in файл.hyb -out файл")
+	fmt.Fprintln(w, "  go run . audit   (-key key.pem [-pass пароль] | -pub key.pub.pem) [-raw] [-json]")
+	fmt.Fprintln(w, "Без аргументов запускается демонстрация.")
+}
+
+// loadPublicKey читает открытый ключ из PEM-файла
+func loadPublicKey(path string) (*RSAPublicKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodePublicKeyPEM(data)
+}
+
+// loadPrivateKey читает закрытый ключ из PEM-файла (при необходимости с паролем)
+func loadPrivateKey(path, passphrase string) (*RSAPrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return DecodePrivateKeyPEM(data, []byte(passphrase))
+}
+
+// newKeyedRSAService создает сервис RSA с уже имеющимися ключами
+func newKeyedRSAService(pub *RSAPublicKey, priv *RSAPrivateKey) (*RSAService, error) {
+	rs := NewRSAService(TestMillerRabin, 0.9999, pub.N.BitLen()/2)
+	if err := rs.SetKeys(pub, priv); err != nil {
+		return nil, err
+	}
+	return rs, nil
+}
+
+func cmdKeygen(args []string) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	bits := fs.Int("bits", 2048, "размер модуля в битах")
+	out := fs.String("out", "key", "префикс файлов ключей (<out>.pem, <out>.pub.pem)")
+	pass := fs.String("pass", "", "пароль для шифрования закрытого ключа")
+	primeCount := fs.Int("primes", 2, "число простых множителей модуля")
+	exponent := fs.Int64("e", 65537, "открытая экспонента")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	rs := NewRSAService(TestMillerRabin, 0.9999, *bits/2)
+	policy := NewKeyGenPolicy(*bits)
+	policy.PrimeCount = *primeCount
+	policy.PublicExponent = big.NewInt(*exponent)
+	if err := rs.SetKeyGenPolicy(policy); err != nil {
+		return err
+	}
+	if err := rs.GenerateKeys(); err != nil {
+		return err
+	}
+
+	privPEM, err := EncodePrivateKeyPEM(rs.GetPrivateKey(), FormatPKCS8, []byte(*pass))
+	if err != nil {
+		return err
+	}
+	pubPEM, err := EncodePublicKeyPEM(rs.GetPublicKey(), FormatPKCS8)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(*out+".pem", privPEM, 0600); err != nil {
+		return err
+	}
+	if err := os.WriteFile(*out+".pub.pem", pubPEM, 0644); err != nil {
+		return err
+	}
+	fmt.Printf("Ключи записаны в %s.pem и %s.pub.pem\n", *out, *out)
+	return nil
+}
+
+func cmdHybridEncrypt(args []string) error {
+	fs := flag.NewFlagSet("encrypt", flag.ContinueOnError)
+	pubPath := fs.String("pub", "", "PEM-файл открытого ключа получателя")
+	in := fs.String("in", "", "входной файл")
+	out := fs.String("out", "", "выходной контейнер")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *pubPath == "" || *in == "" || *out == "" {
+		return fmt.Errorf("требуются -pub, -in и -out")
+	}
+
+	pub, err := loadPublicKey(*pubPath)
+	if err != nil {
+		return fmt.Errorf("load public key: %w", err)
+	}
+	rs, err := newKeyedRSAService(pub, nil)
+	if err != nil {
+		return err
+	}
+	return NewHybridService(rs).EncryptFile(*in, *out)
+}
+
+func cmdHybridDecrypt(args []string) error {
+	fs := flag.NewFlagSet("decrypt", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "PEM-файл закрытого ключа")
+	pass := fs.String("pass", "", "пароль закрытого ключа")
+	in := fs.String("in", "", "входной контейнер")
+	out := fs.String("out", "", "выходной файл")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *keyPath == "" || *in == "" || *out == "" {
+		return fmt.Errorf("требуются -key, -in и -out")
+	}
+
+	priv, err := loadPrivateKey(*keyPath, *pass)
+	if err != nil {
+		return fmt.Errorf("load private key: %w", err)
+	}
+	rs, err := newKeyedRSAService(priv.PublicKey, priv)
+	if err != nil {
+		return err
+	}
+	return NewHybridService(rs).DecryptFile(*in, *out)
+}
+
+// cmdAudit проверяет ключ из PEM-файла и печатает отчет; при критичных
+// проблемах завершается с ошибкой
+func cmdAudit(args []string) error {
+	fs := flag.NewFlagSet("audit", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "PEM-файл закрытого ключа")
+	pass := fs.String("pass", "", "пароль закрытого ключа")
+	pubPath := fs.String("pub", "", "PEM-файл открытого ключа")
+	raw := fs.Bool("raw", false, "ключ используется без дополнения (учебный RSA)")
+	asJSON := fs.Bool("json", false, "вывести отчет в JSON")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if (*keyPath == "") == (*pubPath == "") {
+		return fmt.Errorf("требуется ровно один из -key и -pub")
+	}
+
+	var pub *RSAPublicKey
+	var priv *RSAPrivateKey
+	var err error
+	if *keyPath != "" {
+		if priv, err = loadPrivateKey(*keyPath, *pass); err != nil {
+			return fmt.Errorf("load private key: %w", err)
+		}
+		pub = priv.PublicKey
+	} else if pub, err = loadPublicKey(*pubPath); err != nil {
+		return fmt.Errorf("load public key: %w", err)
+	}
+
+	auditor := NewKeyAuditService(0.999999)
+	auditor.SetUnpadded(*raw)
+	report := auditor.Audit(pub, priv)
+
+	if *asJSON {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+	} else {
+		fmt.Print(report)
+	}
+
+	if report.Worst() == SeverityCritical {
+		return errors.New("key audit found critical problems")
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+)
+
+type CommonModulusAttackResult struct {
+	Message *big.Int
+	A       *big.Int // коэффициенты Безу: a*e1 + b*e2 = 1
+	B       *big.Int
+	Success bool
+}
+
+// CommonModulusAttackService атака на общий модуль: если одно сообщение
+// зашифровано двумя экспонентами e1, e2 с НОД(e1, e2) = 1 при одном n, то
+// m = c1^a * c2^b (mod n), где a*e1 + b*e2 = 1
+type CommonModulusAttackService struct {
+	mathService *MathService
+}
+
+func NewCommonModulusAttackService() *CommonModulusAttackService {
+	return &CommonModulusAttackService{
+		mathService: NewMathService(),
+	}
+}
+
+func (cmas *CommonModulusAttackService) Attack(key1, key2 *RSAPublicKey, c1, c2 *big.Int) (*CommonModulusAttackResult, error) {
+	result := &CommonModulusAttackResult{
+		Success: false,
+	}
+
+	if key1.N.Cmp(key2.N) != 0 {
+		return nil, errors.New("keys do not share the modulus")
+	}
+	n := key1.N
+
+	gcd, a, b := cmas.mathService.ExtendedGCD(key1.E, key2.E)
+	if gcd.Cmp(big.NewInt(1)) != 0 {
+		return result, nil
+	}
+	result.A = a
+	result.B = b
+
+	m1, ok := cmas.signedPow(c1, a, n)
+	if !ok {
+		return result, nil
+	}
+	m2, ok := cmas.signedPow(c2, b, n)
+	if !ok {
+		return result, nil
+	}
+
+	result.Message = m1.Mul(m1, m2).Mod(m1, n)
+	result.Success = true
+	return result, nil
+}
+
+// signedPow вычисляет c^k mod n для k любого знака; для отрицательного k
+// нужен обратный к c, который существует, если c взаимно просто с n
+func (cmas *CommonModulusAttackService) signedPow(c, k, n *big.Int) (*big.Int, bool) {
+	if k.Sign() >= 0 {
+		return cmas.mathService.ModPow(c, k, n), true
+	}
+
+	gcd, inv, _ := cmas.mathService.ExtendedGCD(new(big.Int).Mod(c, n), n)
+	if gcd.Cmp(big.NewInt(1)) != 0 {
+		return nil, false
+	}
+	inv.Mod(inv, n)
+	return cmas.mathService.ModPow(inv, new(big.Int).Neg(k), n), true
+}
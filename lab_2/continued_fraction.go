@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+)
+
+// maxQuadraticPeriod ограничение на число элементов цепной дроби квадратичной
+// иррациональности: длина периода sqrt(D) растет примерно как sqrt(D)
+const maxQuadraticPeriod = 1 << 20
+
+// ContinuedFraction подходящая дробь h/k
+type ContinuedFraction struct {
+	Numerator   *big.Int // h (k в атаке Винера)
+	Denominator *big.Int // k (d в атаке Винера)
+}
+
+// Rat возвращает подходящую дробь как big.Rat
+func (cf ContinuedFraction) Rat() *big.Rat {
+	return new(big.Rat).SetFrac(cf.Numerator, cf.Denominator)
+}
+
+// PeriodicContinuedFraction разложение квадратичной иррациональности:
+// [Prefix; (Period)], период повторяется бесконечно
+type PeriodicContinuedFraction struct {
+	Prefix []*big.Int
+	Period []*big.Int
+}
+
+// Terms возвращает первые count элементов разложения
+func (pcf *PeriodicContinuedFraction) Terms(count int) []*big.Int {
+	terms := make([]*big.Int, 0, count)
+	for i := 0; i < count; i++ {
+		if i < len(pcf.Prefix) {
+			terms = append(terms, pcf.Prefix[i])
+		} else {
+			terms = append(terms, pcf.Period[(i-len(pcf.Prefix))%len(pcf.Period)])
+		}
+	}
+	return terms
+}
+
+// floorDiv деление с округлением вниз при любых знаках
+func floorDiv(a, b *big.Int) *big.Int {
+	q, r := new(big.Int).QuoRem(a, b, new(big.Int))
+	if r.Sign() != 0 && r.Sign() != b.Sign() {
+		q.Sub(q, big.NewInt(1))
+	}
+	return q
+}
+
+// ContinuedFractionTerms раскладывает num/den в конечную цепную дробь
+// [a0; a1, ..., an] алгоритмом Евклида. a0 может быть отрицательным,
+// остальные элементы положительны.
+func (ms *MathService) ContinuedFractionTerms(num, den *big.Int) ([]*big.Int, error) {
+	if den.Sign() == 0 {
+		return nil, errors.New("denominator must be non-zero")
+	}
+	a := new(big.Int).Set(num)
+	b := new(big.Int).Set(den)
+	if b.Sign() < 0 {
+		a.Neg(a)
+		b.Neg(b)
+	}
+
+	var terms []*big.Int
+	for b.Sign() != 0 {
+		q := floorDiv(a, b)
+		terms = append(terms, q)
+		a, b = b, a.Sub(a, new(big.Int).Mul(q, b))
+	}
+	return terms, nil
+}
+
+// Convergents вычисляет подходящие дроби h_i/k_i цепной дроби:
+// h_i = a_i h_{i-1} + h_{i-2}, k_i = a_i k_{i-1} + k_{i-2}
+func (ms *MathService) Convergents(terms []*big.Int) []ContinuedFraction {
+	convergents := make([]ContinuedFraction, 0, len(terms))
+
+	h0, h1 := big.NewInt(1), big.NewInt(0)
+	k0, k1 := big.NewInt(0), big.NewInt(1)
+	for _, a := range terms {
+		h := new(big.Int).Add(new(big.Int).Mul(a, h0), h1)
+		k := new(big.Int).Add(new(big.Int).Mul(a, k0), k1)
+		convergents = append(convergents, ContinuedFraction{Numerator: h, Denominator: k})
+		h1, h0 = h0, h
+		k1, k0 = k0, k
+	}
+	return convergents
+}
+
+// RationalConvergents подходящие дроби к num/den; последняя равна num/den
+// в несократимом виде
+func (ms *MathService) RationalConvergents(num, den *big.Int) ([]ContinuedFraction, error) {
+	terms, err := ms.ContinuedFractionTerms(num, den)
+	if err != nil {
+		return nil, err
+	}
+	return ms.Convergents(terms), nil
+}
+
+// Semiconvergents возвращает промежуточные дроби
+// (h_{i-2} + m h_{i-1}) / (k_{i-2} + m k_{i-1}), 1 <= m <= a_i, в порядке
+// роста знаменателя; при m = a_i это подходящая дробь. Среди них лежат все
+// наилучшие приближения первого рода. Дробей 1 + a_1 + ... + a_n,
+// поэтому функция предназначена для дробей с небольшими элементами.
+func (ms *MathService) Semiconvergents(terms []*big.Int) []ContinuedFraction {
+	if len(terms) == 0 {
+		return nil
+	}
+	result := []ContinuedFraction{{Numerator: new(big.Int).Set(terms[0]), Denominator: big.NewInt(1)}}
+
+	h0, h1 := new(big.Int).Set(terms[0]), big.NewInt(1)
+	k0, k1 := big.NewInt(1), big.NewInt(0)
+	for _, a := range terms[1:] {
+		for m := big.NewInt(1); m.Cmp(a) <= 0; m.Add(m, big.NewInt(1)) {
+			result = append(result, ContinuedFraction{
+				Numerator:   new(big.Int).Add(h1, new(big.Int).Mul(m, h0)),
+				Denominator: new(big.Int).Add(k1, new(big.Int).Mul(m, k0)),
+			})
+		}
+		last := result[len(result)-1]
+		h1, h0 = h0, last.Numerator
+		k1, k0 = k0, last.Denominator
+	}
+	return result
+}
+
+// BestApproximation находит дробь с знаменателем не больше maxDen, ближайшую
+// к num/den (наилучшее приближение первого рода). Кандидаты - последняя
+// подходящая дробь и наибольшая промежуточная дробь перед следующей.
+func (ms *MathService) BestApproximation(num, den, maxDen *big.Int) (ContinuedFraction, error) {
+	if den.Sign() == 0 {
+		return ContinuedFraction{}, errors.New("denominator must be non-zero")
+	}
+	if maxDen.Sign() <= 0 {
+		return ContinuedFraction{}, errors.New("maximum denominator must be positive")
+	}
+	target := new(big.Rat).SetFrac(num, den)
+	if target.Denom().Cmp(maxDen) <= 0 {
+		return ContinuedFraction{Numerator: new(big.Int).Set(target.Num()), Denominator: new(big.Int).Set(target.Denom())}, nil
+	}
+
+	p0, q0 := big.NewInt(0), big.NewInt(1)
+	p1, q1 := big.NewInt(1), big.NewInt(0)
+	n := new(big.Int).Set(target.Num())
+	d := new(big.Int).Set(target.Denom())
+	for {
+		a := floorDiv(n, d)
+		q2 := new(big.Int).Add(q0, new(big.Int).Mul(a, q1))
+		if q2.Cmp(maxDen) > 0 {
+			break
+		}
+		p0, q0, p1, q1 = p1, q1, new(big.Int).Add(p0, new(big.Int).Mul(a, p1)), q2
+		n, d = d, new(big.Int).Sub(n, new(big.Int).Mul(a, d))
+	}
+
+	m := floorDiv(new(big.Int).Sub(maxDen, q0), q1)
+	semi := ContinuedFraction{
+		Numerator:   new(big.Int).Add(p0, new(big.Int).Mul(m, p1)),
+		Denominator: new(big.Int).Add(q0, new(big.Int).Mul(m, q1)),
+	}
+	convergent := ContinuedFraction{Numerator: p1, Denominator: q1}
+
+	distSemi := new(big.Rat).Sub(semi.Rat(), target)
+	distConv := new(big.Rat).Sub(convergent.Rat(), target)
+	if distConv.Abs(distConv).Cmp(distSemi.Abs(distSemi)) <= 0 {
+		return convergent, nil
+	}
+	return semi, nil
+}
+
+// QuadraticContinuedFraction раскладывает квадратичную иррациональность
+// (p + sqrt(d)) / q в периодическую цепную дробь (теорема Лагранжа).
+// Состояние (P, Q) ведется в целых числах: a = floor((P + sqrt(D)) / Q),
+// P' = aQ - P, Q' = (D - P'^2) / Q; повтор состояния замыкает период.
+func (ms *MathService) QuadraticContinuedFraction(p, d, q *big.Int) (*PeriodicContinuedFraction, error) {
+	if d.Sign() <= 0 {
+		return nil, errors.New("radicand must be positive")
+	}
+	if q.Sign() == 0 {
+		return nil, errors.New("denominator must be non-zero")
+	}
+	s := new(big.Int).Sqrt(d)
+	if new(big.Int).Mul(s, s).Cmp(d) == 0 {
+		return nil, errors.New("radicand must not be a perfect square")
+	}
+
+	P := new(big.Int).Set(p)
+	D := new(big.Int).Set(d)
+	Q := new(big.Int).Set(q)
+
+	// Рекуррентность требует Q | (D - P^2); иначе домножаем числитель и
+	// знаменатель на |Q|
+	if new(big.Int).Mod(new(big.Int).Sub(D, new(big.Int).Mul(P, P)), new(big.Int).Abs(Q)).Sign() != 0 {
+		absQ := new(big.Int).Abs(Q)
+		P.Mul(P, absQ)
+		D.Mul(D, new(big.Int).Mul(Q, Q))
+		Q.Mul(Q, absQ)
+		s.Sqrt(D)
+	}
+
+	seen := make(map[string]int)
+	var terms []*big.Int
+	for len(terms) < maxQuadraticPeriod {
+		key := P.String() + "/" + Q.String()
+		if start, ok := seen[key]; ok {
+			return &PeriodicContinuedFraction{Prefix: terms[:start], Period: terms[start:]}, nil
+		}
+		seen[key] = len(terms)
+
+		// sqrt(D) иррационален, поэтому floor((P + sqrt(D)) / Q) выражается
+		// через s = floor(sqrt(D)) с поправкой для отрицательного Q
+		num := new(big.Int).Add(P, s)
+		if Q.Sign() < 0 {
+			num.Add(num, big.NewInt(1))
+		}
+		a := floorDiv(num, Q)
+		terms = append(terms, a)
+
+		P = new(big.Int).Sub(new(big.Int).Mul(a, Q), P)
+		Q = new(big.Int).Quo(new(big.Int).Sub(D, new(big.Int).Mul(P, P)), Q)
+	}
+	return nil, errors.New("continued fraction period too long")
+}
+
+// SqrtContinuedFraction разложение sqrt(n) = [a0; (a1, ..., a_r)], где
+// a_r = 2 a0
+func (ms *MathService) SqrtContinuedFraction(n *big.Int) (*PeriodicContinuedFraction, error) {
+	return ms.QuadraticContinuedFraction(big.NewInt(0), n, big.NewInt(1))
+}
+
+// SolvePell находит фундаментальное решение уравнения Пелля x^2 - n y^2 = 1:
+// это подходящая дробь h_{r-1}/k_{r-1} к sqrt(n) при четной длине периода r
+// и h_{2r-1}/k_{2r-1} при нечетной
+func (ms *MathService) SolvePell(n *big.Int) (*big.Int, *big.Int, error) {
+	cf, err := ms.SqrtContinuedFraction(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := len(cf.Period)
+	count := r
+	if r%2 == 1 {
+		count = 2 * r
+	}
+	convergents := ms.Convergents(cf.Terms(count))
+	solution := convergents[count-1]
+	return solution.Numerator, solution.Denominator, nil
+}
+
+// SolveNegativePell находит наименьшее решение x^2 - n y^2 = -1; оно
+// существует тогда и только тогда, когда период sqrt(n) нечетной длины
+func (ms *MathService) SolveNegativePell(n *big.Int) (*big.Int, *big.Int, error) {
+	cf, err := ms.SqrtContinuedFraction(n)
+	if err != nil {
+		return nil, nil, err
+	}
+	r := len(cf.Period)
+	if r%2 == 0 {
+		return nil, nil, errors.New("negative Pell equation has no solutions")
+	}
+	solution := ms.Convergents(cf.Terms(r))[r-1]
+	return solution.Numerator, solution.Denominator, nil
+}
+
+// PellSolutions возвращает count первых решений x^2 - n y^2 = 1:
+// x_k + y_k sqrt(n) = (x_1 + y_1 sqrt(n))^k
+func (ms *MathService) PellSolutions(n *big.Int, count int) ([][2]*big.Int, error) {
+	x1, y1, err := ms.SolvePell(n)
+	if err != nil {
+		return nil, err
+	}
+	solutions := make([][2]*big.Int, 0, count)
+	x, y := new(big.Int).Set(x1), new(big.Int).Set(y1)
+	for i := 0; i < count; i++ {
+		solutions = append(solutions, [2]*big.Int{x, y})
+		nx := new(big.Int).Mul(x1, x)
+		nx.Add(nx, new(big.Int).Mul(n, new(big.Int).Mul(y1, y)))
+		ny := new(big.Int).Mul(x1, y)
+		ny.Add(ny, new(big.Int).Mul(y1, x))
+		x, y = nx, ny
+	}
+	return solutions, nil
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func requireTerms(t *testing.T, what string, got []*big.Int, want ...int64) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("%s = %v, want %v", what, got, want)
+	}
+	for i, w := range want {
+		if got[i].Int64() != w {
+			t.Fatalf("%s = %v, want %v", what, got, want)
+		}
+	}
+}
+
+func TestRationalContinuedFractions(t *testing.T) {
+	ms := NewMathService()
+
+	terms, err := ms.ContinuedFractionTerms(big.NewInt(415), big.NewInt(93))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireTerms(t, "415/93", terms, 4, 2, 6, 7)
+
+	var got []string
+	for _, cf := range ms.Convergents(terms) {
+		got = append(got, cf.Rat().RatString())
+	}
+	want := []string{"4", "9/2", "58/13", "415/93"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("convergents = %v, want %v", got, want)
+		}
+	}
+	// a_0 и по a_i дробей для каждого следующего элемента
+	if semi := ms.Semiconvergents(terms); len(semi) != 1+2+6+7 {
+		t.Errorf("%d semiconvergents, want 16", len(semi))
+	} else if semi[2].Rat().RatString() != "9/2" || semi[3].Rat().RatString() != "13/3" {
+		t.Errorf("semiconvergents = %v", semi[:4])
+	}
+
+	// -7/3 = -3 + 2/3 = [-3; 1, 2]
+	terms, _ = ms.ContinuedFractionTerms(big.NewInt(-7), big.NewInt(3))
+	requireTerms(t, "-7/3", terms, -3, 1, 2)
+	// Последняя подходящая дробь - исходное число в несократимом виде
+	convergents, _ := ms.RationalConvergents(big.NewInt(30), big.NewInt(-12))
+	if last := convergents[len(convergents)-1].Rat(); last.Cmp(big.NewRat(-5, 2)) != 0 {
+		t.Errorf("last convergent of 30/-12 = %s", last.RatString())
+	}
+	if _, err := ms.ContinuedFractionTerms(big.NewInt(1), big.NewInt(0)); err == nil {
+		t.Error("zero denominator accepted")
+	}
+}
+
+func TestBestApproximationOfPi(t *testing.T) {
+	ms := NewMathService()
+	pi, _ := new(big.Rat).SetString("3.141592653589793")
+
+	// Эталон: fractions.Fraction.limit_denominator
+	tests := []struct {
+		maxDen int64
+		want   string
+	}{
+		{7, "22/7"},
+		{10, "22/7"},
+		{57, "179/57"}, // промежуточная дробь, а не подходящая
+		{100, "311/99"},
+		{113, "355/113"},
+		{1000, "355/113"},
+		{100000, "312689/99532"},
+	}
+	for _, tt := range tests {
+		got, err := ms.BestApproximation(pi.Num(), pi.Denom(), big.NewInt(tt.maxDen))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Rat().RatString() != tt.want {
+			t.Errorf("BestApproximation(pi, %d) = %s, want %s", tt.maxDen, got.Rat().RatString(), tt.want)
+		}
+	}
+	if _, err := ms.BestApproximation(pi.Num(), pi.Denom(), big.NewInt(0)); err == nil {
+		t.Error("maximum denominator 0 accepted")
+	}
+}
+
+func TestQuadraticContinuedFraction(t *testing.T) {
+	ms := NewMathService()
+
+	cf, err := ms.SqrtContinuedFraction(big.NewInt(23))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireTerms(t, "sqrt(23) prefix", cf.Prefix, 4)
+	requireTerms(t, "sqrt(23) period", cf.Period, 1, 3, 1, 8)
+
+	// Отрицательный знаменатель; эталон получен разложением с 80 знаками
+	tests := []struct {
+		p, d, q int64
+		want    []int64
+	}{
+		{1, 5, -2, []int64{-2, 2, 1, 1, 1, 1, 1, 1, 1, 1}},
+		{-3, 7, -2, []int64{0, 5, 1, 1, 1, 4, 1, 1, 1, 4}},
+		{2, 13, -3, []int64{-2, 7, 1, 1, 1, 1, 6, 1, 1, 1, 1, 6}},
+	}
+	for _, tt := range tests {
+		cf, err := ms.QuadraticContinuedFraction(big.NewInt(tt.p), big.NewInt(tt.d), big.NewInt(tt.q))
+		if err != nil {
+			t.Fatal(err)
+		}
+		requireTerms(t, "quadratic", cf.Terms(len(tt.want)), tt.want...)
+	}
+
+	if _, err := ms.SqrtContinuedFraction(big.NewInt(49)); err == nil {
+		t.Error("perfect square accepted")
+	}
+}
+
+func TestSolvePell(t *testing.T) {
+	ms := NewMathService()
+
+	x, y, err := ms.SolvePell(big.NewInt(61))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if x.Int64() != 1766319049 || y.Int64() != 226153980 {
+		t.Errorf("SolvePell(61) = (%s, %s), want (1766319049, 226153980)", x, y)
+	}
+
+	// Период sqrt(61) нечетный, поэтому есть решение x^2 - 61 y^2 = -1
+	x, y, err = ms.SolveNegativePell(big.NewInt(61))
+	if err != nil || x.Int64() != 29718 || y.Int64() != 3805 {
+		t.Errorf("SolveNegativePell(61) = (%v, %v), %v, want (29718, 3805)", x, y, err)
+	}
+	if _, _, err := ms.SolveNegativePell(big.NewInt(3)); err == nil {
+		t.Error("x^2 - 3y^2 = -1 reported solvable")
+	}
+
+	solutions, err := ms.PellSolutions(big.NewInt(2), 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][2]int64{{3, 2}, {17, 12}, {99, 70}, {577, 408}}
+	for i, s := range solutions {
+		if s[0].Int64() != want[i][0] || s[1].Int64() != want[i][1] {
+			t.Errorf("solution %d = (%s, %s), want %v", i+1, s[0], s[1], want[i])
+		}
+	}
+}
+
+// TestWienerAttackUsesConvergents проверяет, что k/d с ed - 1 = k*phi есть
+// среди RationalConvergents(e, n) при малом d, и атака Винера его находит
+func TestWienerAttackUsesConvergents(t *testing.T) {
+	ms := NewMathService()
+	pub, d := weakRSAKey(512, 100)
+
+	convergents, err := ms.RationalConvergents(pub.E, pub.N)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, cf := range convergents {
+		if cf.Denominator.Cmp(d) == 0 {
+			found = true
+			break
+		}
+	}
+	if !found {
+		t.Fatal("d is not a convergent denominator of e/n")
+	}
+
+	result := NewWienerAttackService().Attack(pub)
+	if !result.Success || result.D.Cmp(d) != 0 {
+		t.Fatalf("Wiener attack: success %v", result.Success)
+	}
+
+	// d ~ n^0.6 далеко за границей n^(1/4)
+	pub, _ = weakRSAKey(512, 300)
+	if NewWienerAttackService().Attack(pub).Success {
+		t.Error("Wiener attack succeeded for a large d")
+	}
+}
@@ -0,0 +1,261 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	maxBSGSOrderBits   = 48      // таблица до 2^24 шагов младенца; для большего порядка - PollardRhoLog
+	bsgsThresholdBits  = 40      // Похлиг-Хеллман решает подзадачи меньшего порядка через BSGS
+	maxRhoRestarts     = 32      // перезапусков rho при вырожденном совпадении
+	maxRhoCandidates   = 1 << 16 // перебор решений сравнения при НОД(b, n) > 1
+	rhoWalkMultipliers = 16      // классов в r-сдвиговом блуждании rho
+)
+
+// BabyStepGiantStep решает g^x = h (mod m) методом шагов младенца и великана
+// за O(sqrt(n)) операций и памяти, где n - порядок g (если order == nil,
+// используется m - 1). Возвращает наименьшее x из [0, n).
+func (ms *MathService) BabyStepGiantStep(g, h, m, order *big.Int) (*big.Int, error) {
+	n, err := ms.logOrder(m, order)
+	if err != nil {
+		return nil, err
+	}
+	if n.BitLen() > maxBSGSOrderBits {
+		return nil, fmt.Errorf("group order exceeds %d bits, use PollardRhoLog", maxBSGSOrderBits)
+	}
+
+	// s = ceil(sqrt(n))
+	s := new(big.Int).Sqrt(n)
+	if new(big.Int).Mul(s, s).Cmp(n) < 0 {
+		s.Add(s, big.NewInt(1))
+	}
+	steps := s.Int64()
+
+	// Шаги младенца: g^j -> j
+	table := make(map[string]int64, steps)
+	e := big.NewInt(1)
+	gm := new(big.Int).Mod(g, m)
+	for j := int64(0); j < steps; j++ {
+		key := string(e.Bytes())
+		if _, ok := table[key]; !ok {
+			table[key] = j
+		}
+		e.Mul(e, gm).Mod(e, m)
+	}
+
+	// Шаги великана: h * g^(-s*i)
+	gInv, err := ms.ModInverse(g, m)
+	if err != nil {
+		return nil, err
+	}
+	factor := ms.ModPow(gInv, s, m)
+	y := new(big.Int).Mod(h, m)
+	for i := int64(0); i < steps; i++ {
+		if j, ok := table[string(y.Bytes())]; ok {
+			x := new(big.Int).Mul(big.NewInt(i), s)
+			x.Add(x, big.NewInt(j))
+			if x.Cmp(n) < 0 {
+				return x, nil
+			}
+		}
+		y.Mul(y, factor).Mod(y, m)
+	}
+	return nil, errors.New("discrete logarithm does not exist")
+}
+
+// PollardRhoLog решает g^x = h (mod m) rho-методом Полларда с r-сдвиговым
+// блужданием Теске и поиском цикла по Флойду. Память O(1), ожидаемое время
+// O(sqrt(n)). Наиболее эффективен для простого порядка n; для составного
+// порядка перебираются решения получившегося линейного сравнения.
+func (ms *MathService) PollardRhoLog(g, h, m, order *big.Int) (*big.Int, error) {
+	n, err := ms.logOrder(m, order)
+	if err != nil {
+		return nil, err
+	}
+
+	gm := new(big.Int).Mod(g, m)
+	hm := new(big.Int).Mod(h, m)
+	if hm.Cmp(big.NewInt(1)) == 0 {
+		return big.NewInt(0), nil
+	}
+
+	// randomPoint возвращает случайные (a, b) и g^a * h^b
+	randomPoint := func() (*big.Int, *big.Int, *big.Int, error) {
+		a, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		b, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, nil, nil, err
+		}
+		x := ms.ModPow(gm, a, m)
+		x.Mul(x, ms.ModPow(hm, b, m)).Mod(x, m)
+		return x, a, b, nil
+	}
+
+	// Шаг блуждания: x -> x * M_i, где i = x mod r и M_i = g^alpha_i * h^beta_i.
+	// Показатели (a, b) поддерживают инвариант x = g^a * h^b. Возведение в
+	// квадрат из классического блуждания не используется: в группе четного
+	// порядка оно теряет младшие биты показателей, и в группе порядка 2^k
+	// все блуждания сходятся к одинаковым (a, b).
+	var multipliers, alphas, betas [rhoWalkMultipliers]*big.Int
+	class := big.NewInt(rhoWalkMultipliers)
+	step := func(x, a, b *big.Int) {
+		i := new(big.Int).Mod(x, class).Int64()
+		x.Mul(x, multipliers[i]).Mod(x, m)
+		a.Add(a, alphas[i]).Mod(a, n)
+		b.Add(b, betas[i]).Mod(b, n)
+	}
+
+	for restart := 0; restart < maxRhoRestarts; restart++ {
+		for i := range multipliers {
+			if multipliers[i], alphas[i], betas[i], err = randomPoint(); err != nil {
+				return nil, err
+			}
+		}
+		x1, a1, b1, err := randomPoint()
+		if err != nil {
+			return nil, err
+		}
+		x2, a2, b2 := new(big.Int).Set(x1), new(big.Int).Set(a1), new(big.Int).Set(b1)
+
+		for {
+			step(x1, a1, b1)
+			step(x2, a2, b2)
+			step(x2, a2, b2)
+			if x1.Cmp(x2) == 0 {
+				break
+			}
+		}
+
+		// g^a1 h^b1 = g^a2 h^b2 => (b1 - b2) x = a2 - a1 (mod n)
+		bDiff := new(big.Int).Sub(b1, b2)
+		bDiff.Mod(bDiff, n)
+		aDiff := new(big.Int).Sub(a2, a1)
+		aDiff.Mod(aDiff, n)
+		if bDiff.Sign() == 0 {
+			continue
+		}
+		if x, ok := ms.solveLogCongruence(gm, hm, m, bDiff, aDiff, n); ok {
+			return x, nil
+		}
+	}
+	return nil, errors.New("discrete logarithm not found")
+}
+
+// solveLogCongruence перебирает решения сравнения b*x = a (mod n) и
+// возвращает то, которое удовлетворяет g^x = h (mod m)
+func (ms *MathService) solveLogCongruence(g, h, m, b, a, n *big.Int) (*big.Int, bool) {
+	d := ms.GCD(b, n)
+	if new(big.Int).Mod(a, d).Sign() != 0 || d.Cmp(big.NewInt(maxRhoCandidates)) > 0 {
+		return nil, false
+	}
+
+	nd := new(big.Int).Quo(n, d)
+	inv, err := ms.ModInverse(new(big.Int).Quo(b, d), nd)
+	if err != nil {
+		return nil, false
+	}
+	x := new(big.Int).Quo(a, d)
+	x.Mul(x, inv).Mod(x, nd)
+
+	for i := int64(0); i < d.Int64(); i++ {
+		if ms.ModPow(g, x, m).Cmp(h) == 0 {
+			return x, true
+		}
+		x.Add(x, nd)
+	}
+	return nil, false
+}
+
+// PohligHellman решает g^x = h (mod m), когда известно разложение порядка g:
+// задача сводится к логарифмам в подгруппах простых порядков q (через BSGS
+// или rho), показатель по модулю q^e восстанавливается по q-ичным цифрам,
+// а ответы объединяются китайской теоремой об остатках.
+func (ms *MathService) PohligHellman(g, h, m *big.Int, orderFactors []PrimePower) (*big.Int, error) {
+	if err := validateFactorization(orderFactors); err != nil {
+		return nil, err
+	}
+
+	n := big.NewInt(1)
+	for _, f := range orderFactors {
+		n.Mul(n, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent)), nil))
+	}
+	if ms.ModPow(g, n, m).Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("factorization does not match the order of g")
+	}
+
+	residues := make([]*big.Int, 0, len(orderFactors))
+	moduli := make([]*big.Int, 0, len(orderFactors))
+	for _, f := range orderFactors {
+		qe := new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent)), nil)
+		cofactor := new(big.Int).Quo(n, qe)
+
+		// g0, h0 лежат в подгруппе порядка q^e
+		g0 := ms.ModPow(g, cofactor, m)
+		h0 := ms.ModPow(h, cofactor, m)
+
+		// gamma порождает подгруппу порядка q
+		qPow := new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent-1)), nil)
+		gamma := ms.ModPow(g0, qPow, m)
+		g0Inv, err := ms.ModInverse(g0, m)
+		if err != nil {
+			return nil, err
+		}
+
+		x := big.NewInt(0)
+		qk := big.NewInt(1)
+		for k := 0; k < f.Exponent; k++ {
+			// hk = (g0^(-x) * h0)^(q^(e-1-k))
+			hk := ms.ModPow(g0Inv, x, m)
+			hk.Mul(hk, h0).Mod(hk, m)
+			hk = ms.ModPow(hk, qPow, m)
+			qPow.Quo(qPow, f.Prime)
+
+			digit, err := ms.primeOrderLog(gamma, hk, m, f.Prime)
+			if err != nil {
+				return nil, err
+			}
+			x.Add(x, new(big.Int).Mul(digit, qk))
+			qk.Mul(qk, f.Prime)
+		}
+
+		residues = append(residues, x)
+		moduli = append(moduli, qe)
+	}
+
+	x, _, err := ms.CRT(residues, moduli)
+	if err != nil {
+		return nil, err
+	}
+	if ms.ModPow(g, x, m).Cmp(new(big.Int).Mod(h, m)) != 0 {
+		return nil, errors.New("discrete logarithm does not exist")
+	}
+	return x, nil
+}
+
+// primeOrderLog решает логарифм в подгруппе простого порядка q
+func (ms *MathService) primeOrderLog(g, h, m, q *big.Int) (*big.Int, error) {
+	if q.BitLen() <= bsgsThresholdBits {
+		return ms.BabyStepGiantStep(g, h, m, q)
+	}
+	return ms.PollardRhoLog(g, h, m, q)
+}
+
+// logOrder возвращает порядок группы для задачи логарифмирования
+func (ms *MathService) logOrder(m, order *big.Int) (*big.Int, error) {
+	if m.Cmp(big.NewInt(2)) < 0 {
+		return nil, errors.New("modulus must be greater than 1")
+	}
+	if order == nil {
+		return new(big.Int).Sub(m, big.NewInt(1)), nil
+	}
+	if order.Sign() <= 0 {
+		return nil, errors.New("group order must be positive")
+	}
+	return new(big.Int).Set(order), nil
+}
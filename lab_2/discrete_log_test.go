@@ -0,0 +1,121 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+type discreteLogMethod struct {
+	name string
+	log  func(g, h, m *big.Int) (*big.Int, error)
+}
+
+// discreteLogMethods возвращает все три метода для группы известного порядка
+func discreteLogMethods(ms *MathService, order *big.Int, factors []PrimePower) []discreteLogMethod {
+	return []discreteLogMethod{
+		{"BabyStepGiantStep", func(g, h, m *big.Int) (*big.Int, error) { return ms.BabyStepGiantStep(g, h, m, order) }},
+		{"PollardRhoLog", func(g, h, m *big.Int) (*big.Int, error) { return ms.PollardRhoLog(g, h, m, order) }},
+		{"PohligHellman", func(g, h, m *big.Int) (*big.Int, error) { return ms.PohligHellman(g, h, m, factors) }},
+	}
+}
+
+func TestDiscreteLogPrimePowerOrder(t *testing.T) {
+	ms := NewMathService()
+	groups := []struct {
+		name   string
+		g, m   *big.Int
+		factor PrimePower
+	}{
+		// 3 - первообразный корень по модулю простого Ферма 65537
+		{"2^16", big.NewInt(3), big.NewInt(65537), PrimePower{Prime: big.NewInt(2), Exponent: 16}},
+		// p = 2 * 3^20 * 13 + 1, g = 2^((p-1)/3^20) имеет порядок 3^20
+		{"3^20", big.NewInt(67108864), big.NewInt(90656394427), PrimePower{Prime: big.NewInt(3), Exponent: 20}},
+	}
+
+	for _, group := range groups {
+		order := new(big.Int).Exp(group.factor.Prime, big.NewInt(int64(group.factor.Exponent)), nil)
+		if ms.ModPow(group.g, order, group.m).Cmp(big.NewInt(1)) != 0 {
+			t.Fatalf("%s: g^order != 1", group.name)
+		}
+
+		exponents := []*big.Int{big.NewInt(0), big.NewInt(1), new(big.Int).Sub(order, big.NewInt(1))}
+		for i := 0; i < 5; i++ {
+			x, _ := rand.Int(rand.Reader, order)
+			exponents = append(exponents, x)
+		}
+		for _, method := range discreteLogMethods(ms, order, []PrimePower{group.factor}) {
+			for _, x := range exponents {
+				h := ms.ModPow(group.g, x, group.m)
+				got, err := method.log(group.g, h, group.m)
+				if err != nil {
+					t.Fatalf("%s in %s: log of g^%s: %v", method.name, group.name, x, err)
+				}
+				if got.Cmp(x) != 0 {
+					t.Errorf("%s in %s = %s, want %s", method.name, group.name, got, x)
+				}
+			}
+		}
+
+		// 5 не лежит в подгруппе <g>: 5^(3^20) != 1 (mod p)
+		if group.name == "3^20" {
+			for _, method := range discreteLogMethods(ms, order, []PrimePower{group.factor}) {
+				if method.name == "PollardRhoLog" {
+					continue // rho не отличает отсутствие решения от неудачи
+				}
+				if x, err := method.log(group.g, big.NewInt(5), group.m); err == nil {
+					t.Errorf("%s: found log %s of an element outside <g>", method.name, x)
+				}
+			}
+		}
+	}
+}
+
+func TestDiscreteLogCompositeOrder(t *testing.T) {
+	ms := NewMathService()
+	// Безопасное простое: p - 1 = 2 * 500000003
+	p := big.NewInt(1000000007)
+	factors := []PrimePower{{Prime: big.NewInt(2), Exponent: 1}, {Prime: big.NewInt(500000003), Exponent: 1}}
+	g := big.NewInt(5) // первообразный корень
+
+	for i := 0; i < 3; i++ {
+		x, _ := rand.Int(rand.Reader, new(big.Int).Sub(p, big.NewInt(1)))
+		h := ms.ModPow(g, x, p)
+		for _, method := range discreteLogMethods(ms, nil, factors) {
+			got, err := method.log(g, h, p)
+			if err != nil || got.Cmp(x) != 0 {
+				t.Errorf("%s = %v, %v, want %s", method.name, got, err, x)
+			}
+		}
+	}
+
+	// Разложение не того порядка
+	wrong := []PrimePower{{Prime: big.NewInt(2), Exponent: 2}, {Prime: big.NewInt(3), Exponent: 1}}
+	if _, err := ms.PohligHellman(g, big.NewInt(7), p, wrong); err == nil {
+		t.Error("PohligHellman accepted a factorization of the wrong order")
+	}
+}
+
+func TestBabyStepGiantStepOrderLimit(t *testing.T) {
+	ms := NewMathService()
+	m := big.NewInt(1000000007)
+
+	limit := new(big.Int).Lsh(big.NewInt(1), maxBSGSOrderBits) // 49 бит
+	if _, err := ms.BabyStepGiantStep(big.NewInt(5), big.NewInt(7), m, limit); err == nil {
+		t.Errorf("order of %d bits accepted", limit.BitLen())
+	}
+	// 2^61 - 1 - простое; при order == nil порядок равен m - 1
+	mersenne := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 61), big.NewInt(1))
+	if _, err := ms.BabyStepGiantStep(big.NewInt(37), big.NewInt(7), mersenne, nil); err == nil {
+		t.Error("61-bit group accepted")
+	}
+
+	for _, order := range []*big.Int{big.NewInt(0), big.NewInt(-5)} {
+		if _, err := ms.BabyStepGiantStep(big.NewInt(5), big.NewInt(7), m, order); err == nil {
+			t.Errorf("order %s accepted", order)
+		}
+	}
+	if _, err := ms.BabyStepGiantStep(big.NewInt(5), big.NewInt(7), big.NewInt(1), nil); err == nil {
+		t.Error("modulus 1 accepted")
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"math/big"
+)
+
+const (
+	ecmStage2Factor = 50         // B2 = 50 * B1
+	ecmMaxB2        = 10_000_000 // ограничение памяти решета второй стадии
+	ecmStage2Step   = 210        // шаг D второй стадии (2*3*5*7)
+)
+
+// ecmSchedule границы B1 и число кривых для поиска множителей растущего
+// размера (~15, 20, 25, 30 и 35 десятичных цифр)
+var ecmSchedule = []struct {
+	bound  int
+	curves int
+}{
+	{2000, 25},
+	{11000, 90},
+	{50000, 300},
+	{250000, 700},
+	{1000000, 1800},
+}
+
+// montgomeryPoint точка кривой Монтгомери в проективных координатах (X : Z)
+type montgomeryPoint struct {
+	X, Z *big.Int
+}
+
+// ecmCurve кривая Монтгомери By^2 = x^3 + Ax^2 + x по модулю n,
+// хранится a24 = (A + 2) / 4
+type ecmCurve struct {
+	n   *big.Int
+	a24 *big.Int
+}
+
+// ECM ищет делитель n методом эллиптических кривых Ленстры:
+// на каждой из curves случайных кривых Монтгомери (параметризация Суямы,
+// порядок группы делится на 12) точка умножается на все степени простых
+// до bound. Если порядок кривой по модулю p оказался bound-гладким, координата
+// Z обращается в ноль по модулю p, и НОД(Z, n) дает делитель. Вторая стадия
+// допускает в порядке один простой множитель до B2 = 50 * B1.
+// nil без ошибки - делитель не найден.
+func (fs *FactorizationService) ECM(ctx context.Context, n *big.Int, bound, curves int) (*big.Int, error) {
+	primes := primesUpTo(bound)
+	one := big.NewInt(1)
+
+	b2 := bound * ecmStage2Factor
+	if b2 > ecmMaxB2 {
+		b2 = ecmMaxB2
+	}
+	isPrime := make([]bool, b2+1)
+	for _, p := range primesUpTo(b2) {
+		isPrime[p] = true
+	}
+
+	for c := 0; c < curves; c++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		curve, point, d, err := fs.suyamaCurve(n)
+		if err != nil {
+			return nil, err
+		}
+		if d != nil {
+			return d, nil
+		}
+
+		k := new(big.Int)
+		for i, p := range primes {
+			pk := p
+			for pk <= bound/p {
+				pk *= p
+			}
+			point = curve.multiply(point, k.SetInt64(int64(pk)))
+
+			if i%ctxCheckEvery == 0 {
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+			}
+		}
+
+		g := fs.mathService.GCD(point.Z, n)
+		if g.Cmp(n) == 0 {
+			continue
+		}
+		if g.Cmp(one) != 0 {
+			return g, nil
+		}
+
+		if g := curve.stage2(point, bound, isPrime); g != nil {
+			return g, nil
+		}
+	}
+	return nil, nil
+}
+
+// stage2 вторая стадия: находит делитель, если порядок Q по модулю p равен
+// простому q из (B1, B2]. Простые q = mD +- j перебираются шагами великана
+// R = mDQ; x(R) = x(jQ) по модулю p тогда и только тогда, когда R = +-jQ,
+// поэтому накапливается произведение X_R Z_j - X_j Z_R.
+func (c *ecmCurve) stage2(q montgomeryPoint, b1 int, isPrime []bool) *big.Int {
+	b2 := len(isPrime) - 1
+	const d = ecmStage2Step
+
+	// Шаги младенца: jQ для нечетных j < D/2; в переборе участвуют только j,
+	// взаимно простые с D
+	type babyStep struct {
+		j     int
+		point montgomeryPoint
+	}
+	var baby []babyStep
+	q2 := c.double(q)
+	prevJ, curJ := q, c.add(q2, q, q) // Q и 3Q
+	for j := 1; j < d/2; j += 2 {
+		if j > 3 {
+			prevJ, curJ = curJ, c.add(curJ, q2, prevJ)
+		}
+		point := curJ
+		if j == 1 {
+			point = q
+		}
+		if j%3 != 0 && j%5 != 0 && j%7 != 0 {
+			baby = append(baby, babyStep{j, point})
+		}
+	}
+
+	// Шаги великана R = mDQ, начиная с m >= 2, чтобы (m-1)DQ была определена
+	mStart := b1 / d
+	if mStart < 2 {
+		mStart = 2
+	}
+	step := c.multiply(q, big.NewInt(d))
+	prev := c.multiply(q, big.NewInt(int64((mStart-1)*d)))
+	r := c.multiply(q, big.NewInt(int64(mStart*d)))
+
+	acc := big.NewInt(1)
+	t1, t2 := new(big.Int), new(big.Int)
+	for m := mStart; m*d-d/2 <= b2; m++ {
+		for _, b := range baby {
+			lo, hi := m*d-b.j, m*d+b.j
+			if !(lo > b1 && lo <= b2 && isPrime[lo]) && !(hi > b1 && hi <= b2 && isPrime[hi]) {
+				continue
+			}
+			t1.Mul(r.X, b.point.Z)
+			t2.Mul(b.point.X, r.Z)
+			acc.Mul(acc, t1.Sub(t1, t2)).Mod(acc, c.n)
+		}
+		prev, r = r, c.add(r, step, prev)
+	}
+
+	g := new(big.Int).GCD(nil, nil, acc, c.n)
+	if g.Cmp(big.NewInt(1)) == 0 || g.Cmp(c.n) == 0 {
+		return nil
+	}
+	return g
+}
+
+// suyamaCurve строит случайную кривую по параметру sigma:
+// u = sigma^2 - 5, v = 4 sigma, P = (u^3 : v^3),
+// a24 = (v - u)^3 (3u + v) / (16 u^3 v). Если знаменатель необратим,
+// возвращается найденный при этом делитель.
+func (fs *FactorizationService) suyamaCurve(n *big.Int) (*ecmCurve, montgomeryPoint, *big.Int, error) {
+	sigma, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(7)))
+	if err != nil {
+		return nil, montgomeryPoint{}, nil, err
+	}
+	sigma.Add(sigma, big.NewInt(6))
+
+	u := new(big.Int).Mul(sigma, sigma)
+	u.Sub(u, big.NewInt(5)).Mod(u, n)
+	v := new(big.Int).Lsh(sigma, 2)
+	v.Mod(v, n)
+
+	u3 := new(big.Int).Exp(u, big.NewInt(3), n)
+	v3 := new(big.Int).Exp(v, big.NewInt(3), n)
+
+	num := new(big.Int).Sub(v, u)
+	num.Exp(num.Mod(num, n), big.NewInt(3), n)
+	t := new(big.Int).Mul(u, big.NewInt(3))
+	t.Add(t, v)
+	num.Mul(num, t).Mod(num, n)
+
+	den := new(big.Int).Mul(u3, v)
+	den.Lsh(den, 4).Mod(den, n)
+	denInv, err := fs.mathService.ModInverse(den, n)
+	if err != nil {
+		g := fs.mathService.GCD(den, n)
+		if g.Cmp(n) != 0 {
+			return nil, montgomeryPoint{}, g, nil
+		}
+		// Вырожденный sigma: пробуем другой
+		return fs.suyamaCurve(n)
+	}
+
+	curve := &ecmCurve{n: n, a24: num.Mul(num, denInv).Mod(num, n)}
+	return curve, montgomeryPoint{X: u3, Z: v3}, nil, nil
+}
+
+// double удвоение: X2 = (X+Z)^2 (X-Z)^2, Z2 = 4XZ ((X-Z)^2 + a24 * 4XZ)
+func (c *ecmCurve) double(p montgomeryPoint) montgomeryPoint {
+	sum := new(big.Int).Add(p.X, p.Z)
+	sum.Mul(sum, sum).Mod(sum, c.n)
+	diff := new(big.Int).Sub(p.X, p.Z)
+	diff.Mul(diff, diff).Mod(diff, c.n)
+
+	fourXZ := new(big.Int).Sub(sum, diff)
+	x := new(big.Int).Mul(sum, diff)
+	z := new(big.Int).Mul(c.a24, fourXZ)
+	z.Add(z, diff).Mul(z, fourXZ)
+	return montgomeryPoint{X: x.Mod(x, c.n), Z: z.Mod(z, c.n)}
+}
+
+// add дифференциальное сложение P + Q по известной разности P - Q
+func (c *ecmCurve) add(p, q, diff montgomeryPoint) montgomeryPoint {
+	u := new(big.Int).Sub(p.X, p.Z)
+	u.Mul(u, new(big.Int).Add(q.X, q.Z))
+	v := new(big.Int).Add(p.X, p.Z)
+	v.Mul(v, new(big.Int).Sub(q.X, q.Z))
+
+	x := new(big.Int).Add(u, v)
+	x.Mul(x, x).Mod(x, c.n).Mul(x, diff.Z)
+	z := new(big.Int).Sub(u, v)
+	z.Mul(z, z).Mod(z, c.n).Mul(z, diff.X)
+	return montgomeryPoint{X: x.Mod(x, c.n), Z: z.Mod(z, c.n)}
+}
+
+// multiply вычисляет kP лестницей Монтгомери (k > 0)
+func (c *ecmCurve) multiply(p montgomeryPoint, k *big.Int) montgomeryPoint {
+	r0, r1 := p, c.double(p)
+	for i := k.BitLen() - 2; i >= 0; i-- {
+		if k.Bit(i) == 1 {
+			r0, r1 = c.add(r1, r0, p), c.double(r1)
+		} else {
+			r0, r1 = c.double(r0), c.add(r1, r0, p)
+		}
+	}
+	return r0
+}
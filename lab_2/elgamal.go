@@ -0,0 +1,372 @@
+package main
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"errors"
+	"math/big"
+
+	"lab_4/primes"
+)
+
+// ElGamalParameters группа простого порядка Q в (Z/PZ)* с образующей G
+type ElGamalParameters struct {
+	P *big.Int
+	Q *big.Int
+	G *big.Int
+}
+
+type ElGamalPublicKey struct {
+	Params *ElGamalParameters
+	Y      *big.Int // y = g^x mod p
+}
+
+type ElGamalPrivateKey struct {
+	PublicKey *ElGamalPublicKey
+	X         *big.Int
+}
+
+// ElGamalCiphertext пара (c1, c2) = (g^k, M * y^k)
+type ElGamalCiphertext struct {
+	C1 *big.Int
+	C2 *big.Int
+}
+
+// ElGamalSignature подпись (r, s): r = g^k mod p, s = k^(-1) (H - x*r) mod q
+type ElGamalSignature struct {
+	R *big.Int
+	S *big.Int
+}
+
+// ElGamalService шифрование и подпись Эль-Гамаля в подгруппе простого порядка q.
+// Шифрование мультипликативно гомоморфно: E(M1) * E(M2) = E(M1 * M2); в
+// экспоненциальном варианте E(g^m) оно становится аддитивным.
+type ElGamalService struct {
+	mathService    *MathService
+	primalityTest  PrimalityTest
+	minProbability float64
+	params         *ElGamalParameters
+	publicKey      *ElGamalPublicKey
+	privateKey     *ElGamalPrivateKey
+}
+
+func NewElGamalService(testType PrimalityTestType, minProbability float64) *ElGamalService {
+	ms := NewMathService()
+	return &ElGamalService{
+		mathService:    ms,
+		primalityTest:  NewPrimalityTest(testType, ms),
+		minProbability: minProbability,
+	}
+}
+
+// GenerateParameters строит безопасное простое p = 2q + 1 параллельным поиском
+// lab_4/primes и берет образующую подгруппы квадратичных вычетов порядка q
+func (es *ElGamalService) GenerateParameters(bits int) error {
+	p, _, err := primes.Search(context.Background(), primes.SearchConfig{
+		Bits: bits,
+		Safe: true,
+		IsPrime: func(n *big.Int) (bool, error) {
+			result, err := es.primalityTest.Test(n, es.minProbability)
+			if err != nil {
+				return false, err
+			}
+			return result.Probable, nil
+		},
+	})
+	if err != nil {
+		return err
+	}
+	q := new(big.Int).Rsh(p, 1)
+
+	// g = h^2 лежит в подгруппе порядка q и отлично от 1 при h != +-1
+	for {
+		h, err := rand.Int(rand.Reader, new(big.Int).Sub(p, big.NewInt(3)))
+		if err != nil {
+			return err
+		}
+		h.Add(h, big.NewInt(2))
+		g := new(big.Int).Mul(h, h)
+		g.Mod(g, p)
+		if g.Cmp(big.NewInt(1)) != 0 {
+			es.params = &ElGamalParameters{P: p, Q: q, G: g}
+			es.publicKey, es.privateKey = nil, nil
+			return nil
+		}
+	}
+}
+
+// SetParameters задает готовые параметры после проверки: p и q простые,
+// q делит p - 1, g имеет порядок q
+func (es *ElGamalService) SetParameters(params *ElGamalParameters) error {
+	if params == nil || params.P == nil || params.Q == nil || params.G == nil {
+		return errors.New("incomplete ElGamal parameters")
+	}
+	for _, n := range []*big.Int{params.P, params.Q} {
+		result, err := es.primalityTest.Test(n, es.minProbability)
+		if err != nil {
+			return err
+		}
+		if !result.Probable {
+			return errors.New("ElGamal modulus and group order must be prime")
+		}
+	}
+	pMinus1 := new(big.Int).Sub(params.P, big.NewInt(1))
+	if new(big.Int).Mod(pMinus1, params.Q).Sign() != 0 {
+		return errors.New("q does not divide p - 1")
+	}
+	if params.G.Cmp(big.NewInt(1)) <= 0 || params.G.Cmp(params.P) >= 0 || !es.inGroup(params, params.G) {
+		return errors.New("g is not a generator of the order-q subgroup")
+	}
+
+	es.params = &ElGamalParameters{
+		P: new(big.Int).Set(params.P),
+		Q: new(big.Int).Set(params.Q),
+		G: new(big.Int).Set(params.G),
+	}
+	es.publicKey, es.privateKey = nil, nil
+	return nil
+}
+
+func (es *ElGamalService) GenerateKeys() error {
+	if es.params == nil {
+		return errors.New("parameters not generated")
+	}
+	x, err := es.randomExponent()
+	if err != nil {
+		return err
+	}
+
+	pub := &ElGamalPublicKey{Params: es.params, Y: es.mathService.ModPow(es.params.G, x, es.params.P)}
+	es.publicKey = pub
+	es.privateKey = &ElGamalPrivateKey{PublicKey: pub, X: x}
+	return nil
+}
+
+func (es *ElGamalService) GetParameters() *ElGamalParameters {
+	return es.params
+}
+
+func (es *ElGamalService) GetPublicKey() *ElGamalPublicKey {
+	return es.publicKey
+}
+
+// Encrypt шифрует число m из [1, q]. Для безопасного простого p = 2q + 1
+// m отображается в подгруппу квадратичных вычетов: m или p - m (-1 - невычет
+// при p = 3 mod 4), поэтому шифротексты не раскрывают символ Лежандра m.
+func (es *ElGamalService) Encrypt(message *big.Int) (*ElGamalCiphertext, error) {
+	if es.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	params := es.params
+	safe := new(big.Int).Lsh(params.Q, 1)
+	if safe.Add(safe, big.NewInt(1)).Cmp(params.P) != 0 {
+		return nil, errors.New("message encoding requires a safe prime p = 2q + 1")
+	}
+	if message.Sign() <= 0 || message.Cmp(params.Q) > 0 {
+		return nil, errors.New("message out of range [1, q]")
+	}
+
+	element := new(big.Int).Set(message)
+	if es.mathService.LegendreSymbol(element, params.P) != 1 {
+		element.Sub(params.P, element)
+	}
+	return es.EncryptElement(element)
+}
+
+// Decrypt расшифровывает результат Encrypt
+func (es *ElGamalService) Decrypt(ciphertext *ElGamalCiphertext) (*big.Int, error) {
+	element, err := es.DecryptElement(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if element.Cmp(es.params.Q) > 0 {
+		element.Sub(es.params.P, element)
+	}
+	return element, nil
+}
+
+// EncryptElement шифрует элемент M подгруппы: (g^k, M * y^k) со случайным k
+func (es *ElGamalService) EncryptElement(element *big.Int) (*ElGamalCiphertext, error) {
+	if es.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	params := es.params
+	if element.Sign() <= 0 || element.Cmp(params.P) >= 0 || !es.inGroup(params, element) {
+		return nil, errors.New("message is not an element of the order-q subgroup")
+	}
+
+	k, err := es.randomExponent()
+	if err != nil {
+		return nil, err
+	}
+	c2 := es.mathService.ModPow(es.publicKey.Y, k, params.P)
+	c2.Mul(c2, element).Mod(c2, params.P)
+	return &ElGamalCiphertext{C1: es.mathService.ModPow(params.G, k, params.P), C2: c2}, nil
+}
+
+// DecryptElement вычисляет M = c2 * c1^(-x) = c2 * c1^(q - x) mod p
+func (es *ElGamalService) DecryptElement(ciphertext *ElGamalCiphertext) (*big.Int, error) {
+	if es.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	if err := es.checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+	params := es.params
+
+	s := es.mathService.ModPow(ciphertext.C1, new(big.Int).Sub(params.Q, es.privateKey.X), params.P)
+	s.Mul(s, ciphertext.C2)
+	return s.Mod(s, params.P), nil
+}
+
+// EncryptExponent экспоненциальный вариант: шифруется g^m, и произведение
+// шифротекстов расшифровывается в сумму показателей
+func (es *ElGamalService) EncryptExponent(m *big.Int) (*ElGamalCiphertext, error) {
+	if es.params == nil {
+		return nil, errors.New("parameters not generated")
+	}
+	return es.EncryptElement(es.mathService.ModPow(es.params.G, new(big.Int).Mod(m, es.params.Q), es.params.P))
+}
+
+// DecryptExponent расшифровывает g^m и находит m из [0, bound) методом шагов
+// младенца и великана за O(sqrt(bound))
+func (es *ElGamalService) DecryptExponent(ciphertext *ElGamalCiphertext, bound *big.Int) (*big.Int, error) {
+	element, err := es.DecryptElement(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	if bound.Sign() <= 0 || bound.Cmp(es.params.Q) > 0 {
+		return nil, errors.New("bound must be in (0, q]")
+	}
+	return es.mathService.BabyStepGiantStep(es.params.G, element, es.params.P, bound)
+}
+
+// Multiply перемножает шифротексты покомпонентно: E(M1) * E(M2) = E(M1 * M2),
+// для экспоненциального варианта E(g^a) * E(g^b) = E(g^(a+b))
+func (es *ElGamalService) Multiply(c1, c2 *ElGamalCiphertext) (*ElGamalCiphertext, error) {
+	if err := es.checkCiphertext(c1); err != nil {
+		return nil, err
+	}
+	if err := es.checkCiphertext(c2); err != nil {
+		return nil, err
+	}
+	p := es.params.P
+	a := new(big.Int).Mul(c1.C1, c2.C1)
+	b := new(big.Int).Mul(c1.C2, c2.C2)
+	return &ElGamalCiphertext{C1: a.Mod(a, p), C2: b.Mod(b, p)}, nil
+}
+
+// Power возводит шифротекст в степень k: E(M)^k = E(M^k), E(g^m)^k = E(g^(k*m))
+func (es *ElGamalService) Power(ciphertext *ElGamalCiphertext, k *big.Int) (*ElGamalCiphertext, error) {
+	if err := es.checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+	params := es.params
+	e := new(big.Int).Mod(k, params.Q)
+	return &ElGamalCiphertext{
+		C1: es.mathService.ModPow(ciphertext.C1, e, params.P),
+		C2: es.mathService.ModPow(ciphertext.C2, e, params.P),
+	}, nil
+}
+
+// Sign подписывает хеш сообщения: r = g^k mod p, s = k^(-1) (H - x*r) mod q,
+// где H - хеш, приведенный по модулю q
+func (es *ElGamalService) Sign(hash crypto.Hash, digest []byte) (*ElGamalSignature, error) {
+	if es.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	if err := checkDigest(hash, digest); err != nil {
+		return nil, err
+	}
+	params := es.params
+	h := es.digestToExponent(digest)
+
+	for {
+		k, err := es.randomExponent()
+		if err != nil {
+			return nil, err
+		}
+		r := es.mathService.ModPow(params.G, k, params.P)
+
+		kInv, err := es.mathService.ModInverse(k, params.Q)
+		if err != nil {
+			return nil, err
+		}
+		s := new(big.Int).Mul(es.privateKey.X, r)
+		s.Sub(h, s)
+		s.Mul(s, kInv).Mod(s, params.Q)
+		if s.Sign() != 0 {
+			return &ElGamalSignature{R: r, S: s}, nil
+		}
+	}
+}
+
+// Verify проверяет подпись: g^H = y^r * r^s (mod p), r - элемент подгруппы
+func (es *ElGamalService) Verify(hash crypto.Hash, digest []byte, signature *ElGamalSignature) error {
+	if es.publicKey == nil {
+		return errors.New("keys not generated")
+	}
+	if err := checkDigest(hash, digest); err != nil {
+		return err
+	}
+	params := es.params
+	errInvalid := errors.New("invalid ElGamal signature")
+	if signature == nil || signature.R == nil || signature.S == nil {
+		return errInvalid
+	}
+	if signature.R.Sign() <= 0 || signature.R.Cmp(params.P) >= 0 || !es.inGroup(params, signature.R) {
+		return errInvalid
+	}
+	if signature.S.Sign() <= 0 || signature.S.Cmp(params.Q) >= 0 {
+		return errInvalid
+	}
+
+	left := es.mathService.ModPow(params.G, es.digestToExponent(digest), params.P)
+	right := es.mathService.ModPow(es.publicKey.Y, new(big.Int).Mod(signature.R, params.Q), params.P)
+	right.Mul(right, es.mathService.ModPow(signature.R, signature.S, params.P)).Mod(right, params.P)
+	if left.Cmp(right) != 0 {
+		return errInvalid
+	}
+	return nil
+}
+
+// digestToExponent берет старшие биты хеша по длине q (как в DSA) и
+// приводит по модулю q
+func (es *ElGamalService) digestToExponent(digest []byte) *big.Int {
+	h := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - es.params.Q.BitLen(); excess > 0 {
+		h.Rsh(h, uint(excess))
+	}
+	return h.Mod(h, es.params.Q)
+}
+
+// randomExponent случайное число из [1, q - 1]
+func (es *ElGamalService) randomExponent() (*big.Int, error) {
+	k, err := rand.Int(rand.Reader, new(big.Int).Sub(es.params.Q, big.NewInt(1)))
+	if err != nil {
+		return nil, err
+	}
+	return k.Add(k, big.NewInt(1)), nil
+}
+
+// inGroup сообщает, что x лежит в подгруппе порядка q: x^q = 1 (mod p)
+func (es *ElGamalService) inGroup(params *ElGamalParameters, x *big.Int) bool {
+	return es.mathService.ModPow(x, params.Q, params.P).Cmp(big.NewInt(1)) == 0
+}
+
+func (es *ElGamalService) checkCiphertext(c *ElGamalCiphertext) error {
+	if es.params == nil {
+		return errors.New("parameters not generated")
+	}
+	if c == nil || c.C1 == nil || c.C2 == nil {
+		return errors.New("incomplete ElGamal ciphertext")
+	}
+	p := es.params.P
+	for _, x := range []*big.Int{c.C1, c.C2} {
+		if x.Sign() <= 0 || x.Cmp(p) >= 0 || !es.inGroup(es.params, x) {
+			return errors.New("ciphertext is not in the order-q subgroup")
+		}
+	}
+	return nil
+}
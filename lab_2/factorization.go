@@ -0,0 +1,417 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"time"
+)
+
+const (
+	rhoOnlyBits     = 64      // до этого размера достаточно ро-метода Брента
+	siqsMaxBits     = 200     // выше квадратичное решето слишком медленное, используется только ECM
+	pMinus1Bound    = 50000   // граница B1 метода p-1 для составных чисел
+	rhoIterations   = 1 << 18 // ограничение ро-метода перед переходом к ECM/SIQS
+	ctxCheckEvery   = 256     // период проверки отмены контекста в циклах
+	rhoBatchSize    = 128     // произведений |x - y| накапливается между вычислениями НОД
+	maxPerfectPower = 64      // наибольшая проверяемая степень n = m^k (для k > 64 m < 2)
+)
+
+// FactorizationStep запись о найденном делителе: каким методом и за какое время
+type FactorizationStep struct {
+	Method   string
+	Divisor  *big.Int
+	Cofactor *big.Int
+	Duration time.Duration
+}
+
+type FactorizationResult struct {
+	N          *big.Int
+	Factors    []PrimePower        // разложение на простые по возрастанию
+	Unfactored []*big.Int          // составные множители, не разложенные до отмены
+	Steps      []FactorizationStep // найденные по ходу нетривиальные делители
+	Duration   time.Duration
+	Success    bool
+}
+
+// FactorizationService раскладывает целые числа на простые множители,
+// комбинируя методы по размеру числа: пробное деление, проверку на точную
+// степень, ро-метод Брента, p-1 Полларда, метод эллиптических кривых Ленстры
+// и самоинициализирующееся квадратичное решето. Каждый найденный простой
+// множитель подтверждается настроенным тестом простоты.
+type FactorizationService struct {
+	mathService    *MathService
+	primalityTest  PrimalityTest
+	minProbability float64
+	timeLimit      time.Duration
+}
+
+func NewFactorizationService(testType PrimalityTestType, minProbability float64) *FactorizationService {
+	ms := NewMathService()
+	return &FactorizationService{
+		mathService:    ms,
+		primalityTest:  NewPrimalityTest(testType, ms),
+		minProbability: minProbability,
+	}
+}
+
+// SetTimeLimit ограничивает время одного вызова Factor; 0 снимает ограничение
+func (fs *FactorizationService) SetTimeLimit(limit time.Duration) {
+	fs.timeLimit = limit
+}
+
+// Factor раскладывает n > 1 на простые множители. При отмене контекста или
+// истечении лимита времени возвращается частичный результат (найденные простые
+// и неразложенные составные множители) вместе с ошибкой контекста.
+func (fs *FactorizationService) Factor(ctx context.Context, n *big.Int) (*FactorizationResult, error) {
+	if n.Cmp(big.NewInt(2)) < 0 {
+		return nil, errors.New("number to factor must be greater than 1")
+	}
+	if fs.timeLimit > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, fs.timeLimit)
+		defer cancel()
+	}
+
+	start := time.Now()
+	result := &FactorizationResult{N: new(big.Int).Set(n)}
+	exponents := make(map[string]int)
+	primes := make(map[string]*big.Int)
+	addPrime := func(p *big.Int, k int) {
+		key := p.String()
+		primes[key] = p
+		exponents[key] += k
+	}
+
+	// Малые множители снимаются пробным делением
+	m := new(big.Int).Set(n)
+	for p, k := range trialDivide(m) {
+		addPrime(big.NewInt(int64(p)), k)
+	}
+
+	// Очередь составных (или еще не проверенных) множителей с кратностями
+	type pending struct {
+		value *big.Int
+		mult  int
+	}
+	queue := []pending{}
+	if m.Cmp(big.NewInt(1)) > 0 {
+		queue = append(queue, pending{m, 1})
+	}
+
+	var err error
+	for len(queue) > 0 {
+		item := queue[len(queue)-1]
+		queue = queue[:len(queue)-1]
+
+		// Уже найденные простые снимаются сразу, без повторного поиска
+		for _, p := range primes {
+			for {
+				q, r := new(big.Int).QuoRem(item.value, p, new(big.Int))
+				if r.Sign() != 0 {
+					break
+				}
+				item.value = q
+				addPrime(p, item.mult)
+			}
+		}
+		if item.value.Cmp(big.NewInt(1)) == 0 {
+			continue
+		}
+
+		if err == nil {
+			err = ctx.Err()
+		}
+		if err != nil {
+			result.Unfactored = append(result.Unfactored, item.value)
+			continue
+		}
+
+		primality, testErr := fs.primalityTest.Test(item.value, fs.minProbability)
+		if testErr != nil {
+			err = testErr
+			result.Unfactored = append(result.Unfactored, item.value)
+			continue
+		}
+		if primality.Probable {
+			addPrime(item.value, item.mult)
+			continue
+		}
+
+		if root, k := perfectPower(item.value); k > 1 {
+			queue = append(queue, pending{root, item.mult * k})
+			continue
+		}
+
+		stepStart := time.Now()
+		d, method, splitErr := fs.split(ctx, item.value)
+		if splitErr != nil {
+			err = splitErr
+			result.Unfactored = append(result.Unfactored, item.value)
+			continue
+		}
+
+		cofactor := new(big.Int).Quo(item.value, d)
+		result.Steps = append(result.Steps, FactorizationStep{
+			Method:   method,
+			Divisor:  d,
+			Cofactor: cofactor,
+			Duration: time.Since(stepStart),
+		})
+		// Меньший множитель обрабатывается первым: он вероятнее простой
+		if d.Cmp(cofactor) > 0 {
+			d, cofactor = cofactor, d
+		}
+		queue = append(queue, pending{cofactor, item.mult}, pending{d, item.mult})
+	}
+
+	for key, p := range primes {
+		result.Factors = append(result.Factors, PrimePower{Prime: p, Exponent: exponents[key]})
+	}
+	sort.Slice(result.Factors, func(i, j int) bool {
+		return result.Factors[i].Prime.Cmp(result.Factors[j].Prime) < 0
+	})
+	result.Duration = time.Since(start)
+
+	if err != nil {
+		return result, err
+	}
+	if verifyErr := fs.verify(n, result.Factors); verifyErr != nil {
+		return result, verifyErr
+	}
+	result.Success = true
+	return result, nil
+}
+
+// split находит нетривиальный делитель составного числа n, не являющегося
+// точной степенью и не имеющего малых делителей
+func (fs *FactorizationService) split(ctx context.Context, n *big.Int) (*big.Int, string, error) {
+	bits := n.BitLen()
+	if bits <= rhoOnlyBits {
+		d, err := fs.BrentRho(ctx, n, 0)
+		return d, "rho Брента", err
+	}
+
+	if d, err := fs.PollardPMinus1(ctx, n, pMinus1Bound); err != nil || d != nil {
+		return d, "p-1 Полларда", err
+	}
+	if d, err := fs.BrentRho(ctx, n, rhoIterations); err != nil || d != nil {
+		return d, "rho Брента", err
+	}
+
+	if bits <= siqsMaxBits {
+		// Короткий прогон ECM находит несбалансированные множители до ~15 цифр
+		if d, err := fs.ECM(ctx, n, 2000, 25); err != nil || d != nil {
+			return d, "ECM", err
+		}
+		d, err := fs.SIQS(ctx, n)
+		return d, "SIQS", err
+	}
+
+	for _, stage := range ecmSchedule {
+		if d, err := fs.ECM(ctx, n, stage.bound, stage.curves); err != nil || d != nil {
+			return d, "ECM", err
+		}
+	}
+	last := ecmSchedule[len(ecmSchedule)-1]
+	for {
+		if d, err := fs.ECM(ctx, n, last.bound, last.curves); err != nil || d != nil {
+			return d, "ECM", err
+		}
+	}
+}
+
+// verify проверяет, что произведение множителей равно n и каждый из них
+// проходит тест простоты
+func (fs *FactorizationService) verify(n *big.Int, factors []PrimePower) error {
+	product := big.NewInt(1)
+	for _, f := range factors {
+		// Множители меньше sieveLimit получены пробным делением на таблицу простых
+		if f.Prime.Cmp(big.NewInt(sieveLimit)) >= 0 {
+			primality, err := fs.primalityTest.Test(f.Prime, fs.minProbability)
+			if err != nil {
+				return err
+			}
+			if !primality.Probable {
+				return fmt.Errorf("factor %s failed the primality test", f.Prime)
+			}
+		}
+		product.Mul(product, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent)), nil))
+	}
+	if product.Cmp(n) != 0 {
+		return errors.New("product of factors does not match the input")
+	}
+	return nil
+}
+
+// trialDivide снимает с m множители 2 и нечетные простые меньше sieveLimit,
+// возвращая их кратности; m изменяется на месте
+func trialDivide(m *big.Int) map[uint32]int {
+	found := make(map[uint32]int)
+	if tz := m.TrailingZeroBits(); tz > 0 {
+		found[2] = int(tz)
+		m.Rsh(m, tz)
+	}
+
+	q, r := new(big.Int), new(big.Int)
+	for _, p := range smallPrimes {
+		pBig := big.NewInt(int64(p))
+		for {
+			q.QuoRem(m, pBig, r)
+			if r.Sign() != 0 {
+				break
+			}
+			m.Set(q)
+			found[p]++
+		}
+		// Остаток меньше p^2 не имеет делителей больше p, то есть простой или 1
+		if m.Cmp(big.NewInt(int64(p)*int64(p))) < 0 {
+			break
+		}
+	}
+	return found
+}
+
+// perfectPower проверяет, является ли n точной степенью m^k с k > 1,
+// и возвращает m и наибольшее такое k (k = 1, если не является)
+func perfectPower(n *big.Int) (*big.Int, int) {
+	for k := maxPerfectPower; k >= 2; k-- {
+		if n.BitLen() < k {
+			continue
+		}
+		if root, exact := integerRoot(n, k); exact {
+			return root, k
+		}
+	}
+	return n, 1
+}
+
+// BrentRho ищет делитель n ро-методом Полларда с поиском цикла по Бренту и
+// накоплением произведений перед НОД. maxIterations = 0 означает перебор
+// до успеха или отмены контекста; nil без ошибки - делитель не найден.
+// n должно быть составным: для простого n перебор не завершился бы.
+func (fs *FactorizationService) BrentRho(ctx context.Context, n *big.Int, maxIterations int) (*big.Int, error) {
+	if n.Cmp(big.NewInt(4)) < 0 {
+		return nil, errors.New("rho method requires n >= 4")
+	}
+	primality, err := fs.primalityTest.Test(n, fs.minProbability)
+	if err != nil {
+		return nil, err
+	}
+	if primality.Probable {
+		return nil, fmt.Errorf("%s is a probable prime", n)
+	}
+
+	one := big.NewInt(1)
+	for {
+		c, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(3)))
+		if err != nil {
+			return nil, err
+		}
+		c.Add(c, one)
+		y, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+
+		f := func(x *big.Int) {
+			x.Mul(x, x).Add(x, c).Mod(x, n)
+		}
+
+		g := big.NewInt(1)
+		q := big.NewInt(1)
+		x, ys := new(big.Int), new(big.Int)
+		diff := new(big.Int)
+		iterations := 0
+
+		for r := 1; g.Cmp(one) == 0; r *= 2 {
+			x.Set(y)
+			for i := 0; i < r; i++ {
+				f(y)
+			}
+			for k := 0; k < r && g.Cmp(one) == 0; k += rhoBatchSize {
+				ys.Set(y)
+				for i := 0; i < rhoBatchSize && i < r-k; i++ {
+					f(y)
+					q.Mul(q, diff.Sub(x, y).Abs(diff)).Mod(q, n)
+				}
+				g = fs.mathService.GCD(q, n)
+
+				iterations += rhoBatchSize
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				if maxIterations > 0 && iterations >= maxIterations {
+					return nil, nil
+				}
+			}
+		}
+
+		// Пакет перескочил через делитель: повтор по одному шагу
+		if g.Cmp(n) == 0 {
+			for {
+				f(ys)
+				g = fs.mathService.GCD(diff.Sub(x, ys).Abs(diff), n)
+				if g.Cmp(one) != 0 {
+					break
+				}
+			}
+		}
+		if g.Cmp(n) != 0 {
+			return g, nil
+		}
+		// Вырожденный цикл: другая константа c
+	}
+}
+
+// PollardPMinus1 первая стадия метода p-1: a = 2^M mod n, где M - произведение
+// степеней простых, не превосходящих bound. Делитель p находится, если p-1
+// bound-гладкое. nil без ошибки - делитель не найден.
+func (fs *FactorizationService) PollardPMinus1(ctx context.Context, n *big.Int, bound int) (*big.Int, error) {
+	a := big.NewInt(2)
+	one := big.NewInt(1)
+	exp := new(big.Int)
+
+	for i, p := range primesUpTo(bound) {
+		// Наибольшая степень p, не превосходящая bound
+		pk := p
+		for pk <= bound/p {
+			pk *= p
+		}
+		a = fs.mathService.ModPow(a, exp.SetInt64(int64(pk)), n)
+
+		if i%ctxCheckEvery == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	g := fs.mathService.GCD(new(big.Int).Sub(a, one), n)
+	if g.Cmp(one) == 0 || g.Cmp(n) == 0 {
+		return nil, nil
+	}
+	return g, nil
+}
+
+// primesUpTo возвращает все простые числа, не превосходящие limit
+func primesUpTo(limit int) []int {
+	if limit < 2 {
+		return nil
+	}
+	composite := make([]bool, limit+1)
+	primes := []int{2}
+	for i := 3; i <= limit; i += 2 {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, i)
+		for j := i * i; j <= limit; j += 2 * i {
+			composite[j] = true
+		}
+	}
+	return primes
+}
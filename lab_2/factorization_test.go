@@ -0,0 +1,137 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func testFactorizationService() *FactorizationService {
+	return NewFactorizationService(TestMillerRabin, 0.999999)
+}
+
+// factorsProduct перемножает найденные простые и неразложенные множители
+func factorsProduct(result *FactorizationResult) *big.Int {
+	product := big.NewInt(1)
+	for _, f := range result.Factors {
+		product.Mul(product, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent)), nil))
+	}
+	for _, u := range result.Unfactored {
+		product.Mul(product, u)
+	}
+	return product
+}
+
+func requireFactorization(t *testing.T, n *big.Int, want []PrimePower) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	result, err := testFactorizationService().Factor(ctx, n)
+	if err != nil {
+		t.Fatalf("Factor(%s): %v", n, err)
+	}
+	if !result.Success || len(result.Unfactored) != 0 {
+		t.Fatalf("Factor(%s): success %v, unfactored %v", n, result.Success, result.Unfactored)
+	}
+	if len(result.Factors) != len(want) {
+		t.Fatalf("Factor(%s) = %v, want %v", n, result.Factors, want)
+	}
+	for i, f := range result.Factors {
+		if f.Prime.Cmp(want[i].Prime) != 0 || f.Exponent != want[i].Exponent {
+			t.Errorf("factor %d = %s^%d, want %s^%d", i, f.Prime, f.Exponent, want[i].Prime, want[i].Exponent)
+		}
+	}
+}
+
+func TestFactorKnownNumbers(t *testing.T) {
+	pp := func(p string, k int) PrimePower {
+		prime, _ := new(big.Int).SetString(p, 10)
+		return PrimePower{Prime: prime, Exponent: k}
+	}
+	one := big.NewInt(1)
+
+	t.Run("F6", func(t *testing.T) {
+		// 2^64 + 1 = 274177 * 67280421310721
+		n := new(big.Int).Add(new(big.Int).Lsh(one, 64), one)
+		requireFactorization(t, n, []PrimePower{pp("274177", 1), pp("67280421310721", 1)})
+	})
+	t.Run("F7", func(t *testing.T) {
+		if testing.Short() {
+			t.Skip("129-bit number with two large factors")
+		}
+		// 2^128 + 1 = 59649589127497217 * 5704689200685129054721
+		n := new(big.Int).Add(new(big.Int).Lsh(one, 128), one)
+		requireFactorization(t, n, []PrimePower{pp("59649589127497217", 1), pp("5704689200685129054721", 1)})
+	})
+	t.Run("mixed", func(t *testing.T) {
+		// 2^4 * 3^2 * 4099^2 * p1^3 * p2 * p3: пробное деление, точная степень и ро-метод
+		want := []PrimePower{pp("2", 4), pp("3", 2), pp("4099", 2),
+			pp("1099511627791", 3), pp("281474976710677", 1), pp("281474976710731", 1)}
+		n := big.NewInt(1)
+		for _, f := range want {
+			n.Mul(n, new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent)), nil))
+		}
+		requireFactorization(t, n, want)
+	})
+	t.Run("prime", func(t *testing.T) {
+		requireFactorization(t, big.NewInt(2147483647), []PrimePower{pp("2147483647", 1)})
+	})
+}
+
+func TestFactorCancellation(t *testing.T) {
+	// Произведение двух 129-битных простых за 200 мс не раскладывается
+	p, _ := new(big.Int).SetString("340282366920938463463374607431768211507", 10)
+	q, _ := new(big.Int).SetString("340282366920938463463374607431768211537", 10)
+	n := new(big.Int).Mul(p, q)
+	n.Mul(n, big.NewInt(12)) // малые множители находятся до отмены
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	result, err := testFactorizationService().Factor(ctx, n)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("cancellation took %v", elapsed)
+	}
+	if result == nil || result.Success || len(result.Unfactored) == 0 {
+		t.Fatalf("partial result = %+v", result)
+	}
+	if factorsProduct(result).Cmp(n) != 0 {
+		t.Error("partial factorization does not multiply back to n")
+	}
+
+	if _, err := testFactorizationService().Factor(context.Background(), big.NewInt(1)); err == nil {
+		t.Error("Factor(1) succeeded")
+	}
+}
+
+func TestBrentRhoValidatesInput(t *testing.T) {
+	fs := testFactorizationService()
+	ctx := context.Background()
+	for _, n := range []int64{-5, 0, 1, 2, 3, 101, 2147483647} {
+		if d, err := fs.BrentRho(ctx, big.NewInt(n), 0); err == nil {
+			t.Errorf("BrentRho(%d) = %v without error", n, d)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	for n := int64(4); n < 2000; n++ {
+		nb := big.NewInt(n)
+		if nb.ProbablyPrime(20) {
+			continue
+		}
+		d, err := fs.BrentRho(ctx, nb, 0)
+		if err != nil {
+			t.Fatalf("BrentRho(%d): %v", n, err)
+		}
+		if d.Cmp(big.NewInt(1)) <= 0 || d.Cmp(nb) >= 0 || new(big.Int).Mod(nb, d).Sign() != 0 {
+			t.Fatalf("BrentRho(%d) = %s, not a proper divisor", n, d)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package main
+
+import "math/big"
+
+type BellcoreAttackResult struct {
+	P       *big.Int
+	Q       *big.Int
+	Success bool
+}
+
+// BellcoreAttackService атака Боне-ДеМилло-Липтона (Bellcore) на RSA-КТО:
+// если одна из половин КТО вычислена с ошибкой, то неверная подпись s'
+// совпадает с верной по модулю второго простого, и gcd(s'^e - m, n) дает делитель n
+type BellcoreAttackService struct {
+	mathService *MathService
+}
+
+func NewBellcoreAttackService() *BellcoreAttackService {
+	return &BellcoreAttackService{
+		mathService: NewMathService(),
+	}
+}
+
+func (bas *BellcoreAttackService) Attack(publicKey *RSAPublicKey, message, faultySignature *big.Int) *BellcoreAttackResult {
+	result := &BellcoreAttackResult{
+		Success: false,
+	}
+
+	// s'^e - m mod n
+	diff := bas.mathService.ModPow(faultySignature, publicKey.E, publicKey.N)
+	diff.Sub(diff, message)
+	diff.Mod(diff, publicKey.N)
+
+	factor := bas.mathService.GCD(diff, publicKey.N)
+	if factor.Cmp(big.NewInt(1)) == 0 || factor.Cmp(publicKey.N) == 0 {
+		return result
+	}
+
+	result.P = factor
+	result.Q = new(big.Int).Div(publicKey.N, factor)
+	result.Success = true
+	return result
+}
+
+// signWithFault подписывает message, исказив половину КТО по модулю q функцией
+// fault. Искажение недоступно вне пакета и нужно только для демонстрации атаки;
+// после подписи сервис возвращается к обычной работе.
+func (rs *RSAService) signWithFault(message *big.Int, fault func(mq *big.Int) *big.Int) (*big.Int, error) {
+	rs.faultInjector = fault
+	defer func() { rs.faultInjector = nil }()
+	return rs.Sign(message)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestBellcoreAttack(t *testing.T) {
+	rs, _ := testRSAService(t)
+	pub, priv := rs.GetPublicKey(), rs.GetPrivateKey()
+	message := big.NewInt(0x5eed)
+	flipLowBit := func(mq *big.Int) *big.Int { return new(big.Int).Xor(mq, big.NewInt(1)) }
+
+	if _, err := rs.signWithFault(message, flipLowBit); err == nil {
+		t.Fatal("fault check did not detect the faulty CRT half")
+	}
+
+	rs.SetFaultCheck(false)
+	faulty, err := rs.signWithFault(message, flipLowBit)
+	rs.SetFaultCheck(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := NewBellcoreAttackService().Attack(pub, message, faulty)
+	if !result.Success || result.P.Cmp(priv.P) != 0 || result.Q.Cmp(priv.Q) != 0 {
+		t.Fatalf("attack did not recover p: %+v", result)
+	}
+
+	// После signWithFault сервис снова подписывает верно
+	signature, err := rs.Sign(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if NewBellcoreAttackService().Attack(pub, message, signature).Success {
+		t.Error("attack succeeded on a correct signature")
+	}
+}
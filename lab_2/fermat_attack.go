@@ -0,0 +1,73 @@
+package main
+
+import "math/big"
+
+type FermatAttackResult struct {
+	P          *big.Int
+	Q          *big.Int
+	Iterations int
+	Success    bool
+}
+
+// FermatAttackService факторизация Ферма: если p и q близки, то
+// n = a^2 - b^2 = (a - b)(a + b) при a, немного превышающем sqrt(n).
+// Именно от нее защищает проверка |p - q| в GenerateKeyPair.
+type FermatAttackService struct {
+	mathService   *MathService
+	maxIterations int
+}
+
+func NewFermatAttackService(maxIterations int) *FermatAttackService {
+	return &FermatAttackService{
+		mathService:   NewMathService(),
+		maxIterations: maxIterations,
+	}
+}
+
+func (fas *FermatAttackService) Attack(publicKey *RSAPublicKey) *FermatAttackResult {
+	result := &FermatAttackResult{
+		Success: false,
+	}
+
+	n := publicKey.N
+	if n.Bit(0) == 0 {
+		result.P = big.NewInt(2)
+		result.Q = new(big.Int).Rsh(n, 1)
+		result.Success = true
+		return result
+	}
+
+	// a = ceil(sqrt(n))
+	a := new(big.Int).Sqrt(n)
+	if new(big.Int).Mul(a, a).Cmp(n) < 0 {
+		a.Add(a, big.NewInt(1))
+	}
+
+	// b2 = a^2 - n, при увеличении a на 1 b2 растет на 2a + 1
+	b2 := new(big.Int).Mul(a, a)
+	b2.Sub(b2, n)
+	b := new(big.Int)
+
+	for result.Iterations = 1; result.Iterations <= fas.maxIterations; result.Iterations++ {
+		b.Sqrt(b2)
+		if new(big.Int).Mul(b, b).Cmp(b2) == 0 {
+			p := new(big.Int).Add(a, b)
+			q := new(big.Int).Sub(a, b)
+			if q.Cmp(big.NewInt(1)) == 0 {
+				// Тривиальное разложение n = n * 1
+				return result
+			}
+			result.P = p
+			result.Q = q
+			result.Success = true
+			return result
+		}
+
+		b2.Add(b2, new(big.Int).Lsh(a, 1))
+		b2.Add(b2, big.NewInt(1))
+		a.Add(a, big.NewInt(1))
+	}
+
+	result.Iterations = fas.maxIterations
+	return result
+}
@@ -1,3 +1,7 @@
-module main
+module lab_2
 
-go 1.25.1
+go 1.25.4
+
+require lab_4 v0.0.0
+
+replace lab_4 => ../lab_4
@@ -0,0 +1,162 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+type GMPublicKey struct {
+	N *big.Int
+	X *big.Int // псевдоквадрат: (x/n) = 1, но x - невычет по модулю n
+}
+
+type GMPrivateKey struct {
+	PublicKey *GMPublicKey
+	P         *big.Int
+	Q         *big.Int
+}
+
+// GoldwasserMicaliService вероятностное шифрование Гольдвассер-Микали: бит b
+// шифруется как y^2 * x^b mod n. Стойкость основана на трудности различения
+// вычетов и псевдоквадратов с символом Якоби 1; владелец p определяет бит
+// символом Лежандра. Шифрование гомоморфно относительно XOR.
+type GoldwasserMicaliService struct {
+	mathService  *MathService
+	keyGenerator *RSAKeyGenerator
+	publicKey    *GMPublicKey
+	privateKey   *GMPrivateKey
+}
+
+func NewGoldwasserMicaliService(testType PrimalityTestType, minProbability float64, bitLength int) *GoldwasserMicaliService {
+	ms := NewMathService()
+	return &GoldwasserMicaliService{
+		mathService:  ms,
+		keyGenerator: NewRSAKeyGenerator(testType, minProbability, bitLength/2, ms),
+	}
+}
+
+// GenerateKeys выбирает x, невычет по модулю p и по модулю q одновременно
+func (gs *GoldwasserMicaliService) GenerateKeys() error {
+	p, q, err := generateBlumPrimes(gs.keyGenerator)
+	if err != nil {
+		return err
+	}
+	n := new(big.Int).Mul(p, q)
+
+	for {
+		x, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return err
+		}
+		if gs.mathService.LegendreSymbol(new(big.Int).Mod(x, p), p) == -1 &&
+			gs.mathService.LegendreSymbol(new(big.Int).Mod(x, q), q) == -1 {
+			pub := &GMPublicKey{N: n, X: x}
+			gs.publicKey = pub
+			gs.privateKey = &GMPrivateKey{PublicKey: pub, P: p, Q: q}
+			return nil
+		}
+	}
+}
+
+func (gs *GoldwasserMicaliService) GetPublicKey() *GMPublicKey {
+	return gs.publicKey
+}
+
+// EncryptBit шифрует один бит случайным y из (Z/nZ)*
+func (gs *GoldwasserMicaliService) EncryptBit(bit uint) (*big.Int, error) {
+	if gs.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	if bit > 1 {
+		return nil, errors.New("bit must be 0 or 1")
+	}
+	pub := gs.publicKey
+
+	var y *big.Int
+	for {
+		var err error
+		y, err = rand.Int(rand.Reader, pub.N)
+		if err != nil {
+			return nil, err
+		}
+		if y.Sign() > 0 && gs.mathService.GCD(y, pub.N).Cmp(big.NewInt(1)) == 0 {
+			break
+		}
+	}
+
+	c := new(big.Int).Mul(y, y)
+	if bit == 1 {
+		c.Mul(c, pub.X)
+	}
+	return c.Mod(c, pub.N), nil
+}
+
+// DecryptBit возвращает 0, если c - квадратичный вычет по модулю p, иначе 1
+func (gs *GoldwasserMicaliService) DecryptBit(ciphertext *big.Int) (uint, error) {
+	if gs.privateKey == nil {
+		return 0, errors.New("keys not generated")
+	}
+	if err := gs.checkCiphertext(ciphertext); err != nil {
+		return 0, err
+	}
+	p := gs.privateKey.P
+	if gs.mathService.LegendreSymbol(new(big.Int).Mod(ciphertext, p), p) == 1 {
+		return 0, nil
+	}
+	return 1, nil
+}
+
+// Encrypt шифрует сообщение побитно, от старшего бита первого байта
+func (gs *GoldwasserMicaliService) Encrypt(message []byte) ([]*big.Int, error) {
+	ciphertext := make([]*big.Int, 0, len(message)*8)
+	for _, b := range message {
+		for i := 7; i >= 0; i-- {
+			c, err := gs.EncryptBit(uint(b>>i) & 1)
+			if err != nil {
+				return nil, err
+			}
+			ciphertext = append(ciphertext, c)
+		}
+	}
+	return ciphertext, nil
+}
+
+func (gs *GoldwasserMicaliService) Decrypt(ciphertext []*big.Int) ([]byte, error) {
+	if len(ciphertext)%8 != 0 {
+		return nil, errors.New("ciphertext length is not a multiple of 8 bits")
+	}
+	message := make([]byte, len(ciphertext)/8)
+	for i, c := range ciphertext {
+		bit, err := gs.DecryptBit(c)
+		if err != nil {
+			return nil, err
+		}
+		message[i/8] |= byte(bit) << (7 - i%8)
+	}
+	return message, nil
+}
+
+// Xor возвращает шифротекст b1 XOR b2: произведение двух шифротекстов
+func (gs *GoldwasserMicaliService) Xor(c1, c2 *big.Int) (*big.Int, error) {
+	if err := gs.checkCiphertext(c1); err != nil {
+		return nil, err
+	}
+	if err := gs.checkCiphertext(c2); err != nil {
+		return nil, err
+	}
+	c := new(big.Int).Mul(c1, c2)
+	return c.Mod(c, gs.publicKey.N), nil
+}
+
+// checkCiphertext открытая проверка: у любого шифротекста символ Якоби равен 1
+func (gs *GoldwasserMicaliService) checkCiphertext(c *big.Int) error {
+	if gs.publicKey == nil {
+		return errors.New("keys not generated")
+	}
+	n := gs.publicKey.N
+	if c.Sign() <= 0 || c.Cmp(n) >= 0 || gs.mathService.JacobiSymbol(c, n) != 1 {
+		return errors.New("invalid Goldwasser-Micali ciphertext")
+	}
+	return nil
+}
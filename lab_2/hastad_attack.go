@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+)
+
+type HastadAttackResult struct {
+	Message *big.Int
+	Success bool
+}
+
+// HastadAttackService широковещательная атака Хостада: одно и то же сообщение m,
+// зашифрованное без дополнения e получателям с одинаковой малой экспонентой e,
+// восстанавливается по КТО (m^e < N1*...*Ne) и извлечению целого корня степени e
+type HastadAttackService struct {
+	mathService *MathService
+}
+
+func NewHastadAttackService() *HastadAttackService {
+	return &HastadAttackService{
+		mathService: NewMathService(),
+	}
+}
+
+func (has *HastadAttackService) Attack(publicKeys []*RSAPublicKey, ciphertexts []*big.Int) (*HastadAttackResult, error) {
+	result := &HastadAttackResult{
+		Success: false,
+	}
+
+	if len(publicKeys) == 0 || len(publicKeys) != len(ciphertexts) {
+		return nil, errors.New("number of keys and ciphertexts must match")
+	}
+
+	e := publicKeys[0].E
+	if !e.IsInt64() || e.Int64() < 2 || e.Int64() > int64(len(publicKeys)) {
+		return nil, errors.New("need at least e ciphertexts for a small exponent e")
+	}
+	count := int(e.Int64())
+
+	moduli := make([]*big.Int, count)
+	product := big.NewInt(1)
+	for i := 0; i < count; i++ {
+		if publicKeys[i].E.Cmp(e) != 0 {
+			return nil, errors.New("all keys must share the public exponent")
+		}
+		moduli[i] = publicKeys[i].N
+		product.Mul(product, moduli[i])
+	}
+
+	// m^e < N1*...*Ne восстанавливается только при попарно взаимно простых модулях
+	c, lcm, err := has.mathService.CRT(ciphertexts[:count], moduli)
+	if err != nil {
+		return nil, err
+	}
+	if lcm.Cmp(product) != 0 {
+		return nil, errors.New("moduli are not pairwise coprime")
+	}
+
+	m, exact := integerRoot(c, count)
+	if !exact {
+		return result, nil
+	}
+
+	result.Message = m
+	result.Success = true
+	return result, nil
+}
+
+// integerRoot вычисляет floor(a^(1/k)) методом Ньютона и сообщает, является ли
+// a точной k-й степенью
+func integerRoot(a *big.Int, k int) (*big.Int, bool) {
+	if a.Sign() == 0 || k == 1 {
+		return new(big.Int).Set(a), true
+	}
+
+	kBig := big.NewInt(int64(k))
+	kMinus1 := big.NewInt(int64(k - 1))
+
+	// Начальное приближение 2^ceil(bits/k) заведомо не меньше корня
+	x := new(big.Int).Lsh(big.NewInt(1), uint((a.BitLen()+k-1)/k))
+	for {
+		// y = ((k-1)x + a / x^(k-1)) / k
+		y := new(big.Int).Exp(x, kMinus1, nil)
+		y.Div(a, y)
+		y.Add(y, new(big.Int).Mul(kMinus1, x))
+		y.Div(y, kBig)
+		if y.Cmp(x) >= 0 {
+			break
+		}
+		x = y
+	}
+
+	return x, new(big.Int).Exp(x, kBig, nil).Cmp(a) == 0
+}
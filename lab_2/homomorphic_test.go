@@ -0,0 +1,234 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+func testPaillierService(t *testing.T) *PaillierService {
+	t.Helper()
+	ps := NewPaillierService(TestMillerRabin, 0.9999, 512)
+	if err := ps.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return ps
+}
+
+var (
+	elGamalParamsOnce sync.Once
+	elGamalParams     *ElGamalParameters
+	elGamalParamsErr  error
+)
+
+// testElGamalService возвращает сервис с новым ключом; безопасное простое
+// p = 2q + 1 (256 бит) ищется один раз на весь запуск тестов
+func testElGamalService(t *testing.T) *ElGamalService {
+	t.Helper()
+	es := NewElGamalService(TestMillerRabin, 0.9999)
+	elGamalParamsOnce.Do(func() {
+		if elGamalParamsErr = es.GenerateParameters(256); elGamalParamsErr == nil {
+			elGamalParams = es.GetParameters()
+		}
+	})
+	if elGamalParamsErr != nil {
+		t.Fatal(elGamalParamsErr)
+	}
+	if err := es.SetParameters(elGamalParams); err != nil {
+		t.Fatal(err)
+	}
+	if err := es.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	return es
+}
+
+func mustPaillierEncrypt(t *testing.T, ps *PaillierService, m *big.Int) *big.Int {
+	t.Helper()
+	c, err := ps.Encrypt(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func requirePaillierPlaintext(t *testing.T, ps *PaillierService, c, want *big.Int, what string) {
+	t.Helper()
+	got, err := ps.Decrypt(c)
+	if err != nil {
+		t.Fatalf("%s: %v", what, err)
+	}
+	if got.Cmp(want) != 0 {
+		t.Errorf("%s = %s, want %s", what, got, want)
+	}
+}
+
+func TestPaillierAddition(t *testing.T) {
+	ps := testPaillierService(t)
+	n := ps.GetPublicKey().N
+
+	for i := 0; i < 10; i++ {
+		a, _ := rand.Int(rand.Reader, n)
+		b, _ := rand.Int(rand.Reader, n)
+		sum, err := ps.Add(mustPaillierEncrypt(t, ps, a), mustPaillierEncrypt(t, ps, b))
+		if err != nil {
+			t.Fatal(err)
+		}
+		want := new(big.Int).Add(a, b)
+		requirePaillierPlaintext(t, ps, sum, want.Mod(want, n), "D(E(a) * E(b))")
+	}
+
+	// (n - 1) + 2 = 1 (mod n)
+	nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+	sum, err := ps.Add(mustPaillierEncrypt(t, ps, nMinus1), mustPaillierEncrypt(t, ps, big.NewInt(2)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirePaillierPlaintext(t, ps, sum, big.NewInt(1), "wraparound sum")
+}
+
+func TestPaillierPlaintextOperations(t *testing.T) {
+	ps := testPaillierService(t)
+	n := ps.GetPublicKey().N
+	c := mustPaillierEncrypt(t, ps, big.NewInt(100))
+
+	added, err := ps.AddPlain(c, big.NewInt(-30))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirePaillierPlaintext(t, ps, added, big.NewInt(70), "100 + (-30)")
+
+	multiplied, err := ps.MulPlain(c, big.NewInt(7))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirePaillierPlaintext(t, ps, multiplied, big.NewInt(700), "100 * 7")
+
+	negated, err := ps.MulPlain(c, big.NewInt(-1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requirePaillierPlaintext(t, ps, negated, new(big.Int).Sub(n, big.NewInt(100)), "100 * (-1)")
+}
+
+func TestPaillierRerandomize(t *testing.T) {
+	ps := testPaillierService(t)
+	c := mustPaillierEncrypt(t, ps, big.NewInt(42))
+
+	fresh, err := ps.Rerandomize(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fresh.Cmp(c) == 0 {
+		t.Error("rerandomized ciphertext equals the original")
+	}
+	requirePaillierPlaintext(t, ps, fresh, big.NewInt(42), "rerandomized")
+
+	if _, err := ps.Decrypt(ps.GetPublicKey().N); err == nil {
+		t.Error("decrypted a ciphertext sharing a factor with n")
+	}
+	if _, err := ps.Encrypt(ps.GetPublicKey().N); err == nil {
+		t.Error("encrypted a message outside [0, n)")
+	}
+}
+
+func TestElGamalMultiplicativeHomomorphism(t *testing.T) {
+	es := testElGamalService(t)
+
+	m1, m2 := big.NewInt(123456789), big.NewInt(987654321)
+	c1, err := es.Encrypt(m1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c2, err := es.Encrypt(m2)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	product, err := es.Multiply(c1, c2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := es.Decrypt(product)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(big.Int).Mul(m1, m2); got.Cmp(want) != 0 {
+		t.Errorf("D(E(m1) * E(m2)) = %s, want %s", got, want)
+	}
+
+	for _, m := range []*big.Int{big.NewInt(0), new(big.Int).Add(es.GetParameters().Q, big.NewInt(1))} {
+		if _, err := es.Encrypt(m); err == nil {
+			t.Errorf("encrypted %s outside [1, q]", m)
+		}
+	}
+}
+
+func TestElGamalExponentHomomorphism(t *testing.T) {
+	es := testElGamalService(t)
+	bound := big.NewInt(1 << 20)
+
+	a, b := big.NewInt(31337), big.NewInt(4242)
+	ca, err := es.EncryptExponent(a)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cb, err := es.EncryptExponent(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sum, err := es.Multiply(ca, cb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := es.DecryptExponent(sum, bound)
+	if err != nil || got.Cmp(big.NewInt(31337+4242)) != 0 {
+		t.Errorf("exponent sum = %v, %v, want %d", got, err, 31337+4242)
+	}
+
+	scaled, err := es.Power(cb, big.NewInt(3))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = es.DecryptExponent(scaled, bound)
+	if err != nil || got.Cmp(big.NewInt(3*4242)) != 0 {
+		t.Errorf("exponent 3 * b = %v, %v, want %d", got, err, 3*4242)
+	}
+
+	if _, err := es.Multiply(ca, &ElGamalCiphertext{C1: big.NewInt(1), C2: big.NewInt(0)}); err == nil {
+		t.Error("Multiply accepted a ciphertext outside the subgroup")
+	}
+}
+
+func TestElGamalSignature(t *testing.T) {
+	es := testElGamalService(t)
+	digest := hashOf(crypto.SHA256, []byte("ElGamal"))
+
+	signature, err := es.Sign(crypto.SHA256, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := es.Verify(crypto.SHA256, digest, signature); err != nil {
+		t.Fatalf("valid signature rejected: %v", err)
+	}
+
+	other := hashOf(crypto.SHA256, []byte("elgamal"))
+	if err := es.Verify(crypto.SHA256, other, signature); err == nil {
+		t.Error("signature verified for another digest")
+	}
+	q := es.GetParameters().Q
+	tampered := []*ElGamalSignature{
+		{R: signature.R, S: new(big.Int).Mod(new(big.Int).Add(signature.S, big.NewInt(1)), q)},
+		{R: new(big.Int).Add(signature.R, big.NewInt(1)), S: signature.S},
+		{R: signature.R, S: q},
+		nil,
+	}
+	for i, sig := range tampered {
+		if err := es.Verify(crypto.SHA256, digest, sig); err == nil {
+			t.Errorf("tampered signature %d verified", i)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"lab_4/crypto"
+)
+
+// Формат контейнера гибридного шифрования:
+//
+//	magic (8 байт) || длина обернутого ключа (2 байта, big-endian) ||
+//	обернутый ключ (RSA-OAEP SHA-256) || nonce || шифротекст AES-256-GCM || тег
+var hybridMagic = []byte("RSAHYB01")
+
+// hybridKeyLabel метка OAEP, привязывающая обернутый ключ к формату контейнера
+var hybridKeyLabel = []byte("lab_2 hybrid AES-256-GCM")
+
+const hybridKeySize = 32
+
+// HybridService гибридное шифрование: данные шифруются AES-256-GCM (lab_4/crypto)
+// на случайном ключе, а ключ оборачивается RSA-OAEP открытым ключом получателя
+type HybridService struct {
+	rsaService *RSAService
+}
+
+func NewHybridService(rsaService *RSAService) *HybridService {
+	return &HybridService{rsaService: rsaService}
+}
+
+// Encrypt шифрует данные для владельца открытого ключа сервиса RSA
+func (hs *HybridService) Encrypt(plaintext []byte) ([]byte, error) {
+	key := make([]byte, hybridKeySize)
+	if _, err := io.ReadFull(rand.Reader, key); err != nil {
+		return nil, fmt.Errorf("generate symmetric key: %w", err)
+	}
+	defer zeroBytes(key)
+
+	wrappedKey, err := hs.rsaService.EncryptOAEP(sha256.New(), key, hybridKeyLabel)
+	if err != nil {
+		return nil, fmt.Errorf("wrap symmetric key: %w", err)
+	}
+
+	payload, err := crypto.EncryptAES(plaintext, key)
+	if err != nil {
+		return nil, fmt.Errorf("encrypt payload: %w", err)
+	}
+
+	var buf bytes.Buffer
+	buf.Write(hybridMagic)
+	binary.Write(&buf, binary.BigEndian, uint16(len(wrappedKey)))
+	buf.Write(wrappedKey)
+	buf.Write(payload)
+	return buf.Bytes(), nil
+}
+
+// Decrypt расшифровывает контейнер закрытым ключом сервиса RSA
+func (hs *HybridService) Decrypt(container []byte) ([]byte, error) {
+	if len(container) < len(hybridMagic)+2 || !bytes.Equal(container[:len(hybridMagic)], hybridMagic) {
+		return nil, errors.New("not a hybrid container")
+	}
+
+	rest := container[len(hybridMagic):]
+	keyLen := int(binary.BigEndian.Uint16(rest))
+	rest = rest[2:]
+	if len(rest) < keyLen {
+		return nil, errors.New("truncated hybrid container")
+	}
+
+	key, err := hs.rsaService.DecryptOAEP(sha256.New(), rest[:keyLen], hybridKeyLabel)
+	if err != nil {
+		return nil, fmt.Errorf("unwrap symmetric key: %w", err)
+	}
+	defer zeroBytes(key)
+	if len(key) != hybridKeySize {
+		return nil, errors.New("unexpected symmetric key size")
+	}
+
+	plaintext, err := crypto.DecryptAES(rest[keyLen:], key)
+	if err != nil {
+		return nil, fmt.Errorf("decrypt payload: %w", err)
+	}
+	return plaintext, nil
+}
+
+// EncryptFile шифрует файл inputPath в контейнер outputPath
+func (hs *HybridService) EncryptFile(inputPath, outputPath string) error {
+	data, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("read input file: %w", err)
+	}
+
+	container, err := hs.Encrypt(data)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, container, 0644)
+}
+
+// DecryptFile расшифровывает контейнер inputPath в файл outputPath
+func (hs *HybridService) DecryptFile(inputPath, outputPath string) error {
+	container, err := os.ReadFile(inputPath)
+	if err != nil {
+		return fmt.Errorf("read input file: %w", err)
+	}
+
+	data, err := hs.Decrypt(container)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(outputPath, data, 0600)
+}
+
+// zeroBytes затирает содержимое среза нулями
+func zeroBytes(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
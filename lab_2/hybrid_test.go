@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHybridRoundTrip(t *testing.T) {
+	rs, _ := testRSAService(t)
+	hs := NewHybridService(rs)
+
+	for _, length := range []int{0, 1, 31, 4096, 1 << 20} {
+		message := make([]byte, length)
+		rand.Read(message)
+
+		container, err := hs.Encrypt(message)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", length, err)
+		}
+		plain, err := hs.Decrypt(container)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", length, err)
+		}
+		if !bytes.Equal(plain, message) {
+			t.Errorf("%d bytes: round trip mismatch", length)
+		}
+	}
+
+	// Новый симметричный ключ и nonce в каждом контейнере
+	a, _ := hs.Encrypt([]byte("same message"))
+	b, _ := hs.Encrypt([]byte("same message"))
+	if bytes.Equal(a, b) {
+		t.Error("two encryptions of the same message are identical")
+	}
+}
+
+func TestHybridRejectsTamperedContainer(t *testing.T) {
+	rs, _ := testRSAService(t)
+	hs := NewHybridService(rs)
+	container, err := hs.Encrypt([]byte("гибридное шифрование RSA-OAEP + AES-256-GCM"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	k := rs.GetPublicKey().modulusLen()
+	keyStart := len(hybridMagic) + 2
+	payloadStart := keyStart + k
+	flip := func(i int) []byte {
+		c := append([]byte(nil), container...)
+		c[i] ^= 1
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		container []byte
+	}{
+		{"magic", flip(0)},
+		{"key length", flip(keyStart - 1)},
+		{"wrapped key", flip(keyStart + k/2)},
+		{"nonce", flip(payloadStart)},
+		{"ciphertext", flip(payloadStart + 20)},
+		{"tag", flip(len(container) - 1)},
+		{"truncated tag", container[:len(container)-1]},
+		{"truncated wrapped key", container[:payloadStart-1]},
+		{"appended byte", append(append([]byte(nil), container...), 0)},
+		{"header only", container[:keyStart]},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		if _, err := hs.Decrypt(tt.container); err == nil {
+			t.Errorf("%s: tampered container decrypted", tt.name)
+		}
+	}
+
+	// Контейнер для другого получателя
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other := NewRSAService(TestMillerRabin, 0.9999, 1024)
+	priv, err := RSAPrivateKeyFromStdlib(otherKey, other.mathService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SetKeys(priv.PublicKey, priv); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewHybridService(other).Decrypt(container); err == nil {
+		t.Error("container decrypted with another private key")
+	}
+}
+
+func TestHybridFiles(t *testing.T) {
+	rs, _ := testRSAService(t)
+	hs := NewHybridService(rs)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.bin")
+	data := make([]byte, 100_000)
+	rand.Read(data)
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := hs.EncryptFile(input, input+".hyb"); err != nil {
+		t.Fatal(err)
+	}
+	if err := hs.DecryptFile(input+".hyb", input+".out"); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := os.ReadFile(input + ".out")
+	if err != nil || !bytes.Equal(restored, data) {
+		t.Fatalf("restored file differs (%v)", err)
+	}
+	if err := hs.DecryptFile(filepath.Join(dir, "missing.hyb"), input+".out"); err == nil {
+		t.Error("missing input file decrypted")
+	}
+}
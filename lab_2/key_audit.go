@@ -0,0 +1,366 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	auditMinModulusBits  = 2048 // рекомендуемая длина модуля (NIST SP 800-57)
+	auditWeakModulusBits = 1024 // меньшие модули факторизуются на практике
+	auditMinExponentBits = 17   // e >= 65537
+	auditDistanceMargin  = 100  // |p - q| > 2^(nlen/2 - 100) (FIPS 186-4, B.3.1)
+)
+
+// rocaPrimes простые, по которым отпечаток ROCA (CVE-2017-15361) отличим от
+// случайного: порядок 65537 по этим модулям меньше r - 1, а модули ключей
+// RSALib имеют вид n = 65537^c mod M
+var rocaPrimes = []int64{11, 13, 17, 19, 37, 53, 61, 71, 73, 79, 97, 103, 107, 109, 127, 151, 157}
+
+// AuditSeverity серьезность найденной проблемы
+type AuditSeverity int
+
+const (
+	SeverityOK       AuditSeverity = iota // проверка пройдена
+	SeverityInfo                          // замечание, не влияющее на стойкость
+	SeverityWarning                       // ослабление стойкости или нарушение рекомендаций
+	SeverityCritical                      // ключ небезопасен или некорректен
+)
+
+func (s AuditSeverity) String() string {
+	switch s {
+	case SeverityOK:
+		return "OK"
+	case SeverityInfo:
+		return "замечание"
+	case SeverityWarning:
+		return "предупреждение"
+	default:
+		return "критично"
+	}
+}
+
+// MarshalText кодирует серьезность строкой в JSON-отчете
+func (s AuditSeverity) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+// AuditFinding результат одной проверки ключа
+type AuditFinding struct {
+	Check    string        `json:"check"`
+	Severity AuditSeverity `json:"severity"`
+	Message  string        `json:"message"`
+}
+
+// KeyAuditReport структурированный отчет аудита ключа
+type KeyAuditReport struct {
+	ModulusBits   int            `json:"modulus_bits"`
+	Exponent      *big.Int       `json:"e"`
+	PrimeCount    int            `json:"prime_count,omitempty"`
+	HasPrivateKey bool           `json:"has_private_key"`
+	Findings      []AuditFinding `json:"findings"`
+	RecoveredD    *big.Int       `json:"recovered_d,omitempty"` // d, восстановленный атакой Винера
+	Duration      time.Duration  `json:"duration_ns"`
+	Secure        bool           `json:"secure"` // нет предупреждений и критичных проблем
+}
+
+// Worst возвращает наибольшую серьезность среди проверок
+func (r *KeyAuditReport) Worst() AuditSeverity {
+	worst := SeverityOK
+	for _, f := range r.Findings {
+		worst = max(worst, f.Severity)
+	}
+	return worst
+}
+
+func (r *KeyAuditReport) String() string {
+	var b strings.Builder
+	kind := "открытый ключ"
+	if r.HasPrivateKey {
+		kind = fmt.Sprintf("закрытый ключ, простых: %d", r.PrimeCount)
+	}
+	fmt.Fprintf(&b, "Аудит ключа RSA (%d бит, e = %s, %s)\n", r.ModulusBits, r.Exponent, kind)
+	for _, f := range r.Findings {
+		fmt.Fprintf(&b, "  [%s] %s: %s\n", f.Severity, f.Check, f.Message)
+	}
+	fmt.Fprintf(&b, "Итог: %s (%v)\n", r.Worst(), r.Duration.Round(time.Millisecond))
+	return b.String()
+}
+
+func (r *KeyAuditReport) add(check string, severity AuditSeverity, format string, args ...any) {
+	r.Findings = append(r.Findings, AuditFinding{Check: check, Severity: severity, Message: fmt.Sprintf(format, args...)})
+}
+
+type namedPrimalityTest struct {
+	name string
+	test PrimalityTest
+}
+
+// KeyAuditService проверяет импортированные ключи RSA на ошибки и известные
+// слабости
+type KeyAuditService struct {
+	mathService    *MathService
+	tests          []namedPrimalityTest
+	minProbability float64
+	unpadded       bool
+}
+
+// NewKeyAuditService создает аудитор; простота множителей проверяется всеми
+// реализациями PrimalityTest с вероятностью не ниже minProbability
+func NewKeyAuditService(minProbability float64) *KeyAuditService {
+	ms := NewMathService()
+	tests := []namedPrimalityTest{
+		{"Ферма", NewFermatTest(ms)},
+		{"Соловея-Штрассена", NewSolovayStrassenTest(ms)},
+		{"Миллера-Рабина", NewMillerRabinTest(ms)},
+		{"Люка", NewStrongLucasTest(ms)},
+		{"BPSW", NewBPSWTest(ms)},
+		{"детерм. М-Р", NewDeterministicMillerRabinTest(ms)},
+	}
+	return &KeyAuditService{
+		mathService:    ms,
+		tests:          tests,
+		minProbability: minProbability,
+	}
+}
+
+// SetUnpadded сообщает аудитору, что ключ используется без дополнения
+// (учебный RSA): тогда малая e критична
+func (kas *KeyAuditService) SetUnpadded(unpadded bool) {
+	kas.unpadded = unpadded
+}
+
+// Audit проверяет открытый ключ и, если он передан, закрытый ключ
+func (kas *KeyAuditService) Audit(pub *RSAPublicKey, priv *RSAPrivateKey) *KeyAuditReport {
+	start := time.Now()
+	report := &KeyAuditReport{
+		ModulusBits:   pub.N.BitLen(),
+		Exponent:      new(big.Int).Set(pub.E),
+		HasPrivateKey: priv != nil,
+	}
+
+	kas.checkModulus(report, pub)
+	kas.checkExponent(report, pub)
+	kas.checkROCA(report, pub)
+	kas.checkWienerAttack(report, pub)
+
+	if priv != nil {
+		kas.checkPrivateKey(report, pub, priv)
+	}
+
+	report.Duration = time.Since(start)
+	report.Secure = report.Worst() <= SeverityInfo
+	return report
+}
+
+func (kas *KeyAuditService) checkModulus(report *KeyAuditReport, pub *RSAPublicKey) {
+	bits := pub.N.BitLen()
+	switch {
+	case pub.N.Bit(0) == 0:
+		report.add("modulus", SeverityCritical, "модуль четный")
+	case bits < auditWeakModulusBits:
+		report.add("modulus", SeverityCritical, "%d бит: модуль факторизуем", bits)
+	case bits < auditMinModulusBits:
+		report.add("modulus", SeverityWarning, "%d бит: меньше рекомендуемых %d", bits, auditMinModulusBits)
+	default:
+		report.add("modulus", SeverityOK, "%d бит", bits)
+	}
+}
+
+func (kas *KeyAuditService) checkExponent(report *KeyAuditReport, pub *RSAPublicKey) {
+	if err := ValidatePublicExponent(pub.E); err != nil {
+		severity := SeverityCritical
+		if pub.E.Bit(0) == 1 && pub.E.BitLen() > maxPublicExponentBits {
+			// Большая e не ослабляет ключ сама по себе, но не соответствует
+			// FIPS 186-4 и типична для ключей с малой d
+			severity = SeverityWarning
+		}
+		report.add("public-exponent", severity, "%v", err)
+		return
+	}
+	if pub.E.BitLen() >= auditMinExponentBits {
+		report.add("public-exponent", SeverityOK, "e = %s", pub.E)
+		return
+	}
+	if kas.unpadded {
+		// Без дополнения m^e < n для коротких m, а одно сообщение нескольким
+		// получателям раскрывается атакой Хостада
+		report.add("public-exponent", SeverityCritical,
+			"e = %s без дополнения: корень степени e и атака Хостада", pub.E)
+		return
+	}
+	report.add("public-exponent", SeverityWarning, "e = %s меньше 65537", pub.E)
+}
+
+// checkROCA ищет отпечаток уязвимой генерации RSALib: n mod r лежит в
+// подгруппе, порожденной 65537, для всех простых r из rocaPrimes. У случайного
+// ключа это выполняется с вероятностью порядка 2^-30.
+func (kas *KeyAuditService) checkROCA(report *KeyAuditReport, pub *RSAPublicKey) {
+	residue := new(big.Int)
+	for _, r := range rocaPrimes {
+		x := residue.Mod(pub.N, big.NewInt(r)).Int64()
+		if !inSubgroup(65537%r, x, r) {
+			report.add("roca", SeverityOK, "отпечаток ROCA не найден")
+			return
+		}
+	}
+	report.add("roca", SeverityCritical, "модуль имеет отпечаток ROCA (CVE-2017-15361): ключ факторизуем методом Копперсмита")
+}
+
+// inSubgroup сообщает, что x лежит в циклической подгруппе g по модулю r
+func inSubgroup(g, x, r int64) bool {
+	y := int64(1)
+	for {
+		if y == x {
+			return true
+		}
+		y = y * g % r
+		if y == 1 {
+			return false
+		}
+	}
+}
+
+func (kas *KeyAuditService) checkWienerAttack(report *KeyAuditReport, pub *RSAPublicKey) {
+	result := NewWienerAttackService().Attack(pub)
+	if result.Success {
+		report.RecoveredD = result.D
+		report.add("wiener-attack", SeverityCritical, "атака Винера восстановила d (%d бит)", result.D.BitLen())
+		return
+	}
+	report.add("wiener-attack", SeverityOK, "атака Винера не удалась (%d подходящих дробей)", len(result.ContinuedFractions))
+}
+
+func (kas *KeyAuditService) checkPrivateKey(report *KeyAuditReport, pub *RSAPublicKey, priv *RSAPrivateKey) {
+	if priv.P == nil || priv.Q == nil || priv.D == nil {
+		report.add("private-key", SeverityCritical, "нет простых множителей или d")
+		return
+	}
+	primes := priv.Primes()
+	report.PrimeCount = len(primes)
+
+	if primesProduct(priv).Cmp(pub.N) != 0 {
+		report.add("factors", SeverityCritical, "n не равно произведению простых")
+		return
+	}
+	report.add("factors", SeverityOK, "n = произведение %d простых", len(primes))
+
+	allPrime := true
+	for i, r := range primes {
+		if !kas.checkPrime(report, i+1, r) {
+			allPrime = false
+		}
+	}
+	kas.checkPrimeDistance(report, pub, primes)
+
+	if allPrime {
+		kas.checkLambda(report, pub, priv, primes)
+	}
+	kas.checkPrivateExponent(report, pub, priv)
+	kas.checkCRT(report, priv)
+}
+
+// checkPrime проверяет простоту множителя всеми тестами; составное число
+// достаточно отвергнуть одним тестом, так как ни один из них не ошибается
+// на простых
+func (kas *KeyAuditService) checkPrime(report *KeyAuditReport, index int, r *big.Int) bool {
+	check := fmt.Sprintf("prime-%d", index)
+	bound := 1.0
+	for _, t := range kas.tests {
+		result, err := t.test.Test(r, kas.minProbability)
+		if err != nil {
+			report.add(check, SeverityWarning, "тест %s: %v", t.name, err)
+			return false
+		}
+		if !result.Probable {
+			report.add(check, SeverityCritical, "r%d (%d бит) составное по тесту %s", index, r.BitLen(), t.name)
+			return false
+		}
+		if result.Guarantee == GuaranteeExact {
+			bound = 0
+		} else if result.Guarantee == GuaranteeProbabilistic {
+			bound = min(bound, result.ErrorBound)
+		}
+	}
+	report.add(check, SeverityOK, "r%d (%d бит) простое по %d тестам, оценка ошибки %.2g", index, r.BitLen(), len(kas.tests), bound)
+	return true
+}
+
+func (kas *KeyAuditService) checkPrimeDistance(report *KeyAuditReport, pub *RSAPublicKey, primes []*big.Int) {
+	bits := max(0, pub.N.BitLen()/len(primes)-auditDistanceMargin)
+	minDiff := new(big.Int).Lsh(big.NewInt(1), uint(bits))
+	diff := new(big.Int)
+	for i := range primes {
+		for j := i + 1; j < len(primes); j++ {
+			diff.Sub(primes[i], primes[j]).Abs(diff)
+			if diff.Cmp(minDiff) <= 0 {
+				report.add("prime-distance", SeverityCritical,
+					"|r%d - r%d| ≈ 2^%d не больше 2^%d: факторизация Ферма", i+1, j+1, diff.BitLen(), bits)
+				return
+			}
+		}
+	}
+	report.add("prime-distance", SeverityOK, "все |r_i - r_j| > 2^%d", bits)
+}
+
+// checkLambda проверяет e*d = 1 (mod lambda(n))
+func (kas *KeyAuditService) checkLambda(report *KeyAuditReport, pub *RSAPublicKey, priv *RSAPrivateKey, primes []*big.Int) {
+	factors := make([]PrimePower, len(primes))
+	for i, r := range primes {
+		factors[i] = PrimePower{Prime: r, Exponent: 1}
+	}
+	lambda, err := kas.mathService.CarmichaelLambda(factors)
+	if err != nil {
+		report.add("exponent-inverse", SeverityCritical, "%v", err)
+		return
+	}
+
+	ed := new(big.Int).Mul(pub.E, priv.D)
+	if ed.Mod(ed, lambda).Cmp(big.NewInt(1)) != 0 {
+		report.add("exponent-inverse", SeverityCritical, "e*d != 1 (mod lambda(n)): расшифрование неверно")
+		return
+	}
+	report.add("exponent-inverse", SeverityOK, "e*d = 1 (mod lambda(n))")
+}
+
+func (kas *KeyAuditService) checkPrivateExponent(report *KeyAuditReport, pub *RSAPublicKey, priv *RSAPrivateKey) {
+	ratio := log2Big(priv.D) / log2Big(pub.N)
+	switch {
+	case ratio <= 0.25:
+		report.add("private-exponent", SeverityCritical, "d ≈ n^%.3f ниже границы Винера n^0.25", ratio)
+	case BelowBonehDurfeeBound(pub.N, priv.D):
+		report.add("private-exponent", SeverityWarning, "d ≈ n^%.3f ниже границы Боне-Дерфи n^%.3f", ratio, BonehDurfeeExponent)
+	default:
+		report.add("private-exponent", SeverityOK, "d ≈ n^%.3f", ratio)
+	}
+}
+
+// checkCRT сверяет сохраненные параметры КТО с p, q и d: искаженные значения
+// дают неверные подписи, раскрывающие множитель (атака Bellcore)
+func (kas *KeyAuditService) checkCRT(report *KeyAuditReport, priv *RSAPrivateKey) {
+	if priv.Dp == nil || priv.Dq == nil || priv.Qinv == nil {
+		report.add("crt", SeverityInfo, "параметры КТО отсутствуют")
+		return
+	}
+	expected := &RSAPrivateKey{PublicKey: priv.PublicKey, D: priv.D, P: priv.P, Q: priv.Q}
+	for _, r := range priv.AdditionalPrimes {
+		expected.AdditionalPrimes = append(expected.AdditionalPrimes, CRTPrime{Prime: r.Prime})
+	}
+	if err := expected.Precompute(kas.mathService); err != nil {
+		report.add("crt", SeverityCritical, "%v", err)
+		return
+	}
+
+	match := expected.Dp.Cmp(priv.Dp) == 0 && expected.Dq.Cmp(priv.Dq) == 0 && expected.Qinv.Cmp(priv.Qinv) == 0
+	for i, r := range priv.AdditionalPrimes {
+		e := expected.AdditionalPrimes[i]
+		match = match && r.Exp != nil && r.Coeff != nil && e.Exp.Cmp(r.Exp) == 0 && e.Coeff.Cmp(r.Coeff) == 0
+	}
+	if !match {
+		report.add("crt", SeverityCritical, "параметры КТО не соответствуют p, q и d")
+		return
+	}
+	report.add("crt", SeverityOK, "параметры КТО согласованы")
+}
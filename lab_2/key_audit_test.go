@@ -0,0 +1,102 @@
+package main
+
+import (
+	"crypto/rand"
+	"math/big"
+	"testing"
+)
+
+// requireFinding проверяет, что в отчете есть проверка check с серьезностью want
+func requireFinding(t *testing.T, report *KeyAuditReport, check string, want AuditSeverity) {
+	t.Helper()
+	for _, f := range report.Findings {
+		if f.Check == check {
+			if f.Severity != want {
+				t.Errorf("%s: %s (%s), want %s", check, f.Severity, f.Message, want)
+			}
+			return
+		}
+	}
+	t.Errorf("no %s finding in report:\n%s", check, report)
+}
+
+func TestKeyAuditAcceptsGeneratedKey(t *testing.T) {
+	rs, _ := testRSAService(t)
+	report := NewKeyAuditService(0.999999).Audit(rs.GetPublicKey(), rs.GetPrivateKey())
+	if !report.Secure || report.Worst() > SeverityInfo {
+		t.Fatalf("generated key reported insecure:\n%s", report)
+	}
+	for _, check := range []string{"modulus", "public-exponent", "roca", "wiener-attack",
+		"factors", "prime-1", "prime-2", "prime-distance", "exponent-inverse", "private-exponent", "crt"} {
+		requireFinding(t, report, check, SeverityOK)
+	}
+}
+
+func TestKeyAuditFindsDefects(t *testing.T) {
+	auditor := NewKeyAuditService(0.999999)
+
+	t.Run("Wiener d", func(t *testing.T) {
+		pub, d := weakRSAKey(1024, 200)
+		report := auditor.Audit(pub, nil)
+		requireFinding(t, report, "wiener-attack", SeverityCritical)
+		if report.RecoveredD == nil || report.RecoveredD.Cmp(d) != 0 {
+			t.Errorf("recovered d = %v", report.RecoveredD)
+		}
+		if report.Secure {
+			t.Error("key reported secure")
+		}
+	})
+
+	t.Run("ROCA", func(t *testing.T) {
+		n := new(big.Int).Mul(rocaLikePrime(512), rocaLikePrime(512))
+		report := auditor.Audit(&RSAPublicKey{N: n, E: big.NewInt(65537)}, nil)
+		requireFinding(t, report, "roca", SeverityCritical)
+		requireFinding(t, report, "wiener-attack", SeverityOK)
+	})
+
+	t.Run("e = 3 unpadded", func(t *testing.T) {
+		rs, _ := testRSAService(t)
+		e3 := &RSAPublicKey{N: rs.GetPublicKey().N, E: big.NewInt(3)}
+
+		unpadded := NewKeyAuditService(0.999999)
+		unpadded.SetUnpadded(true)
+		requireFinding(t, unpadded.Audit(e3, nil), "public-exponent", SeverityCritical)
+		// С дополнением малая e лишь нарушает рекомендации
+		requireFinding(t, auditor.Audit(e3, nil), "public-exponent", SeverityWarning)
+	})
+
+	t.Run("corrupted dP", func(t *testing.T) {
+		rs, _ := testRSAService(t)
+		priv := *rs.GetPrivateKey()
+		priv.Dp = new(big.Int).Add(priv.Dp, big.NewInt(2))
+		report := auditor.Audit(priv.PublicKey, &priv)
+		requireFinding(t, report, "crt", SeverityCritical)
+		requireFinding(t, report, "exponent-inverse", SeverityOK)
+		if report.Secure {
+			t.Error("key reported secure")
+		}
+	})
+
+	t.Run("composite p", func(t *testing.T) {
+		r, _ := rand.Prime(rand.Reader, 256)
+		s, _ := rand.Prime(rand.Reader, 256)
+		q, _ := rand.Prime(rand.Reader, 512)
+		p := new(big.Int).Mul(r, s)
+		bad := &RSAPrivateKey{
+			PublicKey: &RSAPublicKey{N: new(big.Int).Mul(p, q), E: big.NewInt(65537)},
+			D:         big.NewInt(12345),
+			P:         p,
+			Q:         q,
+		}
+		report := auditor.Audit(bad.PublicKey, bad)
+		requireFinding(t, report, "factors", SeverityOK)
+		requireFinding(t, report, "prime-1", SeverityCritical)
+		requireFinding(t, report, "prime-2", SeverityOK)
+		requireFinding(t, report, "private-exponent", SeverityCritical)
+		for _, f := range report.Findings {
+			if f.Check == "exponent-inverse" {
+				t.Error("lambda(n) checked for a composite factor")
+			}
+		}
+	})
+}
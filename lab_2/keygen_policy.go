@@ -0,0 +1,143 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+const (
+	maxPrimeCount         = 16  // верхняя граница числа простых в многопростом RSA
+	minMultiPrimeBits     = 64  // минимальный размер каждого простого при k > 2
+	maxPublicExponentBits = 256 // e < 2^256 (NIST SP 800-56B)
+)
+
+// PolicyRule правило политики генерации ключей, из-за которого кандидат отброшен
+type PolicyRule int
+
+const (
+	RuleExponentCoprime PolicyRule = iota // НОД(e, r - 1) != 1 для простого r
+	RulePrimeDistance                     // |r_i - r_j| меньше допустимого (защита от атаки Ферма)
+	RuleModulusSize                       // произведение простых короче заданной длины модуля
+	RuleWienerBound                       // d слишком мал (атака Винера)
+	RuleBonehDurfee                       // d < n^0.292 (атака Боне-Дерфи)
+	RuleCRT                               // не удалось вычислить параметры КТО
+)
+
+func (r PolicyRule) String() string {
+	switch r {
+	case RuleExponentCoprime:
+		return "e не взаимно просто с r-1"
+	case RulePrimeDistance:
+		return "простые слишком близки"
+	case RuleModulusSize:
+		return "неверная длина модуля"
+	case RuleWienerBound:
+		return "граница Винера"
+	case RuleBonehDurfee:
+		return "граница Боне-Дерфи"
+	case RuleCRT:
+		return "параметры КТО"
+	default:
+		return "неизвестное правило"
+	}
+}
+
+// KeyGenRetry запись о повторе генерации: номер попытки построить ключ и
+// нарушенное правило
+type KeyGenRetry struct {
+	Attempt int
+	Rule    PolicyRule
+	Detail  string
+}
+
+// KeyGenPolicy набор правил генерации ключа RSA
+type KeyGenPolicy struct {
+	ModulusBits          int      // длина модуля n в битах
+	PrimeCount           int      // число простых множителей (RFC 8017 допускает k > 2)
+	PublicExponent       *big.Int // открытая экспонента e
+	MinPrimeDistanceBits int      // |r_i - r_j| >= 2^MinPrimeDistanceBits для всех пар
+	WienerBound          float64  // d > n^WienerBound; 0.25 - классическая граница Винера
+	BonehDurfeeCheck     bool     // дополнительно требовать d >= n^0.292
+}
+
+// NewKeyGenPolicy создает политику для двухпростого ключа длины modulusBits
+// с e = 65537 и проверками прежнего генератора: |p - q| >= 2^(bits/4 - 100),
+// d > n^0.25 и d >= n^0.292
+func NewKeyGenPolicy(modulusBits int) *KeyGenPolicy {
+	return &KeyGenPolicy{
+		ModulusBits:          modulusBits,
+		PrimeCount:           2,
+		PublicExponent:       big.NewInt(65537),
+		MinPrimeDistanceBits: max(0, modulusBits/4-100),
+		WienerBound:          0.25,
+		BonehDurfeeCheck:     true,
+	}
+}
+
+// Validate проверяет согласованность политики
+func (p *KeyGenPolicy) Validate() error {
+	if p.PrimeCount < 2 || p.PrimeCount > maxPrimeCount {
+		return fmt.Errorf("prime count must be between 2 and %d", maxPrimeCount)
+	}
+	if p.ModulusBits < 6 {
+		return errors.New("modulus size too small")
+	}
+	if p.PrimeCount > 2 && p.ModulusBits/p.PrimeCount < minMultiPrimeBits {
+		return fmt.Errorf("multi-prime keys need primes of at least %d bits", minMultiPrimeBits)
+	}
+	if err := ValidatePublicExponent(p.PublicExponent); err != nil {
+		return err
+	}
+	if p.MinPrimeDistanceBits < 0 || p.MinPrimeDistanceBits >= p.ModulusBits/p.PrimeCount {
+		return errors.New("minimum prime distance out of range")
+	}
+	if p.WienerBound < 0 || p.WienerBound >= 1 {
+		return errors.New("Wiener bound must be in [0, 1)")
+	}
+	return nil
+}
+
+// ValidatePublicExponent проверяет открытую экспоненту: e нечетна
+// и 3 <= e < 2^256
+func ValidatePublicExponent(e *big.Int) error {
+	if e == nil || e.Cmp(big.NewInt(3)) < 0 {
+		return errors.New("public exponent must be at least 3")
+	}
+	if e.Bit(0) == 0 {
+		return errors.New("public exponent must be odd")
+	}
+	if e.BitLen() > maxPublicExponentBits {
+		return fmt.Errorf("public exponent must be less than 2^%d", maxPublicExponentBits)
+	}
+	return nil
+}
+
+// primeSizes распределяет длину модуля между простыми: первые
+// ModulusBits mod k простых на бит длиннее
+func (p *KeyGenPolicy) primeSizes() []int {
+	sizes := make([]int, p.PrimeCount)
+	for i := range sizes {
+		sizes[i] = p.ModulusBits / p.PrimeCount
+		if i < p.ModulusBits%p.PrimeCount {
+			sizes[i]++
+		}
+	}
+	return sizes
+}
+
+// checkPrimeDistance возвращает описание нарушения, если какие-то два простых
+// ближе 2^MinPrimeDistanceBits
+func (p *KeyGenPolicy) checkPrimeDistance(primes []*big.Int) (string, bool) {
+	minDiff := new(big.Int).Lsh(big.NewInt(1), uint(p.MinPrimeDistanceBits))
+	diff := new(big.Int)
+	for i := range primes {
+		for j := i + 1; j < len(primes); j++ {
+			diff.Sub(primes[i], primes[j]).Abs(diff)
+			if diff.Cmp(minDiff) < 0 {
+				return fmt.Sprintf("|r%d - r%d| < 2^%d", i+1, j+1, p.MinPrimeDistanceBits), false
+			}
+		}
+	}
+	return "", true
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+)
+
+// LLLReduce выполняет LLL-редукцию базиса решетки с параметром delta
+// (обычно 3/4 или 99/100). Используется целочисленный вариант алгоритма
+// (Коэн, алгоритм 2.6.7): коэффициенты Грама-Шмидта хранятся как рациональные
+// числа lambda[k][j] / d[j] с общими знаменателями d[j], поэтому вся арифметика
+// точная и обходится без big.Rat. Векторы базиса должны быть линейно независимы.
+// Исходный базис не изменяется.
+func LLLReduce(basis [][]*big.Int, delta *big.Rat) ([][]*big.Int, error) {
+	n := len(basis)
+	if n == 0 {
+		return nil, nil
+	}
+	if delta.Cmp(big.NewRat(1, 4)) <= 0 || delta.Cmp(big.NewRat(1, 1)) > 0 {
+		return nil, errors.New("delta must be in (1/4, 1]")
+	}
+
+	// Индексы с 1, как в описании алгоритма: b[1..n], d[0..n]
+	b := make([][]*big.Int, n+1)
+	for i, v := range basis {
+		b[i+1] = make([]*big.Int, len(v))
+		for j, x := range v {
+			b[i+1][j] = new(big.Int).Set(x)
+		}
+	}
+	d := make([]*big.Int, n+1)
+	lambda := make([][]*big.Int, n+1)
+	for i := range lambda {
+		lambda[i] = make([]*big.Int, n+1)
+		for j := range lambda[i] {
+			lambda[i][j] = new(big.Int)
+		}
+	}
+
+	deltaNum, deltaDen := delta.Num(), delta.Denom()
+	d[0] = big.NewInt(1)
+	d[1] = dotProduct(b[1], b[1])
+	if d[1].Sign() == 0 {
+		return nil, errors.New("basis vectors are linearly dependent")
+	}
+
+	red := func(k, l int) {
+		// |2 lambda[k][l]| > d[l] => b[k] -= q b[l], q = round(lambda[k][l] / d[l])
+		twice := new(big.Int).Lsh(lambda[k][l], 1)
+		if twice.CmpAbs(d[l]) <= 0 {
+			return
+		}
+		q := roundDiv(lambda[k][l], d[l])
+		for i := range b[k] {
+			b[k][i].Sub(b[k][i], new(big.Int).Mul(q, b[l][i]))
+		}
+		lambda[k][l].Sub(lambda[k][l], new(big.Int).Mul(q, d[l]))
+		for i := 1; i < l; i++ {
+			lambda[k][i].Sub(lambda[k][i], new(big.Int).Mul(q, lambda[l][i]))
+		}
+	}
+
+	kmax := 1
+	swap := func(k int) {
+		b[k], b[k-1] = b[k-1], b[k]
+		for j := 1; j <= k-2; j++ {
+			lambda[k][j], lambda[k-1][j] = lambda[k-1][j], lambda[k][j]
+		}
+
+		l := lambda[k][k-1]
+		// B = (d[k-2] d[k] + l^2) / d[k-1]
+		B := new(big.Int).Mul(d[k-2], d[k])
+		B.Add(B, new(big.Int).Mul(l, l))
+		B.Quo(B, d[k-1])
+
+		for i := k + 1; i <= kmax; i++ {
+			t := lambda[i][k]
+			newIK := new(big.Int).Mul(d[k], lambda[i][k-1])
+			newIK.Sub(newIK, new(big.Int).Mul(l, t))
+			newIK.Quo(newIK, d[k-1])
+
+			newIK1 := new(big.Int).Mul(B, t)
+			newIK1.Add(newIK1, new(big.Int).Mul(l, newIK))
+			newIK1.Quo(newIK1, d[k])
+
+			lambda[i][k] = newIK
+			lambda[i][k-1] = newIK1
+		}
+		d[k-1] = B
+	}
+
+	left, right := new(big.Int), new(big.Int)
+	for k := 2; k <= n; {
+		// Инкрементальный Грам-Шмидт для нового вектора b[k]
+		if k > kmax {
+			kmax = k
+			for j := 1; j <= k; j++ {
+				u := dotProduct(b[k], b[j])
+				for i := 1; i < j; i++ {
+					u.Mul(u, d[i])
+					u.Sub(u, new(big.Int).Mul(lambda[k][i], lambda[j][i]))
+					u.Quo(u, d[i-1])
+				}
+				if j < k {
+					lambda[k][j] = u
+				} else {
+					d[k] = u
+				}
+			}
+			if d[k].Sign() == 0 {
+				return nil, errors.New("basis vectors are linearly dependent")
+			}
+		}
+
+		// Условие Ловаса: den * d[k] d[k-2] >= num * d[k-1]^2 - den * lambda[k][k-1]^2
+		red(k, k-1)
+		left.Mul(d[k], d[k-2])
+		left.Mul(left, deltaDen)
+		right.Mul(d[k-1], d[k-1])
+		right.Mul(right, deltaNum)
+		lk := new(big.Int).Mul(lambda[k][k-1], lambda[k][k-1])
+		right.Sub(right, lk.Mul(lk, deltaDen))
+
+		if left.Cmp(right) < 0 {
+			swap(k)
+			if k > 2 {
+				k--
+			}
+			continue
+		}
+
+		for l := k - 2; l >= 1; l-- {
+			red(k, l)
+		}
+		k++
+	}
+
+	return b[1:], nil
+}
+
+func dotProduct(a, b []*big.Int) *big.Int {
+	sum := new(big.Int)
+	t := new(big.Int)
+	for i := range a {
+		sum.Add(sum, t.Mul(a[i], b[i]))
+	}
+	return sum
+}
+
+// roundDiv возвращает ближайшее к a/b целое (b > 0)
+func roundDiv(a, b *big.Int) *big.Int {
+	// floor((2a + b) / 2b)
+	num := new(big.Int).Lsh(a, 1)
+	num.Add(num, b)
+	return num.Div(num, new(big.Int).Lsh(b, 1))
+}
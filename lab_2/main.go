@@ -1,11 +1,30 @@
 package main
 
 import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"crypto/x509"
 	"fmt"
+	"io"
 	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"lab_4/primes"
 )
 
 func main() {
+	// С аргументами программа работает как утилита командной строки
+	if len(os.Args) > 1 {
+		os.Exit(runCLI(os.Args[1:]))
+	}
 
 	// 1: Демонстрация MathService
 	fmt.Println("MathService")
@@ -29,7 +48,12 @@ func main() {
 	base := big.NewInt(3)
 	exp := big.NewInt(5)
 	mod := big.NewInt(13)
-	fmt.Printf("ModPow: 3^5 mod 13 = %s\n\n", ms.ModPow(base, exp, mod))
+	fmt.Printf("ModPow: 3^5 mod 13 = %s\n", ms.ModPow(base, exp, mod))
+	fmt.Printf("Бинарный НОД(48, 18) = %s\n\n", ms.BinaryGCD(big.NewInt(48), big.NewInt(18)))
+
+	demonstrateNumberTheory(ms)
+	demonstrateContinuedFractions(ms)
+	demonstrateTimingLeak(ms)
 
 	// 2: Демонстрация тестов простоты
 	fmt.Println("Тесты простоты")
@@ -44,6 +68,12 @@ func main() {
 	miller := NewMillerRabinTest(ms)
 	fmt.Printf("Тест Миллера-Рабина для %s: %v\n\n", testNum, miller.IsProbablyPrime(testNum, 0.999))
 
+	demonstratePrimalityBounds(ms)
+	demonstratePseudoprimes(ms)
+	demonstratePrimeGeneration(ms)
+	demonstrateParallelPrimeSearch(ms)
+	demonstrateProvableKeys()
+
 	// 3: Демонстрация RSA
 	fmt.Println("RSA шифрование")
 	rsaService := NewRSAService(TestMillerRabin, 0.9999, 512)
@@ -55,6 +85,11 @@ func main() {
 		return
 	}
 
+	for i, stats := range rsaService.KeyGenerationStats() {
+		fmt.Printf("  Простое #%d: кандидатов %d, отсеяно решетом %d, вызовов теста %d, %v\n",
+			i+1, stats.Candidates, stats.SieveRejections, stats.TestCalls, stats.Duration)
+	}
+
 	pubKey := rsaService.GetPublicKey()
 	fmt.Printf("Открытый ключ:\n  N = %s\n  E = %s\n", pubKey.N, pubKey.E)
 
@@ -75,6 +110,54 @@ func main() {
 	}
 	fmt.Printf("Расшифрованное: %s\n\n", decrypted)
 
+	demonstrateByteEncryption(rsaService)
+
+	// 3.1: Подпись через КТО и атака по сбоям
+	fmt.Println("Подпись RSA через КТО")
+	privKey := rsaService.GetPrivateKey()
+	fmt.Printf("dP = %s\ndQ = %s\nqInv = %s\n", privKey.Dp, privKey.Dq, privKey.Qinv)
+
+	signature, err := rsaService.Sign(message)
+	if err != nil {
+		fmt.Printf("Ошибка подписи: %v\n", err)
+		return
+	}
+	valid, _ := rsaService.Verify(message, signature)
+	fmt.Printf("Подпись: %s\nПодпись верна: %v\n\n", signature, valid)
+
+	fmt.Println("Атака Bellcore (сбой при вычислении по модулю q)")
+	flipLowBit := func(mq *big.Int) *big.Int {
+		return new(big.Int).Xor(mq, big.NewInt(1)) // переворачиваем младший бит
+	}
+
+	_, err = rsaService.signWithFault(message, flipLowBit)
+	fmt.Printf("С проверкой открытой экспонентой: %v\n", err)
+
+	rsaService.SetFaultCheck(false)
+	faultySignature, err := rsaService.signWithFault(message, flipLowBit)
+	rsaService.SetFaultCheck(true)
+	if err != nil {
+		fmt.Printf("Ошибка подписи: %v\n", err)
+		return
+	}
+
+	bellcoreResult := NewBellcoreAttackService().Attack(pubKey, message, faultySignature)
+	if bellcoreResult.Success {
+		fmt.Printf("Без проверки: атака успешна, найден множитель p = %s\n", bellcoreResult.P)
+		fmt.Printf("Совпадает с секретным p: %v\n\n", bellcoreResult.P.Cmp(privKey.P) == 0)
+	} else {
+		fmt.Print("Без проверки: атака не удалась\n\n")
+	}
+
+	demonstrateBlinding(rsaService)
+	demonstrateMultiPrimeRSA()
+	demonstrateHomomorphicEncryption()
+	demonstrateQuadraticResiduosity()
+	demonstrateRSAPadding(rsaService)
+	demonstrateRSASignatures(rsaService)
+	demonstrateKeySerialization(rsaService)
+	demonstrateHybridEncryption(rsaService)
+
 	/// 4: Демонстрация атаки Винера
 	fmt.Println("Атака Винера")
 	fmt.Println("Создание уязвимого ключа для демонстрации атаки...")
@@ -144,4 +227,1179 @@ func main() {
 		fmt.Println("  (возможно, нужно улучшить алгоритм атаки)")
 	}
 
+	demonstrateBonehDurfee()
+	demonstrateAttackSuite()
+	demonstrateFactorization()
+	demonstrateKeyAudit(rsaService)
+}
+
+// weakRSAKey создает ключ с n длины bits и случайной d длины dBits
+func weakRSAKey(bits, dBits int) (*RSAPublicKey, *big.Int) {
+	for {
+		p, _ := rand.Prime(rand.Reader, bits/2)
+		q, _ := rand.Prime(rand.Reader, bits/2)
+		n := new(big.Int).Mul(p, q)
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, big.NewInt(1)), new(big.Int).Sub(q, big.NewInt(1)))
+
+		d, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(dBits)))
+		d.SetBit(d, dBits-1, 1)
+		e := new(big.Int).ModInverse(d, phi)
+		if e != nil && n.BitLen() == bits {
+			return &RSAPublicKey{N: n, E: e}, d
+		}
+	}
+}
+
+// demonstrateBonehDurfee атакует ключ с d ~ n^0.26 - выше границы Винера,
+// но ниже границы Боне-Дерфи
+func demonstrateBonehDurfee() {
+	fmt.Println("\nАтака Боне-Дерфи")
+	const bits, delta = 256, 0.26
+	pub, d := weakRSAKey(bits, 66) // 66 ≈ 0.26 * 256
+	fmt.Printf("  n: %d бит, d: %d бит (n^0.25 ≈ %d бит, n^0.292 ≈ %.0f бит)\n",
+		pub.N.BitLen(), d.BitLen(), bits/4, BonehDurfeeExponent*bits)
+
+	wiener := NewWienerAttackService().Attack(pub)
+	fmt.Printf("  Атака Винера: успех %v\n", wiener.Success)
+
+	start := time.Now()
+	result, err := NewBonehDurfeeAttackService(4, 2).Attack(pub, delta)
+	if err != nil {
+		fmt.Printf("  Ошибка: %v\n", err)
+		return
+	}
+	fmt.Printf("  Боне-Дерфи (m = 4, t = 2, размерность %d): успех %v за %v\n",
+		result.LatticeDimension, result.Success, time.Since(start))
+	if result.Success {
+		fmt.Printf("  Найденная d совпадает: %v\n", result.D.Cmp(d) == 0)
+	}
+	fmt.Printf("  Ключ ниже границы n^0.292: %v\n", BelowBonehDurfeeBound(pub.N, d))
+}
+
+// nextPrime возвращает наименьшее простое, не меньшее n
+func nextPrime(n *big.Int) *big.Int {
+	p := new(big.Int).SetBit(n, 0, 1)
+	for !p.ProbablyPrime(20) {
+		p.Add(p, big.NewInt(2))
+	}
+	return p
+}
+
+// smoothPrime строит простое p длины не меньше bits, у которого p-1
+// раскладывается на простые не больше bound
+func smoothPrime(bits, bound int) *big.Int {
+	for {
+		p := big.NewInt(2)
+		for p.BitLen() < bits {
+			f, _ := rand.Int(rand.Reader, big.NewInt(int64(bound-2)))
+			f.Add(f, big.NewInt(2))
+			if f.ProbablyPrime(20) {
+				p.Mul(p, f)
+			}
+		}
+		p.Add(p, big.NewInt(1))
+		if p.ProbablyPrime(20) {
+			return p
+		}
+	}
+}
+
+// demonstrateFactorization раскладывает модули RSA растущего размера и
+// составное число со множителями разной величины, показывая, какой метод
+// нашел каждый делитель
+func demonstrateFactorization() {
+	fmt.Println("\nФакторизация")
+	fs := NewFactorizationService(TestMillerRabin, 0.999999)
+	fs.SetTimeLimit(30 * time.Second)
+
+	fmt.Println("Модули RSA n = pq:")
+	for _, bits := range []int{64, 96, 128, 160} {
+		p, _ := rand.Prime(rand.Reader, bits/2)
+		q, _ := rand.Prime(rand.Reader, bits/2)
+		n := new(big.Int).Mul(p, q)
+
+		result, err := fs.Factor(context.Background(), n)
+		if err != nil {
+			fmt.Printf("  %3d бит: %v\n", bits, err)
+			continue
+		}
+		method := "пробное деление"
+		if len(result.Steps) > 0 {
+			method = result.Steps[len(result.Steps)-1].Method
+		}
+		fmt.Printf("  %3d бит: %v (%s)\n", bits, result.Duration.Round(time.Millisecond), method)
+	}
+
+	// 2^4 * 3^2 * 4099^2 * p1^3 * p2 * p3 с 40-битным p1 и p2, p3 по 48 бит
+	n := big.NewInt(16 * 9 * 4099 * 4099)
+	p1, _ := rand.Prime(rand.Reader, 40)
+	n.Mul(n, new(big.Int).Exp(p1, big.NewInt(3), nil))
+	for i := 0; i < 2; i++ {
+		p, _ := rand.Prime(rand.Reader, 48)
+		n.Mul(n, p)
+	}
+	result, err := fs.Factor(context.Background(), n)
+	if err != nil {
+		fmt.Printf("Ошибка факторизации: %v\n", err)
+		return
+	}
+	fmt.Printf("n = %s (%d бит)\n", n, n.BitLen())
+	for _, step := range result.Steps {
+		fmt.Printf("  %s: делитель %s (%v)\n", step.Method, step.Divisor, step.Duration.Round(time.Microsecond))
+	}
+	var parts []string
+	for _, f := range result.Factors {
+		if f.Exponent == 1 {
+			parts = append(parts, f.Prime.String())
+		} else {
+			parts = append(parts, fmt.Sprintf("%s^%d", f.Prime, f.Exponent))
+		}
+	}
+	fmt.Printf("  n = %s, проверено: %v\n", strings.Join(parts, " * "), result.Success)
+
+	// 256-битный модуль за отведенное время не раскладывается
+	fs.SetTimeLimit(2 * time.Second)
+	p, _ := rand.Prime(rand.Reader, 128)
+	q, _ := rand.Prime(rand.Reader, 128)
+	result, err = fs.Factor(context.Background(), new(big.Int).Mul(p, q))
+	fmt.Printf("256-битный модуль с лимитом 2 с: %v, не разложено множителей: %d\n",
+		err, len(result.Unfactored))
+}
+
+// demonstrateAttackSuite показывает атаки на ключи RSA с характерными
+// слабостями: близкие p и q, малая e, общий модуль, общие множители,
+// гладкое p-1 и малый множитель
+func demonstrateAttackSuite() {
+	fmt.Println("\nНабор атак на RSA")
+	e3 := big.NewInt(3)
+	message := new(big.Int).SetBytes([]byte("attack at dawn"))
+
+	// Ферма: q - следующее простое после p + 2^262, число итераций
+	// примерно (p-q)^2 / (8 sqrt(n)) ≈ 2^9
+	p, _ := rand.Prime(rand.Reader, 512)
+	q := nextPrime(new(big.Int).Add(p, new(big.Int).Lsh(big.NewInt(1), 262)))
+	fermatKey := &RSAPublicKey{N: new(big.Int).Mul(p, q), E: big.NewInt(65537)}
+	fermat := NewFermatAttackService(100000).Attack(fermatKey)
+	fmt.Printf("  Ферма (|p-q| ≈ 2^262): успех %v за %d итераций, p совпадает: %v\n",
+		fermat.Success, fermat.Iterations, fermat.Success && (fermat.P.Cmp(p) == 0 || fermat.Q.Cmp(p) == 0))
+
+	// Хостад: одно сообщение трем получателям с e = 3
+	var keys []*RSAPublicKey
+	var ciphertexts []*big.Int
+	for i := 0; i < 3; i++ {
+		p, _ := rand.Prime(rand.Reader, 256)
+		q, _ := rand.Prime(rand.Reader, 256)
+		key := &RSAPublicKey{N: new(big.Int).Mul(p, q), E: e3}
+		keys = append(keys, key)
+		ciphertexts = append(ciphertexts, new(big.Int).Exp(message, e3, key.N))
+	}
+	hastad, err := NewHastadAttackService().Attack(keys, ciphertexts)
+	if err != nil {
+		fmt.Printf("  Хостад: %v\n", err)
+	} else {
+		fmt.Printf("  Хостад (e = 3, 3 получателя): успех %v, сообщение %q\n", hastad.Success, hastad.Message.Bytes())
+	}
+
+	// Общий модуль: одно сообщение под e1 = 65537 и e2 = 17
+	key1 := &RSAPublicKey{N: keys[0].N, E: big.NewInt(65537)}
+	key2 := &RSAPublicKey{N: keys[0].N, E: big.NewInt(17)}
+	c1 := new(big.Int).Exp(message, key1.E, key1.N)
+	c2 := new(big.Int).Exp(message, key2.E, key2.N)
+	common, err := NewCommonModulusAttackService().Attack(key1, key2, c1, c2)
+	if err != nil {
+		fmt.Printf("  Общий модуль: %v\n", err)
+	} else {
+		fmt.Printf("  Общий модуль (a = %s, b = %s): успех %v, сообщение %q\n",
+			common.A, common.B, common.Success, common.Message.Bytes())
+	}
+
+	// Пакетный НОД: ключи 2 и 5 используют общее простое
+	shared, _ := rand.Prime(rand.Reader, 256)
+	var batch []*RSAPublicKey
+	for i := 0; i < 8; i++ {
+		p, _ := rand.Prime(rand.Reader, 256)
+		if i == 2 || i == 5 {
+			p = shared
+		}
+		q, _ := rand.Prime(rand.Reader, 256)
+		batch = append(batch, &RSAPublicKey{N: new(big.Int).Mul(p, q), E: big.NewInt(65537)})
+	}
+	batchResult := NewBatchGCDAttackService().Attack(batch)
+	fmt.Printf("  Пакетный НОД (8 ключей): успех %v, слабые ключи:", batchResult.Success)
+	for _, weak := range batchResult.WeakKeys {
+		fmt.Printf(" #%d", weak.Index)
+	}
+	fmt.Println()
+
+	// Поллард p-1: p-1 раскладывается на простые меньше 1000
+	smooth := smoothPrime(256, 1000)
+	q, _ = rand.Prime(rand.Reader, 256)
+	pm1 := NewPollardAttackService().PMinus1(&RSAPublicKey{N: new(big.Int).Mul(smooth, q), E: big.NewInt(65537)}, 2000)
+	fmt.Printf("  Поллард p-1 (B = 2000): успех %v за %d итераций, p совпадает: %v\n",
+		pm1.Success, pm1.Iterations, pm1.Success && pm1.P.Cmp(smooth) == 0)
+
+	// Ро-метод Полларда: один из множителей 32-битный
+	small, _ := rand.Prime(rand.Reader, 32)
+	rho := NewPollardAttackService().Rho(&RSAPublicKey{N: new(big.Int).Mul(small, q), E: big.NewInt(65537)}, 1000000)
+	fmt.Printf("  Ро-метод Полларда (множитель 32 бит): успех %v за %d итераций, p совпадает: %v\n",
+		rho.Success, rho.Iterations, rho.Success && rho.P.Cmp(small) == 0)
+}
+
+// demonstrateNumberTheory показывает квадратные корни по простому модулю,
+// обобщенную КТО, функции Эйлера и Кармайкла и дискретные логарифмы
+func demonstrateNumberTheory(ms *MathService) {
+	fmt.Println("Теория чисел")
+
+	// p = 1 (mod 2^13): случай, в котором Тонелли-Шенкс делает несколько итераций
+	p := big.NewInt(40961)
+	a := big.NewInt(1234)
+	a.Mul(a, a).Mod(a, p)
+	ts, err := ms.TonelliShanks(a, p)
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	cipolla, _ := ms.Cipolla(a, p)
+	fmt.Printf("sqrt(%s) mod %s: Тонелли-Шенкс %s, Чиполла %s\n", a, p, ts, cipolla)
+	_, err = ms.TonelliShanks(big.NewInt(3), p)
+	fmt.Printf("sqrt(3) mod %s: %v\n", p, err)
+
+	residues := []*big.Int{big.NewInt(3), big.NewInt(7), big.NewInt(6)}
+	moduli := []*big.Int{big.NewInt(12), big.NewInt(10), big.NewInt(9)}
+	x, lcm, err := ms.CRT(residues, moduli)
+	if err != nil {
+		fmt.Printf("Ошибка КТО: %v\n", err)
+		return
+	}
+	fmt.Printf("x = 3 (12), x = 7 (10), x = 6 (9): x = %s (mod %s)\n", x, lcm)
+	_, _, err = ms.CRT([]*big.Int{big.NewInt(1), big.NewInt(2)}, []*big.Int{big.NewInt(4), big.NewInt(6)})
+	fmt.Printf("x = 1 (4), x = 2 (6): %v\n", err)
+
+	_, err = ms.ModInverse(big.NewInt(6), big.NewInt(9))
+	inv, _ := ms.ModInverse(big.NewInt(7), big.NewInt(9))
+	fmt.Printf("7^(-1) mod 9 = %s, 6^(-1) mod 9: %v\n", inv, err)
+
+	// 2016 = 2^5 * 3^2 * 7
+	factors := []PrimePower{{big.NewInt(2), 5}, {big.NewInt(3), 2}, {big.NewInt(7), 1}}
+	phi, _ := ms.EulerPhi(factors)
+	lambda, _ := ms.CarmichaelLambda(factors)
+	fmt.Printf("n = 2016: phi(n) = %s, lambda(n) = %s\n", phi, lambda)
+
+	// Дискретный логарифм по модулю p = 2^2 * 3^3 * 5 * 7 * 11 * 13 * 17 + 1 = 9189181
+	// с гладким p - 1; 6 - первообразный корень
+	mod := big.NewInt(9189181)
+	g := big.NewInt(6)
+	secret := big.NewInt(3141592)
+	h := ms.ModPow(g, secret, mod)
+	orderFactors := []PrimePower{
+		{big.NewInt(2), 2}, {big.NewInt(3), 3}, {big.NewInt(5), 1}, {big.NewInt(7), 1},
+		{big.NewInt(11), 1}, {big.NewInt(13), 1}, {big.NewInt(17), 1},
+	}
+
+	solvers := []struct {
+		name  string
+		solve func() (*big.Int, error)
+	}{
+		{"шаги младенца/великана", func() (*big.Int, error) { return ms.BabyStepGiantStep(g, h, mod, nil) }},
+		{"rho Полларда", func() (*big.Int, error) { return ms.PollardRhoLog(g, h, mod, nil) }},
+		{"Похлиг-Хеллман", func() (*big.Int, error) { return ms.PohligHellman(g, h, mod, orderFactors) }},
+	}
+	fmt.Printf("log_%s(%s) mod %s:\n", g, h, mod)
+	for _, s := range solvers {
+		start := time.Now()
+		x, err := s.solve()
+		if err != nil {
+			fmt.Printf("  %s: %v\n", s.name, err)
+			continue
+		}
+		fmt.Printf("  %s: x = %s, верно: %v (%v)\n", s.name, x, x.Cmp(secret) == 0, time.Since(start))
+	}
+	fmt.Println()
+}
+
+// demonstrateContinuedFractions цепные дроби: рациональные числа, наилучшие
+// приближения, квадратичные иррациональности и уравнение Пелля
+func demonstrateContinuedFractions(ms *MathService) {
+	fmt.Println("Цепные дроби")
+
+	terms, _ := ms.ContinuedFractionTerms(big.NewInt(415), big.NewInt(93))
+	fmt.Printf("415/93 = %v, подходящие дроби:", terms)
+	for _, cf := range ms.Convergents(terms) {
+		fmt.Printf(" %s", cf.Rat().RatString())
+	}
+	fmt.Println()
+
+	semi := ms.Semiconvergents(terms)
+	fmt.Printf("Промежуточных дробей: %d (1 + сумма элементов после a_0)\n", len(semi))
+
+	// pi с 15 знаками: наилучшие приближения с ограниченным знаменателем
+	pi, _ := new(big.Rat).SetString("3.141592653589793")
+	for _, limit := range []int64{10, 100, 1000, 100000} {
+		best, _ := ms.BestApproximation(pi.Num(), pi.Denom(), big.NewInt(limit))
+		diff := new(big.Rat).Sub(best.Rat(), pi)
+		f, _ := diff.Float64()
+		fmt.Printf("  pi, знаменатель <= %d: %s (ошибка %.2e)\n", limit, best.Rat().RatString(), f)
+	}
+
+	for _, n := range []int64{2, 7, 61} {
+		cf, _ := ms.SqrtContinuedFraction(big.NewInt(n))
+		fmt.Printf("sqrt(%d) = [%s; %v], период %d\n", n, cf.Prefix[0], cf.Period, len(cf.Period))
+	}
+	golden, _ := ms.QuadraticContinuedFraction(big.NewInt(1), big.NewInt(5), big.NewInt(2))
+	fmt.Printf("(1 + sqrt(5)) / 2 = %v; (%v)\n", golden.Prefix, golden.Period)
+	negative, _ := ms.QuadraticContinuedFraction(big.NewInt(-3), big.NewInt(7), big.NewInt(-5))
+	fmt.Printf("(-3 + sqrt(7)) / -5 = %v; (%v)\n", negative.Prefix, negative.Period)
+
+	x, y, _ := ms.SolvePell(big.NewInt(61))
+	check := new(big.Int).Mul(x, x)
+	check.Sub(check, new(big.Int).Mul(big.NewInt(61), new(big.Int).Mul(y, y)))
+	fmt.Printf("x^2 - 61 y^2 = 1: x = %s, y = %s (проверка %s)\n", x, y, check)
+
+	x, y, _ = ms.SolveNegativePell(big.NewInt(13))
+	fmt.Printf("x^2 - 13 y^2 = -1: x = %s, y = %s\n", x, y)
+	_, _, err := ms.SolveNegativePell(big.NewInt(3))
+	fmt.Printf("x^2 - 3 y^2 = -1: %v\n", err)
+
+	solutions, _ := ms.PellSolutions(big.NewInt(2), 5)
+	fmt.Print("x^2 - 2 y^2 = 1:")
+	for _, s := range solutions {
+		fmt.Printf(" (%s, %s)", s[0], s[1])
+	}
+	fmt.Print("\n\n")
+}
+
+// demonstrateTimingLeak сравнивает распределения времени возведения в степень
+// для фиксированного разреженного показателя и случайных показателей:
+// скользящее окно выдает число единичных бит, лестница Монтгомери - нет
+func demonstrateTimingLeak(ms *MathService) {
+	fmt.Println("Утечка времени при возведении в степень (тест фиксированный/случайный)")
+
+	m, err := rand.Prime(rand.Reader, 512)
+	if err != nil {
+		fmt.Printf("Ошибка генерации модуля: %v\n", err)
+		return
+	}
+	// Фиксированный показатель из трех единичных бит той же длины, что и модуль
+	fixedExp := new(big.Int).SetBit(big.NewInt(1), m.BitLen()-1, 1)
+	fixedExp.SetBit(fixedExp, m.BitLen()/2, 1)
+
+	operations := []struct {
+		name string
+		op   ModPowFunc
+	}{
+		{"ModPowSlidingWindow (скользящее окно)", func(base, exp, m *big.Int) (*big.Int, error) {
+			return ms.ModPowSlidingWindow(base, exp, m), nil
+		}},
+		{"ModPowConstantTime (лестница)", ms.ModPowConstantTime},
+	}
+
+	analysis := NewTimingAnalysisService(5000)
+	for _, o := range operations {
+		result, err := analysis.FixedVsRandom(m, fixedExp, o.op)
+		if err != nil {
+			fmt.Printf("Ошибка измерения: %v\n", err)
+			return
+		}
+		fmt.Printf("  %s:\n", o.name)
+		fmt.Printf("    фиксированный: медиана %v, P10-P90 %v-%v\n",
+			result.Fixed.Median, result.Fixed.P10, result.Fixed.P90)
+		fmt.Printf("    случайный:     медиана %v, P10-P90 %v-%v\n",
+			result.Random.Median, result.Random.P10, result.Random.P90)
+		fmt.Printf("    вес показателя: фиксированный %d, случайный %.0f\n", result.FixedWeight, result.RandomWeight)
+		fmt.Printf("    t = %.1f (отсечение по P%d), утечка: %v\n", result.TStatistic, result.CropPercentile, result.Leak)
+	}
+
+	base, _ := rand.Int(rand.Reader, m)
+	exp, _ := rand.Int(rand.Reader, m)
+	ct, err := ms.ModPowConstantTime(base, exp, m)
+	fmt.Printf("Результат лестницы совпадает с big.Exp: %v\n\n",
+		err == nil && ct.Cmp(new(big.Int).Exp(base, exp, m)) == 0)
+}
+
+// rocaLikePrime строит простое вида k*M + (65537^a mod M), где M - произведение
+// первых 39 простых, как у уязвимой библиотеки RSALib
+func rocaLikePrime(bits int) *big.Int {
+	m := big.NewInt(1)
+	for p, count := int64(2), 0; count < 39; p++ {
+		if big.NewInt(p).ProbablyPrime(1) {
+			m.Mul(m, big.NewInt(p))
+			count++
+		}
+	}
+	kBits := bits - m.BitLen()
+	for {
+		a, _ := rand.Int(rand.Reader, m)
+		k, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(kBits)))
+		k.SetBit(k, kBits-1, 1)
+		p := new(big.Int).Exp(big.NewInt(65537), a, m)
+		p.Add(p, k.Mul(k, m))
+		if p.ProbablyPrime(20) {
+			return p
+		}
+	}
+}
+
+// demonstrateKeyAudit проверяет аудитором сгенерированный ключ и ключи
+// с характерными дефектами
+func demonstrateKeyAudit(rsaService *RSAService) {
+	fmt.Println("\nАудит ключей")
+	auditor := NewKeyAuditService(0.999999)
+	fmt.Print(auditor.Audit(rsaService.GetPublicKey(), rsaService.GetPrivateKey()))
+
+	summary := func(name string, report *KeyAuditReport) {
+		var problems []string
+		for _, f := range report.Findings {
+			if f.Severity >= SeverityWarning {
+				problems = append(problems, f.Check)
+			}
+		}
+		fmt.Printf("  %-28s: %s %v\n", name, report.Worst(), problems)
+	}
+
+	wienerKey, _ := weakRSAKey(1024, 200)
+	summary("малая d (Винер)", auditor.Audit(wienerKey, nil))
+
+	p, q := rocaLikePrime(512), rocaLikePrime(512)
+	rocaKey := &RSAPublicKey{N: new(big.Int).Mul(p, q), E: big.NewInt(65537)}
+	summary("отпечаток ROCA", auditor.Audit(rocaKey, nil))
+
+	auditor.SetUnpadded(true)
+	e3 := &RSAPublicKey{N: rsaService.GetPublicKey().N, E: big.NewInt(3)}
+	summary("e = 3 без дополнения", auditor.Audit(e3, nil))
+	auditor.SetUnpadded(false)
+
+	// Искаженный dP (сбой памяти) и составной "простой" множитель
+	priv := *rsaService.GetPrivateKey()
+	priv.Dp = new(big.Int).Add(priv.Dp, big.NewInt(2))
+	summary("искаженный dP", auditor.Audit(priv.PublicKey, &priv))
+
+	r, _ := rand.Prime(rand.Reader, 256)
+	s, _ := rand.Prime(rand.Reader, 256)
+	t, _ := rand.Prime(rand.Reader, 512)
+	pq := new(big.Int).Mul(r, s)
+	bad := &RSAPrivateKey{
+		PublicKey: &RSAPublicKey{N: new(big.Int).Mul(pq, t), E: big.NewInt(65537)},
+		D:         big.NewInt(12345),
+		P:         pq,
+		Q:         t,
+	}
+	summary("составной p", auditor.Audit(bad.PublicKey, bad))
+}
+
+// demonstrateMultiPrimeRSA генерирует трехпростой ключ с e = 3 по политике,
+// показывает причины повторов и проверяет ключ кодированием и crypto/rsa
+func demonstrateMultiPrimeRSA() {
+	fmt.Println("Многопростой RSA и политика генерации ключей")
+
+	rs := NewRSAService(TestMillerRabin, 0.9999, 768)
+	policy := NewKeyGenPolicy(1536)
+	policy.PrimeCount = 3
+	policy.PublicExponent = big.NewInt(3)
+	if err := rs.SetKeyGenPolicy(policy); err != nil {
+		fmt.Printf("Ошибка политики: %v\n", err)
+		return
+	}
+	if err := rs.GenerateKeys(); err != nil {
+		fmt.Printf("Ошибка генерации ключей: %v\n", err)
+		return
+	}
+
+	priv := rs.GetPrivateKey()
+	fmt.Printf("Модуль %d бит, e = %s, простые:", priv.PublicKey.N.BitLen(), priv.PublicKey.E)
+	for _, r := range priv.Primes() {
+		fmt.Printf(" %d", r.BitLen())
+	}
+	fmt.Println(" бит")
+
+	counts := make(map[PolicyRule]int)
+	for _, retry := range rs.KeyGenerationRetries() {
+		counts[retry.Rule]++
+	}
+	fmt.Printf("Повторов: %d", len(rs.KeyGenerationRetries()))
+	for rule := RuleExponentCoprime; rule <= RuleCRT; rule++ {
+		if counts[rule] > 0 {
+			fmt.Printf(", %s: %d", rule, counts[rule])
+		}
+	}
+	fmt.Println()
+
+	message := big.NewInt(123456789)
+	ciphertext, _ := rs.Encrypt(message)
+	decrypted, err := rs.Decrypt(ciphertext)
+	fmt.Printf("Расшифровка через КТО по трем простым: %v (%v)\n", decrypted.Cmp(message) == 0, err)
+
+	der, err := MarshalPKCS1PrivateKey(priv)
+	if err != nil {
+		fmt.Printf("Ошибка кодирования: %v\n", err)
+		return
+	}
+	parsed, err := ParsePKCS1PrivateKey(der)
+	fmt.Printf("PKCS#1 v1 (OtherPrimeInfos): %d байт, простых после разбора %d, ошибка: %v\n",
+		len(der), len(parsed.Primes()), err)
+	std, err := priv.ToStdlib()
+	fmt.Printf("crypto/rsa Validate: %v, простых %d\n", err, len(std.Primes))
+
+	for _, e := range []int64{65536, 1} {
+		kg := NewRSAKeyGenerator(TestMillerRabin, 0.9999, 512, NewMathService())
+		fmt.Printf("e = %d: %v\n", e, kg.SetPublicExponent(big.NewInt(e)))
+	}
+	fmt.Println()
+}
+
+// demonstrateHomomorphicEncryption вычисления над шифротекстами Пэйе и Эль-Гамаля
+func demonstrateHomomorphicEncryption() {
+	fmt.Println("Гомоморфное шифрование: Пэйе")
+
+	ps := NewPaillierService(TestMillerRabin, 0.9999, 1024)
+	if err := ps.GenerateKeys(); err != nil {
+		fmt.Printf("Ошибка генерации ключей: %v\n", err)
+		return
+	}
+	pub := ps.GetPublicKey()
+	fmt.Printf("Модуль n: %d бит\n", pub.N.BitLen())
+
+	a, b := big.NewInt(1500), big.NewInt(2700)
+	ca, _ := ps.Encrypt(a)
+	cb, _ := ps.Encrypt(b)
+	sum, _ := ps.Add(ca, cb)
+	plain, err := ps.Decrypt(sum)
+	fmt.Printf("D(E(%s) * E(%s)) = %s (%v)\n", a, b, plain, err)
+
+	scaled, _ := ps.MulPlain(ca, big.NewInt(7))
+	plain, _ = ps.Decrypt(scaled)
+	fmt.Printf("D(E(%s)^7) = %s\n", a, plain)
+
+	shifted, _ := ps.AddPlain(ca, big.NewInt(-500))
+	plain, _ = ps.Decrypt(shifted)
+	fmt.Printf("D(E(%s) * g^(-500)) = %s\n", a, plain)
+
+	// Сумма зарплат без расшифровки отдельных значений
+	salaries := []int64{120000, 95000, 143000, 88000}
+	total, _ := ps.Encrypt(big.NewInt(0))
+	for _, salary := range salaries {
+		c, _ := ps.Encrypt(big.NewInt(salary))
+		total, _ = ps.Add(total, c)
+	}
+	plain, _ = ps.Decrypt(total)
+	fmt.Printf("Сумма зарплат %v по шифротекстам: %s\n", salaries, plain)
+
+	fresh, _ := ps.Rerandomize(ca)
+	plain, _ = ps.Decrypt(fresh)
+	fmt.Printf("Перерандомизация: шифротекст изменился: %v, открытый текст %s\n", fresh.Cmp(ca) != 0, plain)
+
+	nMinus1, _ := ps.Encrypt(new(big.Int).Sub(pub.N, big.NewInt(1)))
+	wrapped, _ := ps.Add(nMinus1, cb)
+	plain, _ = ps.Decrypt(wrapped)
+	fmt.Printf("(n - 1) + %s mod n = %s\n\n", b, plain)
+
+	fmt.Println("Гомоморфное шифрование и подпись: Эль-Гамаль")
+
+	es := NewElGamalService(TestMillerRabin, 0.9999)
+	if err := es.GenerateParameters(768); err != nil {
+		fmt.Printf("Ошибка генерации параметров: %v\n", err)
+		return
+	}
+	if err := es.GenerateKeys(); err != nil {
+		fmt.Printf("Ошибка генерации ключей: %v\n", err)
+		return
+	}
+	params := es.GetParameters()
+	fmt.Printf("p = 2q + 1: %d бит, g порядка q\n", params.P.BitLen())
+
+	m := big.NewInt(31337)
+	c, _ := es.Encrypt(m)
+	plain, err = es.Decrypt(c)
+	fmt.Printf("Шифрование %s -> %s (%v)\n", m, plain, err)
+
+	m1 := es.mathService.ModPow(params.G, big.NewInt(5), params.P)
+	m2 := es.mathService.ModPow(params.G, big.NewInt(11), params.P)
+	c1, _ := es.EncryptElement(m1)
+	c2, _ := es.EncryptElement(m2)
+	product, _ := es.Multiply(c1, c2)
+	element, _ := es.DecryptElement(product)
+	expected := new(big.Int).Mul(m1, m2)
+	fmt.Printf("D(E(M1) * E(M2)) = M1 * M2: %v\n", element.Cmp(expected.Mod(expected, params.P)) == 0)
+
+	bound := big.NewInt(1 << 20)
+	ea, _ := es.EncryptExponent(big.NewInt(4200))
+	eb, _ := es.EncryptExponent(big.NewInt(13))
+	esum, _ := es.Multiply(ea, eb)
+	plain, err = es.DecryptExponent(esum, bound)
+	fmt.Printf("Экспоненциальный вариант: 4200 + 13 = %s (%v)\n", plain, err)
+
+	epow, _ := es.Power(ea, big.NewInt(3))
+	plain, err = es.DecryptExponent(epow, bound)
+	fmt.Printf("E(g^4200)^3 -> %s (%v)\n", plain, err)
+
+	digest := sha256.Sum256([]byte("ElGamal signature"))
+	signature, err := es.Sign(crypto.SHA256, digest[:])
+	if err != nil {
+		fmt.Printf("Ошибка подписи: %v\n", err)
+		return
+	}
+	fmt.Printf("Подпись SHA-256: %v\n", es.Verify(crypto.SHA256, digest[:], signature))
+	digest[0] ^= 1
+	fmt.Printf("Подпись измененного хеша: %v\n\n", es.Verify(crypto.SHA256, digest[:], signature))
+}
+
+// demonstrateQuadraticResiduosity криптосистемы на квадратичных вычетах:
+// Рабин, Гольдвассер-Микали и генератор Блюма-Блюма-Шуба
+func demonstrateQuadraticResiduosity() {
+	fmt.Println("Криптосистема Рабина")
+
+	rabin := NewRabinService(TestMillerRabin, 0.9999, 1024)
+	if err := rabin.GenerateKeys(); err != nil {
+		fmt.Printf("Ошибка генерации ключей: %v\n", err)
+		return
+	}
+	priv := rabin.GetPrivateKey()
+	fmt.Printf("n = pq, %d бит, p mod 4 = %s, q mod 4 = %s\n", priv.PublicKey.N.BitLen(),
+		new(big.Int).Mod(priv.P, big.NewInt(4)), new(big.Int).Mod(priv.Q, big.NewInt(4)))
+
+	message := []byte("Rabin: breaking it is as hard as factoring")
+	ciphertext, err := rabin.EncryptBytes(message)
+	if err != nil {
+		fmt.Printf("Ошибка шифрования: %v\n", err)
+		return
+	}
+	roots, _ := rabin.DecryptRoots(ciphertext)
+	for i, root := range roots {
+		_, ok := parseRabinBlock(root, (priv.PublicKey.N.BitLen()+7)/8-1)
+		fmt.Printf("  корень %d: %x... избыточность верна: %v\n", i+1, root.Bytes()[:8], ok)
+	}
+	decrypted, err := rabin.DecryptBytes(ciphertext)
+	fmt.Printf("Расшифровано: %q (%v)\n", decrypted, err)
+
+	nonResidue := new(big.Int).Sub(priv.PublicKey.N, big.NewInt(1))
+	_, err = rabin.DecryptRoots(nonResidue)
+	fmt.Printf("Расшифрование невычета n - 1: %v\n\n", err)
+
+	fmt.Println("Вероятностное шифрование Гольдвассер-Микали")
+
+	gm := NewGoldwasserMicaliService(TestMillerRabin, 0.9999, 512)
+	if err := gm.GenerateKeys(); err != nil {
+		fmt.Printf("Ошибка генерации ключей: %v\n", err)
+		return
+	}
+	gmPub := gm.GetPublicKey()
+	fmt.Printf("Псевдоквадрат x: символ Якоби (x/n) = %d\n", NewMathService().JacobiSymbol(gmPub.X, gmPub.N))
+
+	bits, _ := gm.Encrypt([]byte("GM"))
+	plain, err := gm.Decrypt(bits)
+	fmt.Printf("Шифрование \"GM\": %d шифротекстов -> %q (%v)\n", len(bits), plain, err)
+
+	zero1, _ := gm.EncryptBit(0)
+	zero2, _ := gm.EncryptBit(0)
+	fmt.Printf("Два шифротекста нуля различны: %v\n", zero1.Cmp(zero2) != 0)
+
+	one, _ := gm.EncryptBit(1)
+	for _, pair := range [][2]*big.Int{{zero1, zero2}, {zero1, one}, {one, one}} {
+		x, _ := gm.Xor(pair[0], pair[1])
+		b, _ := gm.DecryptBit(x)
+		fmt.Printf("  XOR по шифротекстам: %d\n", b)
+	}
+	fmt.Println()
+
+	fmt.Println("Генератор Блюма-Блюма-Шуба")
+
+	bbs, err := GenerateBlumBlumShub(TestMillerRabin, 0.9999, 512)
+	if err != nil {
+		fmt.Printf("Ошибка создания генератора: %v\n", err)
+		return
+	}
+	fmt.Printf("Модуль %d бит, %d бит на шаг\n", bbs.Modulus().BitLen(), bbs.BitsPerStep())
+	stream := make([]byte, 16)
+	io.ReadFull(bbs, stream)
+	fmt.Printf("16 байт: %x\n", stream)
+
+	sample, _ := rand.Int(bbs, big.NewInt(1000000))
+	fmt.Printf("rand.Int(bbs, 10^6) = %s\n", sample)
+
+	// Одинаковое начальное значение дает одинаковую последовательность
+	seed := big.NewInt(2718281828)
+	first, _ := NewBlumBlumShub(bbs.Modulus(), seed)
+	second, _ := NewBlumBlumShub(bbs.Modulus(), seed)
+	a, b := make([]byte, 32), make([]byte, 32)
+	first.Read(a)
+	second.Read(b)
+	fmt.Printf("Воспроизводимость по seed: %v\n", bytes.Equal(a, b))
+
+	_, err = NewBlumBlumShub(big.NewInt(3*7*11), big.NewInt(2))
+	fmt.Printf("Модуль 231 (не число Блюма): %v\n\n", err)
+}
+
+// demonstrateByteEncryption шифрует текст длиннее модуля поблочно и файл
+// в потоковом режиме
+func demonstrateByteEncryption(rsaService *RSAService) {
+	fmt.Println("Шифрование байтовых сообщений")
+
+	text := []byte(strings.Repeat("Сообщение длиннее модуля RSA делится на блоки OAEP. ", 6))
+	chunkSize, err := rsaService.ChunkSize()
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	ciphertext, err := rsaService.EncryptBytes(text)
+	if err != nil {
+		fmt.Printf("Ошибка шифрования: %v\n", err)
+		return
+	}
+	k := rsaService.GetPublicKey().modulusLen()
+	fmt.Printf("Сообщение %d байт, блок до %d байт: %d блоков по %d байт\n",
+		len(text), chunkSize, (len(ciphertext)-len(chunkedMagic)-2)/k, k)
+
+	plain, err := rsaService.DecryptBytes(ciphertext)
+	fmt.Printf("Расшифровано совпадает: %v (%v)\n", bytes.Equal(plain, text), err)
+
+	// Перестановка двух блоков и отбрасывание последнего блока обнаруживаются
+	swapped := append([]byte(nil), ciphertext...)
+	first := swapped[len(chunkedMagic)+2:]
+	tmp := append([]byte(nil), first[:k]...)
+	copy(first[:k], first[k:2*k])
+	copy(first[k:2*k], tmp)
+	_, err = rsaService.DecryptBytes(swapped)
+	fmt.Printf("Переставленные блоки: %v\n", err)
+	_, err = rsaService.DecryptBytes(ciphertext[:len(ciphertext)-k])
+	fmt.Printf("Без последнего блока: %v\n", err)
+
+	dir, err := os.MkdirTemp("", "rsa-chunked")
+	if err != nil {
+		fmt.Printf("Ошибка: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+	input := filepath.Join(dir, "input.bin")
+	data := make([]byte, 100_000)
+	rand.Read(data)
+	os.WriteFile(input, data, 0600)
+
+	start := time.Now()
+	if err := rsaService.EncryptFileChunked(input, input+".rsa"); err != nil {
+		fmt.Printf("Ошибка шифрования файла: %v\n", err)
+		return
+	}
+	if err := rsaService.DecryptFileChunked(input+".rsa", input+".out"); err != nil {
+		fmt.Printf("Ошибка расшифрования файла: %v\n", err)
+		return
+	}
+	restored, _ := os.ReadFile(input + ".out")
+	info, _ := os.Stat(input + ".rsa")
+	fmt.Printf("Файл %d байт -> %d байт, восстановлен: %v (%v)\n\n",
+		len(data), info.Size(), bytes.Equal(restored, data), time.Since(start).Round(time.Millisecond))
+}
+
+// demonstrateBlinding показывает, что ослепление не меняет результат закрытой
+// операции, но делает ее вход непредсказуемым для противника
+func demonstrateBlinding(rsaService *RSAService) {
+	fmt.Println("Ослепление RSA")
+
+	pubKey := rsaService.GetPublicKey()
+	message := big.NewInt(31337)
+	ciphertext, err := rsaService.Encrypt(message)
+	if err != nil {
+		fmt.Printf("Ошибка шифрования: %v\n", err)
+		return
+	}
+
+	blinded, err := rsaService.Decrypt(ciphertext)
+	if err != nil {
+		fmt.Printf("Ошибка дешифрования: %v\n", err)
+		return
+	}
+	rsaService.SetBlinding(false)
+	plain, err := rsaService.Decrypt(ciphertext)
+	rsaService.SetBlinding(true)
+	if err != nil {
+		fmt.Printf("Ошибка дешифрования: %v\n", err)
+		return
+	}
+	fmt.Printf("С ослеплением: %s, без ослепления: %s\n", blinded, plain)
+
+	// Одинаковые запросы подписи обрабатываются с разными внутренними входами
+	first, _ := rsaService.Sign(message)
+	second, _ := rsaService.Sign(message)
+	valid, _ := rsaService.Verify(message, first)
+	fmt.Printf("Подписи одного сообщения совпадают: %v, подпись верна: %v (модуль %d бит)\n\n",
+		first.Cmp(second) == 0, valid, pubKey.N.BitLen())
+}
+
+// demonstratePrimalityBounds показывает для каждого теста число раундов,
+// использованные основания и достигнутую оценку вероятности ошибки
+func demonstratePrimalityBounds(ms *MathService) {
+	fmt.Println("Оценки ошибки тестов простоты (минимальная вероятность 0.999999)")
+
+	tests := []struct {
+		name string
+		test PrimalityTest
+	}{
+		{"Ферма", NewFermatTest(ms)},
+		{"Соловея-Штрассена", NewSolovayStrassenTest(ms)},
+		{"Миллера-Рабина", NewMillerRabinTest(ms)},
+		{"Детерминированный MR", NewDeterministicMillerRabinTest(ms)},
+		{"Люка", NewStrongLucasTest(ms)},
+		{"BPSW", NewBPSWTest(ms)},
+	}
+	numbers := []struct {
+		value string
+		note  string
+	}{
+		{"170141183460469231731687303715884105727", "простое 2^127-1"},
+		{"3215031751", "Кармайкла 151*751*28351"},
+	}
+
+	for _, num := range numbers {
+		n, _ := new(big.Int).SetString(num.value, 10)
+		fmt.Printf("  n = %s (%s)\n", n, num.note)
+		for _, t := range tests {
+			result, err := t.test.Test(n, 0.999999)
+			if err != nil {
+				fmt.Printf("    %-22s ошибка: %v\n", t.name, err)
+				continue
+			}
+			bound := "-"
+			if result.Guarantee == GuaranteeProbabilistic {
+				bound = fmt.Sprintf("%.1e", result.ErrorBound)
+			}
+			fmt.Printf("    %-22s простое: %-5v раундов: %-2d оснований: %-2d ошибка: %-7s (%s)\n",
+				t.name, result.Probable, result.Rounds, len(result.Witnesses), bound, result.Guarantee)
+		}
+	}
+
+	_, err := NewMillerRabinTest(ms).Test(big.NewInt(97), 1)
+	fmt.Printf("  Недопустимая вероятность 1: %v\n", err)
+	fmt.Printf("  IsProbablyPrime(97, 1) с вероятностью по умолчанию: %v\n\n",
+		NewMillerRabinTest(ms).IsProbablyPrime(big.NewInt(97), 1))
+}
+
+// demonstratePseudoprimes прогоняет числа Кармайкла и сильные псевдопростые
+// по основанию 2 через все тесты простоты
+func demonstratePseudoprimes(ms *MathService) {
+	fmt.Println("Числа Кармайкла и сильные псевдопростые")
+
+	numbers := []struct {
+		value string
+		note  string
+	}{
+		{"561", "Кармайкла"},
+		{"41041", "Кармайкла"},
+		{"825265", "Кармайкла"},
+		{"2047", "spsp(2)"},
+		{"3277", "spsp(2)"},
+		{"3215031751", "Кармайкла, spsp(2,3,5,7)"},
+		{"3825123056546413051", "spsp(2..23)"},
+		{"318665857834031151167461", "spsp(2..37)"},
+		{"170141183460469231731687303715884105727", "простое 2^127-1"},
+	}
+
+	base := NewBasePrimalityTest(ms, "base-2")
+	two := big.NewInt(2)
+	tests := []PrimalityTest{
+		NewStrongLucasTest(ms),
+		NewBPSWTest(ms),
+		NewDeterministicMillerRabinTest(ms),
+	}
+
+	fmt.Printf("  %-40s %-8s %-6s %-6s %-6s %-6s %s\n", "n", "Ферма-2", "MR-2", "Люка", "BPSW", "ДетMR", "")
+	for _, num := range numbers {
+		n, _ := new(big.Int).SetString(num.value, 10)
+		nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+		fermat2 := ms.ModPow(two, nMinus1, n).Cmp(big.NewInt(1)) == 0
+
+		fmt.Printf("  %-40s %-8v %-6v", n, fermat2, base.strongProbablePrime(n, two))
+		for _, t := range tests {
+			fmt.Printf(" %-6v", t.IsProbablyPrime(n, 0.9999))
+		}
+		fmt.Printf(" %s\n", num.note)
+	}
+	fmt.Println()
+}
+
+// demonstratePrimeGeneration генерирует простые числа каждым из тестов простоты
+// и показывает, сколько работы отсекает решето малых простых
+func demonstratePrimeGeneration(ms *MathService) {
+	fmt.Println("Генерация простых чисел (решето + тест простоты)")
+
+	tests := []struct {
+		name     string
+		testType PrimalityTestType
+	}{
+		{"Ферма", TestFermat},
+		{"Соловея-Штрассена", TestSolovayStrassen},
+		{"Миллера-Рабина", TestMillerRabin},
+		{"Люка", TestStrongLucas},
+		{"BPSW", TestBPSW},
+		{"детерм. М-Р", TestDeterministicMillerRabin},
+	}
+
+	for _, t := range tests {
+		kg := NewRSAKeyGenerator(t.testType, 0.9999, 512, ms)
+		prime, stats, err := kg.GeneratePrime()
+		if err != nil {
+			fmt.Printf("Ошибка генерации: %v\n", err)
+			return
+		}
+		fmt.Printf("  %-18s: %d бит, кандидатов %d, отсеяно решетом %d (%.0f%%), вызовов теста %d, %v, ProbablyPrime(20): %v\n",
+			t.name, prime.BitLen(), stats.Candidates, stats.SieveRejections,
+			100*float64(stats.SieveRejections)/float64(stats.Candidates),
+			stats.TestCalls, stats.Duration, prime.ProbablyPrime(20))
+	}
+	fmt.Println()
+}
+
+// demonstrateParallelPrimeSearch сравнивает поиск простых в одном и нескольких
+// потоках и прерывает генерацию большого ключа по таймауту
+func demonstrateParallelPrimeSearch(ms *MathService) {
+	fmt.Println("Параллельный поиск простых чисел (1024 бит)")
+
+	for _, workers := range []int{1, 4} {
+		kg := NewRSAKeyGenerator(TestMillerRabin, 0.9999, 1024, ms)
+		kg.SetWorkers(workers)
+		prime, stats, err := kg.GeneratePrime()
+		if err != nil {
+			fmt.Printf("Ошибка генерации: %v\n", err)
+			return
+		}
+		fmt.Printf("  потоков %d: %d бит, баз %d, кандидатов %d, вызовов теста %d, %v\n",
+			stats.Workers, prime.BitLen(), stats.Bases, stats.Candidates, stats.TestCalls, stats.Duration)
+	}
+
+	rsa := NewRSAService(TestMillerRabin, 0.9999, 4096)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err := rsa.GenerateKeysContext(ctx)
+	fmt.Printf("  генерация ключей RSA (простые по 4096 бит) с таймаутом 100 мс: %v\n\n", err)
+}
+
+// demonstrateProvableKeys генерирует ключ RSA из доказуемо простых чисел и
+// проверяет сертификаты Поклингтона
+func demonstrateProvableKeys() {
+	fmt.Println("RSA из доказуемо простых чисел")
+
+	rs := NewRSAService(TestMillerRabin, 0.9999, 512)
+	rs.SetProvablePrimes(true)
+
+	start := time.Now()
+	if err := rs.GenerateKeys(); err != nil {
+		fmt.Printf("Ошибка генерации ключей: %v\n", err)
+		return
+	}
+	fmt.Printf("  Ключ %d бит сгенерирован за %v\n", rs.GetPublicKey().N.BitLen(), time.Since(start))
+
+	priv := rs.GetPrivateKey()
+	certs := rs.PrimeCertificates()
+	for i, prime := range []*big.Int{priv.P, priv.Q} {
+		fmt.Printf("  Сертификат %c: звеньев %d, проверка: %v\n",
+			"pq"[i], certs[i].Depth(), primes.VerifyCertificate(prime, certs[i]))
+	}
+	fmt.Printf("  Сертификат p для q: %v\n\n", primes.VerifyCertificate(priv.Q, certs[0]))
+}
+
+// demonstrateRSAPadding шифрует байтовые сообщения с OAEP и PKCS#1 v1.5 и
+// проверяет совместимость с crypto/rsa в обе стороны
+func demonstrateRSAPadding(rsaService *RSAService) {
+	fmt.Println("RSA-OAEP и PKCS#1 v1.5")
+
+	stdPriv, err := rsaService.GetPrivateKey().ToStdlib()
+	if err != nil {
+		fmt.Printf("Ошибка экспорта ключа: %v\n", err)
+		return
+	}
+
+	message := []byte("byte-oriented RSA message")
+	label := []byte("lab_2")
+
+	// OAEP: наше шифрование -> crypto/rsa
+	ciphertext, err := rsaService.EncryptOAEP(sha256.New(), message, label)
+	if err != nil {
+		fmt.Printf("Ошибка OAEP шифрования: %v\n", err)
+		return
+	}
+	stdPlain, err := rsa.DecryptOAEP(sha256.New(), nil, stdPriv, ciphertext, label)
+	fmt.Printf("OAEP SHA-256: расшифровано crypto/rsa: %v (%v)\n", string(stdPlain) == string(message), err)
+
+	// OAEP: crypto/rsa -> наше расшифрование
+	stdCiphertext, err := rsa.EncryptOAEP(sha512.New384(), rand.Reader, &stdPriv.PublicKey, message, label)
+	if err != nil {
+		fmt.Printf("Ошибка OAEP шифрования crypto/rsa: %v\n", err)
+		return
+	}
+	plain, err := rsaService.DecryptOAEP(sha512.New384(), stdCiphertext, label)
+	fmt.Printf("OAEP SHA-384: шифротекст crypto/rsa расшифрован: %v (%v)\n", string(plain) == string(message), err)
+
+	_, err = rsaService.DecryptOAEP(sha512.New384(), stdCiphertext, []byte("wrong label"))
+	fmt.Printf("OAEP с неверной меткой: %v\n", err)
+
+	// PKCS#1 v1.5 в обе стороны
+	ciphertext, err = rsaService.EncryptPKCS1v15(message)
+	if err != nil {
+		fmt.Printf("Ошибка PKCS#1 v1.5 шифрования: %v\n", err)
+		return
+	}
+	stdPlain, err = rsa.DecryptPKCS1v15(nil, stdPriv, ciphertext)
+	fmt.Printf("PKCS#1 v1.5: расшифровано crypto/rsa: %v (%v)\n", string(stdPlain) == string(message), err)
+
+	stdCiphertext, err = rsa.EncryptPKCS1v15(rand.Reader, &stdPriv.PublicKey, message)
+	if err != nil {
+		fmt.Printf("Ошибка PKCS#1 v1.5 шифрования crypto/rsa: %v\n", err)
+		return
+	}
+	plain, err = rsaService.DecryptPKCS1v15(stdCiphertext)
+	fmt.Printf("PKCS#1 v1.5: шифротекст crypto/rsa расшифрован: %v (%v)\n", string(plain) == string(message), err)
+
+	stdCiphertext[len(stdCiphertext)-1] ^= 1
+	_, err = rsaService.DecryptPKCS1v15(stdCiphertext)
+	fmt.Printf("PKCS#1 v1.5 с искаженным шифротекстом: %v\n\n", err)
+}
+
+// demonstrateRSASignatures подписывает данные PSS и PKCS#1 v1.5 и проверяет
+// подписи перекрестно с crypto/rsa
+func demonstrateRSASignatures(rsaService *RSAService) {
+	fmt.Println("Подписи RSA (PSS и PKCS#1 v1.5)")
+
+	stdPriv, err := rsaService.GetPrivateKey().ToStdlib()
+	if err != nil {
+		fmt.Printf("Ошибка экспорта ключа: %v\n", err)
+		return
+	}
+
+	data := []byte("release artifact v1.0.0")
+	for _, hash := range []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512} {
+		h := hash.New()
+		h.Write(data)
+		digest := h.Sum(nil)
+
+		sig, err := rsaService.SignPKCS1v15(hash, digest)
+		if err != nil {
+			fmt.Printf("Ошибка подписи PKCS#1 v1.5: %v\n", err)
+			return
+		}
+		stdErr := rsa.VerifyPKCS1v15(&stdPriv.PublicKey, hash, digest, sig)
+		stdSig, _ := rsa.SignPKCS1v15(nil, stdPriv, hash, digest)
+		ownErr := rsaService.VerifyPKCS1v15(hash, digest, stdSig)
+		fmt.Printf("  PKCS#1 v1.5 %v: crypto/rsa принимает нашу: %v, мы принимаем crypto/rsa: %v, подписи совпадают: %v\n",
+			hash, stdErr == nil, ownErr == nil, string(sig) == string(stdSig))
+
+		sig, err = rsaService.SignPSS(hash, digest)
+		if err != nil {
+			fmt.Printf("Ошибка подписи PSS: %v\n", err)
+			return
+		}
+		stdErr = rsa.VerifyPSS(&stdPriv.PublicKey, hash, digest, sig, nil)
+		stdSig, _ = rsa.SignPSS(rand.Reader, stdPriv, hash, digest, nil)
+		ownErr = rsaService.VerifyPSS(hash, digest, stdSig)
+		fmt.Printf("  PSS %v: crypto/rsa принимает нашу: %v, мы принимаем crypto/rsa: %v\n",
+			hash, stdErr == nil, ownErr == nil)
+	}
+
+	// Потоковая подпись файла
+	artifact, err := os.CreateTemp("", "artifact-*.bin")
+	if err != nil {
+		fmt.Printf("Ошибка создания файла: %v\n", err)
+		return
+	}
+	defer os.Remove(artifact.Name())
+	artifact.Write(make([]byte, 1<<20))
+	artifact.Close()
+
+	signaturePath := artifact.Name() + ".sig"
+	defer os.Remove(signaturePath)
+	if err := rsaService.SignFile(SchemePSS, crypto.SHA256, artifact.Name(), signaturePath); err != nil {
+		fmt.Printf("Ошибка подписи файла: %v\n", err)
+		return
+	}
+	fmt.Printf("  Подпись файла (1 МБ) верна: %v\n", rsaService.VerifyFile(SchemePSS, crypto.SHA256, artifact.Name(), signaturePath) == nil)
+
+	os.WriteFile(artifact.Name(), []byte("tampered"), 0644)
+	fmt.Printf("  Подпись измененного файла: %v\n\n", rsaService.VerifyFile(SchemePSS, crypto.SHA256, artifact.Name(), signaturePath))
+}
+
+// demonstrateKeySerialization сохраняет ключи в DER/PEM/JWK и сверяет
+// результат с crypto/x509
+func demonstrateKeySerialization(rsaService *RSAService) {
+	fmt.Println("Сериализация ключей RSA")
+
+	pub := rsaService.GetPublicKey()
+	priv := rsaService.GetPrivateKey()
+	stdPriv, err := priv.ToStdlib()
+	if err != nil {
+		fmt.Printf("Ошибка экспорта ключа: %v\n", err)
+		return
+	}
+
+	// DER: наш вывод совпадает побайтно с crypto/x509
+	pkcs1Priv, _ := MarshalPKCS1PrivateKey(priv)
+	pkcs8Priv, _ := MarshalPKCS8PrivateKey(priv)
+	pkcs1Pub, _ := MarshalPKCS1PublicKey(pub)
+	spkiPub, _ := MarshalPKIXPublicKey(pub)
+	stdPKCS8, _ := x509.MarshalPKCS8PrivateKey(stdPriv)
+	stdSPKI, _ := x509.MarshalPKIXPublicKey(&stdPriv.PublicKey)
+
+	fmt.Printf("  PKCS#1 закрытый ключ совпадает с x509: %v\n", string(pkcs1Priv) == string(x509.MarshalPKCS1PrivateKey(stdPriv)))
+	fmt.Printf("  PKCS#8 закрытый ключ совпадает с x509: %v\n", string(pkcs8Priv) == string(stdPKCS8))
+	fmt.Printf("  PKCS#1 открытый ключ совпадает с x509: %v\n", string(pkcs1Pub) == string(x509.MarshalPKCS1PublicKey(&stdPriv.PublicKey)))
+	fmt.Printf("  SPKI открытый ключ совпадает с x509: %v\n", string(spkiPub) == string(stdSPKI))
+
+	// Разбор DER, созданного crypto/x509
+	parsedPriv, err := ParsePKCS8PrivateKey(stdPKCS8)
+	fmt.Printf("  Разбор PKCS#8 из x509: %v (%v)\n", err == nil && parsedPriv.D.Cmp(priv.D) == 0, err)
+	parsedPub, err := ParsePKIXPublicKey(stdSPKI)
+	fmt.Printf("  Разбор SPKI из x509: %v (%v)\n", err == nil && parsedPub.N.Cmp(pub.N) == 0, err)
+
+	// PEM с паролем
+	passphrase := []byte("correct horse battery staple")
+	encryptedPEM, err := EncodePrivateKeyPEM(priv, FormatPKCS8, passphrase)
+	if err != nil {
+		fmt.Printf("Ошибка шифрования ключа: %v\n", err)
+		return
+	}
+	decrypted, err := DecodePrivateKeyPEM(encryptedPEM, passphrase)
+	fmt.Printf("  Зашифрованный PEM восстановлен: %v (%v)\n", err == nil && decrypted.D.Cmp(priv.D) == 0, err)
+	_, err = DecodePrivateKeyPEM(encryptedPEM, []byte("wrong"))
+	fmt.Printf("  Неверный пароль: %v\n", err)
+
+	publicPEM, _ := EncodePublicKeyPEM(pub, FormatPKCS8)
+	fmt.Printf("%s", publicPEM)
+
+	// JWK
+	jwkData, _ := priv.MarshalJWK()
+	jwkPub, jwkPriv, err := ParseJWK(jwkData)
+	fmt.Printf("  JWK восстановлен: %v (%v)\n\n", err == nil && jwkPub.E.Cmp(pub.E) == 0 && jwkPriv.Qinv.Cmp(priv.Qinv) == 0, err)
+}
+
+// demonstrateHybridEncryption шифрует файл гибридной схемой RSA-OAEP + AES-256-GCM
+func demonstrateHybridEncryption(rsaService *RSAService) {
+	fmt.Println("Гибридное шифрование RSA-OAEP + AES-256-GCM")
+
+	dir, err := os.MkdirTemp("", "hybrid")
+	if err != nil {
+		fmt.Printf("Ошибка создания каталога: %v\n", err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	plainPath := dir + "/message.txt"
+	encPath := dir + "/message.hyb"
+	decPath := dir + "/message.dec"
+	original := []byte(strings.Repeat("Гибридное шифрование больших файлов. ", 1000))
+	os.WriteFile(plainPath, original, 0644)
+
+	hybrid := NewHybridService(rsaService)
+	if err := hybrid.EncryptFile(plainPath, encPath); err != nil {
+		fmt.Printf("Ошибка шифрования: %v\n", err)
+		return
+	}
+	if err := hybrid.DecryptFile(encPath, decPath); err != nil {
+		fmt.Printf("Ошибка расшифрования: %v\n", err)
+		return
+	}
+
+	container, _ := os.ReadFile(encPath)
+	restored, _ := os.ReadFile(decPath)
+	fmt.Printf("  Размер файла: %d байт, контейнера: %d байт\n", len(original), len(container))
+	fmt.Printf("  Файл восстановлен: %v\n", bytes.Equal(original, restored))
+
+	// Повреждение шифротекста обнаруживается тегом GCM
+	container[len(container)-1] ^= 1
+	_, err = hybrid.Decrypt(container)
+	fmt.Printf("  Поврежденный контейнер: %v\n\n", err)
 }
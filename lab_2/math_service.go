@@ -1,8 +1,17 @@
 package main
 
-import "math/big"
+import (
+	"math/big"
+	"sync"
+)
 
-type MathService struct{}
+// maxCachedContexts ограничивает число хранимых контекстов Монтгомери
+const maxCachedContexts = 64
+
+type MathService struct {
+	contextsMu sync.Mutex
+	contexts   map[string]*MontgomeryContext // контексты Монтгомери по модулю
+}
 
 func NewMathService() *MathService {
 	return &MathService{}
@@ -104,21 +113,146 @@ func (ms *MathService) ExtendedGCD(a, b *big.Int) (*big.Int, *big.Int, *big.Int)
 	return oldR, oldS, oldT
 }
 
-// ModPow выполняет возведение в степень по модулю
+// BinaryGCD вычисляет НОД бинарным алгоритмом Штейна (сдвиги и вычитания без деления)
+func (ms *MathService) BinaryGCD(a, b *big.Int) *big.Int {
+	x := new(big.Int).Abs(a)
+	y := new(big.Int).Abs(b)
+	if x.Sign() == 0 {
+		return y
+	}
+	if y.Sign() == 0 {
+		return x
+	}
+
+	// Общая степень двойки
+	shift := x.TrailingZeroBits()
+	if tz := y.TrailingZeroBits(); tz < shift {
+		shift = tz
+	}
+
+	x.Rsh(x, x.TrailingZeroBits())
+	for y.Sign() != 0 {
+		y.Rsh(y, y.TrailingZeroBits())
+		if x.Cmp(y) > 0 {
+			x, y = y, x
+		}
+		y.Sub(y, x)
+	}
+	return x.Lsh(x, shift)
+}
+
+// MontgomeryContext возвращает предвычисленный контекст Монтгомери для нечетного
+// модуля m. Контексты кешируются, поэтому повторные операции по тому же модулю
+// (тесты простоты, RSA) не пересчитывают R^2 mod m.
+func (ms *MathService) MontgomeryContext(m *big.Int) (*MontgomeryContext, error) {
+	key := string(m.Bytes())
+
+	ms.contextsMu.Lock()
+	defer ms.contextsMu.Unlock()
+
+	if mc, ok := ms.contexts[key]; ok {
+		return mc, nil
+	}
+
+	mc, err := NewMontgomeryContext(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if ms.contexts == nil || len(ms.contexts) >= maxCachedContexts {
+		ms.contexts = make(map[string]*MontgomeryContext)
+	}
+	ms.contexts[key] = mc
+	return mc, nil
+}
+
+// ModPow выполняет возведение в степень по модулю для открытых показателей.
+// Используется big.Int.Exp (ассемблерное умножение Монтгомери с окном
+// фиксированной ширины): реализация на чистом Go в 1.5-3 раза медленнее,
+// см. BenchmarkModPow. Для exp <= 0 возвращает 1 mod m.
 func (ms *MathService) ModPow(base, exp, m *big.Int) *big.Int {
+	if m.Cmp(big.NewInt(1)) == 0 {
+		return big.NewInt(0)
+	}
+	if exp.Sign() <= 0 {
+		return big.NewInt(1)
+	}
+	return new(big.Int).Exp(base, exp, m)
+}
+
+// ModPowSlidingWindow возводит в степень собственным движком: скользящее окно
+// в форме Монтгомери для нечетного модуля и с обычным приведением для
+// четного. Число умножений зависит от битов показателя, поэтому функция
+// не подходит для секретных показателей (см. demonstrateTimingLeak).
+func (ms *MathService) ModPowSlidingWindow(base, exp, m *big.Int) *big.Int {
+	if m.Cmp(big.NewInt(1)) == 0 {
+		return big.NewInt(0)
+	}
+
+	if m.Bit(0) == 1 {
+		mc, err := ms.MontgomeryContext(m)
+		if err == nil {
+			return mc.Exp(base, exp)
+		}
+	}
+	return ms.slidingWindowModPow(base, exp, m)
+}
+
+// ModPowConstantTime возводит в степень по нечетному модулю m лестницей
+// Монтгомери с фиксированным числом шагов, равным длине модуля. Предназначена
+// для секретных показателей (закрытый ключ RSA): время вычисления не зависит
+// от битов exp. Показатель должен быть меньше 2^m.BitLen().
+func (ms *MathService) ModPowConstantTime(base, exp, m *big.Int) (*big.Int, error) {
+	mc, err := ms.MontgomeryContext(m)
+	if err != nil {
+		return nil, err
+	}
+	return mc.ExpConstantTime(base, exp, m.BitLen())
+}
+
+// slidingWindowModPow возведение в степень скользящим окном без формы Монтгомери
+func (ms *MathService) slidingWindowModPow(base, exp, m *big.Int) *big.Int {
 	result := big.NewInt(1)
-	base = new(big.Int).Mod(base, m)
-	e := new(big.Int).Set(exp)
-	b := new(big.Int).Set(base)
+	if exp.Sign() <= 0 {
+		return result.Mod(result, m)
+	}
+
+	b := new(big.Int).Mod(base, m)
+	k := slidingWindowSize(exp.BitLen())
+	table := make([]*big.Int, 1<<uint(k-1))
+	table[0] = b
+	b2 := new(big.Int).Mul(b, b)
+	b2.Mod(b2, m)
+	for i := 1; i < len(table); i++ {
+		table[i] = new(big.Int).Mul(table[i-1], b2)
+		table[i].Mod(table[i], m)
+	}
+
+	for i := exp.BitLen() - 1; i >= 0; {
+		if exp.Bit(i) == 0 {
+			result.Mul(result, result)
+			result.Mod(result, m)
+			i--
+			continue
+		}
+
+		j := i - k + 1
+		if j < 0 {
+			j = 0
+		}
+		for exp.Bit(j) == 0 {
+			j++
+		}
 
-	for e.Sign() > 0 {
-		if new(big.Int).Mod(e, big.NewInt(2)).Cmp(big.NewInt(1)) == 0 {
-			result.Mul(result, b)
+		value := 0
+		for bit := i; bit >= j; bit-- {
+			result.Mul(result, result)
 			result.Mod(result, m)
+			value = value<<1 | int(exp.Bit(bit))
 		}
-		e.Div(e, big.NewInt(2))
-		b.Mul(b, b)
-		b.Mod(b, m)
+		result.Mul(result, table[value>>1])
+		result.Mod(result, m)
+		i = j - 1
 	}
 	return result
 }
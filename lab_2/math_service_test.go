@@ -0,0 +1,78 @@
+package main
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"testing"
+)
+
+func TestModPowMatchesExp(t *testing.T) {
+	ms := NewMathService()
+	for _, bits := range []int{64, 512, 1024} {
+		m, err := rand.Prime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		even := new(big.Int).Add(m, big.NewInt(1))
+		for i := 0; i < 8; i++ {
+			base, _ := rand.Int(rand.Reader, m)
+			exp, _ := rand.Int(rand.Reader, m)
+			for _, mod := range []*big.Int{m, even} {
+				want := new(big.Int).Exp(base, exp, mod)
+				if got := ms.ModPow(base, exp, mod); got.Cmp(want) != 0 {
+					t.Fatalf("ModPow(%s, %s, %s) = %s, want %s", base, exp, mod, got, want)
+				}
+				if got := ms.ModPowSlidingWindow(base, exp, mod); got.Cmp(want) != 0 {
+					t.Fatalf("ModPowSlidingWindow(%s, %s, %s) = %s, want %s", base, exp, mod, got, want)
+				}
+			}
+			ct, err := ms.ModPowConstantTime(base, exp, m)
+			if err != nil || ct.Cmp(new(big.Int).Exp(base, exp, m)) != 0 {
+				t.Fatalf("ModPowConstantTime mismatch: %v", err)
+			}
+		}
+	}
+
+	if got := ms.ModPow(big.NewInt(5), big.NewInt(0), big.NewInt(7)); got.Cmp(big.NewInt(1)) != 0 {
+		t.Errorf("x^0 mod 7 = %s, want 1", got)
+	}
+	if got := ms.ModPow(big.NewInt(5), big.NewInt(3), big.NewInt(1)); got.Sign() != 0 {
+		t.Errorf("x^3 mod 1 = %s, want 0", got)
+	}
+}
+
+func benchmarkModPow(b *testing.B, bits int, op func(ms *MathService, base, exp, m *big.Int)) {
+	ms := NewMathService()
+	m, err := rand.Prime(rand.Reader, bits)
+	if err != nil {
+		b.Fatal(err)
+	}
+	base, _ := rand.Int(rand.Reader, m)
+	exp, _ := rand.Int(rand.Reader, m)
+	op(ms, base, exp, m) // прогрев кеша контекстов Монтгомери
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		op(ms, base, exp, m)
+	}
+}
+
+func BenchmarkModPow(b *testing.B) {
+	ops := []struct {
+		name string
+		op   func(ms *MathService, base, exp, m *big.Int)
+	}{
+		{"ModPow", func(ms *MathService, base, exp, m *big.Int) { ms.ModPow(base, exp, m) }},
+		{"BigExp", func(_ *MathService, base, exp, m *big.Int) { new(big.Int).Exp(base, exp, m) }},
+		{"SlidingWindow", func(ms *MathService, base, exp, m *big.Int) { ms.ModPowSlidingWindow(base, exp, m) }},
+		{"ConstantTime", func(ms *MathService, base, exp, m *big.Int) { ms.ModPowConstantTime(base, exp, m) }},
+	}
+	for _, bits := range []int{512, 1024, 2048} {
+		for _, o := range ops {
+			b.Run(fmt.Sprintf("%s/%d", o.name, bits), func(b *testing.B) {
+				benchmarkModPow(b, bits, o.op)
+			})
+		}
+	}
+}
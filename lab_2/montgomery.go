@@ -0,0 +1,268 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+	"math/bits"
+)
+
+// MontgomeryContext предвычисленные параметры арифметики Монтгомери для
+// фиксированного нечетного модуля. Числа хранятся в виде машинных слов
+// (little-endian), R = 2^(wordBits * len(n)).
+type MontgomeryContext struct {
+	modulus *big.Int
+	n       []big.Word // слова модуля
+	n0inv   big.Word   // -n^(-1) mod 2^wordBits
+	rr      []big.Word // R^2 mod n
+	one     []big.Word // R mod n (единица в форме Монтгомери)
+}
+
+// NewMontgomeryContext строит контекст Монтгомери для нечетного модуля m > 1
+func NewMontgomeryContext(m *big.Int) (*MontgomeryContext, error) {
+	if m.Sign() <= 0 || m.Bit(0) == 0 || m.Cmp(big.NewInt(1)) == 0 {
+		return nil, errors.New("montgomery modulus must be odd and greater than 1")
+	}
+
+	n := append([]big.Word(nil), m.Bits()...)
+	s := len(n)
+
+	// Обратный элемент по модулю 2^wordBits методом Ньютона: каждая итерация
+	// удваивает число верных бит, начиная с трех
+	inv := n[0]
+	for i := 0; i < 6; i++ {
+		inv *= 2 - n[0]*inv
+	}
+
+	r := new(big.Int).Lsh(big.NewInt(1), uint(s*bits.UintSize))
+	rMod := new(big.Int).Mod(r, m)
+	rrMod := new(big.Int).Mul(rMod, rMod)
+	rrMod.Mod(rrMod, m)
+
+	return &MontgomeryContext{
+		modulus: new(big.Int).Set(m),
+		n:       n,
+		n0inv:   -inv,
+		rr:      toWords(rrMod, s),
+		one:     toWords(rMod, s),
+	}, nil
+}
+
+// Modulus возвращает копию модуля контекста
+func (mc *MontgomeryContext) Modulus() *big.Int {
+	return new(big.Int).Set(mc.modulus)
+}
+
+// toWords переводит неотрицательное число, меньшее модуля, в срез из s слов
+func toWords(x *big.Int, s int) []big.Word {
+	z := make([]big.Word, s)
+	copy(z, x.Bits())
+	return z
+}
+
+// fromWords переводит срез слов в big.Int
+func fromWords(z []big.Word) *big.Int {
+	return new(big.Int).SetBits(append([]big.Word(nil), z...))
+}
+
+// reduce приводит произвольное число по модулю и переводит в слова
+func (mc *MontgomeryContext) reduce(x *big.Int) []big.Word {
+	r := new(big.Int).Mod(x, mc.modulus)
+	return toWords(r, len(mc.n))
+}
+
+// mulAddWWW возвращает x*y + c + acc в виде (старшее, младшее) слово
+func mulAddWWW(x, y, c, acc big.Word) (big.Word, big.Word) {
+	hi, lo := bits.Mul(uint(x), uint(y))
+	var carry uint
+	lo, carry = bits.Add(lo, uint(acc), 0)
+	hi += carry
+	lo, carry = bits.Add(lo, uint(c), 0)
+	hi += carry
+	return big.Word(hi), big.Word(lo)
+}
+
+// montMul вычисляет z = x*y*R^(-1) mod n по схеме CIOS. t - рабочий буфер длиной len(n)+2.
+func (mc *MontgomeryContext) montMul(z, x, y, t []big.Word) {
+	s := len(mc.n)
+	for i := range t {
+		t[i] = 0
+	}
+
+	for i := 0; i < s; i++ {
+		var c big.Word
+		for j := 0; j < s; j++ {
+			c, t[j] = mulAddWWW(x[j], y[i], c, t[j])
+		}
+		sum, carry := bits.Add(uint(t[s]), uint(c), 0)
+		t[s] = big.Word(sum)
+		t[s+1] = big.Word(carry)
+
+		m := t[0] * mc.n0inv
+		c, _ = mulAddWWW(m, mc.n[0], 0, t[0])
+		for j := 1; j < s; j++ {
+			c, t[j-1] = mulAddWWW(m, mc.n[j], c, t[j])
+		}
+		sum, carry = bits.Add(uint(t[s]), uint(c), 0)
+		t[s-1] = big.Word(sum)
+		t[s] = t[s+1] + big.Word(carry)
+	}
+
+	// Результат меньше 2n: достаточно одного вычитания. Вычитание выполняется
+	// всегда, а нужный вариант выбирается маской, чтобы время умножения
+	// не зависело от значений операндов.
+	var borrow uint
+	for j := 0; j < s; j++ {
+		var d uint
+		d, borrow = bits.Sub(uint(t[j]), uint(mc.n[j]), borrow)
+		z[j] = big.Word(d)
+	}
+	_, borrow = bits.Sub(uint(t[s]), 0, borrow)
+
+	// borrow = 1 означает t < n: результатом остается t
+	keep := -big.Word(borrow)
+	for j := 0; j < s; j++ {
+		z[j] = t[j]&keep | z[j]&^keep
+	}
+}
+
+// MulMod вычисляет a*b mod n через умножение Монтгомери
+func (mc *MontgomeryContext) MulMod(a, b *big.Int) *big.Int {
+	s := len(mc.n)
+	t := make([]big.Word, s+2)
+	x := mc.reduce(a)
+	y := mc.reduce(b)
+
+	// (a*b*R^-1) * R^2 * R^-1 = a*b
+	mc.montMul(x, x, y, t)
+	mc.montMul(x, x, mc.rr, t)
+	return fromWords(x)
+}
+
+// slidingWindowSize подбирает ширину окна по длине показателя
+func slidingWindowSize(expBits int) int {
+	switch {
+	case expBits > 768:
+		return 6
+	case expBits > 256:
+		return 5
+	case expBits > 80:
+		return 4
+	case expBits > 24:
+		return 3
+	case expBits > 6:
+		return 2
+	default:
+		return 1
+	}
+}
+
+// Exp вычисляет base^exp mod n возведением в степень со скользящим окном
+// в форме Монтгомери. Для exp <= 0 возвращает 1.
+func (mc *MontgomeryContext) Exp(base, exp *big.Int) *big.Int {
+	s := len(mc.n)
+	t := make([]big.Word, s+2)
+
+	if exp.Sign() <= 0 {
+		return fromWords(mc.fromMontgomery(mc.one, t))
+	}
+
+	// Перевод основания в форму Монтгомери: base * R^2 * R^-1 = base * R
+	g := mc.reduce(base)
+	mc.montMul(g, g, mc.rr, t)
+
+	// Таблица нечетных степеней g, g^3, ..., g^(2^k - 1)
+	k := slidingWindowSize(exp.BitLen())
+	table := make([][]big.Word, 1<<uint(k-1))
+	table[0] = g
+	if len(table) > 1 {
+		g2 := make([]big.Word, s)
+		mc.montMul(g2, g, g, t)
+		for i := 1; i < len(table); i++ {
+			table[i] = make([]big.Word, s)
+			mc.montMul(table[i], table[i-1], g2, t)
+		}
+	}
+
+	result := append([]big.Word(nil), mc.one...)
+	for i := exp.BitLen() - 1; i >= 0; {
+		if exp.Bit(i) == 0 {
+			mc.montMul(result, result, result, t)
+			i--
+			continue
+		}
+
+		// Самое длинное окно не шире k, заканчивающееся единичным битом
+		j := i - k + 1
+		if j < 0 {
+			j = 0
+		}
+		for exp.Bit(j) == 0 {
+			j++
+		}
+
+		value := 0
+		for b := i; b >= j; b-- {
+			mc.montMul(result, result, result, t)
+			value = value<<1 | int(exp.Bit(b))
+		}
+		mc.montMul(result, result, table[value>>1], t)
+		i = j - 1
+	}
+
+	return fromWords(mc.fromMontgomery(result, t))
+}
+
+// ExpConstantTime вычисляет base^exp mod n лестницей Монтгомери: на каждом из
+// expBits шагов выполняются ровно одно умножение и одно возведение в квадрат,
+// а выбор регистров делается условной перестановкой по маске. Число операций
+// и последовательность обращений к памяти не зависят от битов показателя.
+// Показатель должен быть неотрицательным и иметь не более expBits бит;
+// для секретных показателей expBits берется по длине модуля, а не показателя.
+func (mc *MontgomeryContext) ExpConstantTime(base, exp *big.Int, expBits int) (*big.Int, error) {
+	if exp.Sign() < 0 || exp.BitLen() > expBits {
+		return nil, errors.New("exponent does not fit into the declared bit length")
+	}
+
+	s := len(mc.n)
+	t := make([]big.Word, s+2)
+
+	// r0 = 1, r1 = base в форме Монтгомери
+	r0 := append([]big.Word(nil), mc.one...)
+	r1 := mc.reduce(base)
+	mc.montMul(r1, r1, mc.rr, t)
+
+	// Слова показателя дополняются нулями до фиксированной длины
+	words := make([]big.Word, (expBits+bits.UintSize-1)/bits.UintSize)
+	copy(words, exp.Bits())
+
+	for i := expBits - 1; i >= 0; i-- {
+		bit := words[i/bits.UintSize] >> uint(i%bits.UintSize) & 1
+
+		// bit = 0: r1 = r0*r1, r0 = r0^2; bit = 1: r0 = r0*r1, r1 = r1^2
+		condSwap(r0, r1, bit)
+		mc.montMul(r1, r0, r1, t)
+		mc.montMul(r0, r0, r0, t)
+		condSwap(r0, r1, bit)
+	}
+
+	return fromWords(mc.fromMontgomery(r0, t)), nil
+}
+
+// condSwap меняет местами x и y, если bit = 1, без ветвлений по bit
+func condSwap(x, y []big.Word, bit big.Word) {
+	mask := -bit
+	for i := range x {
+		d := (x[i] ^ y[i]) & mask
+		x[i] ^= d
+		y[i] ^= d
+	}
+}
+
+// fromMontgomery переводит число из формы Монтгомери: x * R^-1
+func (mc *MontgomeryContext) fromMontgomery(x, t []big.Word) []big.Word {
+	one := make([]big.Word, len(mc.n))
+	one[0] = 1
+	z := make([]big.Word, len(mc.n))
+	mc.montMul(z, x, one, t)
+	return z
+}
@@ -0,0 +1,272 @@
+package main
+
+import (
+	"errors"
+	"math/big"
+)
+
+// PrimePower множитель p^k в разложении числа на простые
+type PrimePower struct {
+	Prime    *big.Int
+	Exponent int
+}
+
+// ModInverse возвращает a^(-1) mod m или ошибку, если НОД(a, m) != 1
+func (ms *MathService) ModInverse(a, m *big.Int) (*big.Int, error) {
+	if m.Sign() <= 0 {
+		return nil, errors.New("modulus must be positive")
+	}
+
+	gcd, x, _ := ms.ExtendedGCD(new(big.Int).Mod(a, m), m)
+	if gcd.Cmp(big.NewInt(1)) != 0 {
+		return nil, errors.New("value is not invertible modulo m")
+	}
+	return x.Mod(x, m), nil
+}
+
+// TonelliShanks вычисляет квадратный корень из a по нечетному простому модулю p
+// алгоритмом Тонелли-Шенкса. Возвращается меньший из двух корней; для
+// составного p поиск ограничен и завершается ошибкой.
+func (ms *MathService) TonelliShanks(a, p *big.Int) (*big.Int, error) {
+	a, done, err := ms.sqrtPrecheck(a, p)
+	if err != nil || done {
+		return a, err
+	}
+
+	one := big.NewInt(1)
+
+	// p = 3 (mod 4): корень равен a^((p+1)/4)
+	if p.Bit(1) == 1 {
+		exp := new(big.Int).Add(p, one)
+		exp.Rsh(exp, 2)
+		return checkedRoot(ms.ModPow(a, exp, p), a, p)
+	}
+
+	// p - 1 = q * 2^s, q нечетное
+	pMinus1 := new(big.Int).Sub(p, one)
+	s := pMinus1.TrailingZeroBits()
+	q := new(big.Int).Rsh(pMinus1, s)
+
+	// z - произвольный квадратичный невычет
+	z := big.NewInt(2)
+	for limit := nonResidueSearchLimit(p); ms.LegendreSymbol(z, p) != -1; limit-- {
+		if limit == 0 {
+			return nil, errModulusNotPrime
+		}
+		z.Add(z, one)
+	}
+
+	m := s
+	c := ms.ModPow(z, q, p)
+	t := ms.ModPow(a, q, p)
+	r := ms.ModPow(a, new(big.Int).Rsh(new(big.Int).Add(q, one), 1), p)
+
+	// Инвариант: r^2 = a*t, порядок t делит 2^(m-1)
+	for t.Cmp(one) != 0 {
+		// Наименьшее i с t^(2^i) = 1
+		i := uint(0)
+		for x := new(big.Int).Set(t); x.Cmp(one) != 0 && i < m; i++ {
+			x.Mul(x, x).Mod(x, p)
+		}
+		if i >= m {
+			return nil, errModulusNotPrime // для простого p порядок t делит 2^(m-1)
+		}
+
+		b := new(big.Int).Set(c)
+		for j := uint(0); j < m-i-1; j++ {
+			b.Mul(b, b).Mod(b, p)
+		}
+		m = i
+		c.Mul(b, b).Mod(c, p)
+		t.Mul(t, c).Mod(t, p)
+		r.Mul(r, b).Mod(r, p)
+	}
+	return checkedRoot(r, a, p)
+}
+
+// Cipolla вычисляет квадратный корень из a по нечетному простому модулю p
+// алгоритмом Чиполлы: для t с невычетом w = t^2 - a корень равен
+// (t + sqrt(w))^((p+1)/2) в поле F_p(sqrt(w)).
+func (ms *MathService) Cipolla(a, p *big.Int) (*big.Int, error) {
+	a, done, err := ms.sqrtPrecheck(a, p)
+	if err != nil || done {
+		return a, err
+	}
+
+	one := big.NewInt(1)
+	t := big.NewInt(1)
+	w := new(big.Int)
+	for limit := nonResidueSearchLimit(p); ; limit-- {
+		if limit == 0 {
+			return nil, errModulusNotPrime
+		}
+		w.Mul(t, t).Sub(w, a).Mod(w, p)
+		if ms.LegendreSymbol(w, p) == -1 {
+			break
+		}
+		t.Add(t, one)
+	}
+
+	// Элементы поля x + y*sqrt(w)
+	mul := func(x1, y1, x2, y2 *big.Int) (*big.Int, *big.Int) {
+		x := new(big.Int).Mul(x1, x2)
+		x.Add(x, new(big.Int).Mul(new(big.Int).Mul(y1, y2), w)).Mod(x, p)
+		y := new(big.Int).Mul(x1, y2)
+		y.Add(y, new(big.Int).Mul(y1, x2)).Mod(y, p)
+		return x, y
+	}
+
+	exp := new(big.Int).Add(p, one)
+	exp.Rsh(exp, 1)
+	rx, ry := big.NewInt(1), big.NewInt(0)
+	bx, by := new(big.Int).Set(t), big.NewInt(1)
+	for i := exp.BitLen() - 1; i >= 0; i-- {
+		rx, ry = mul(rx, ry, rx, ry)
+		if exp.Bit(i) == 1 {
+			rx, ry = mul(rx, ry, bx, by)
+		}
+	}
+
+	if ry.Sign() != 0 {
+		return nil, errModulusNotPrime
+	}
+	return smallerRoot(rx, p), nil
+}
+
+var errModulusNotPrime = errors.New("modulus must be a prime")
+
+// nonResidueSearchLimit граница перебора в поиске невычета. Для простого p
+// наименьший невычет меньше 2 ln^2 p (при GRH), а ln p < bitlen(p); у составного
+// модуля "невычета" по критерию Эйлера может не быть вовсе.
+func nonResidueSearchLimit(p *big.Int) int {
+	bits := p.BitLen()
+	return 2*bits*bits + 16
+}
+
+// sqrtPrecheck приводит a по модулю p и отсеивает тривиальные случаи.
+// done = true означает, что ответ уже найден.
+func (ms *MathService) sqrtPrecheck(a, p *big.Int) (*big.Int, bool, error) {
+	if p.Cmp(big.NewInt(2)) < 0 {
+		return nil, false, errModulusNotPrime
+	}
+
+	r := new(big.Int).Mod(a, p)
+	if p.Cmp(big.NewInt(2)) == 0 || r.Sign() == 0 {
+		return r, true, nil
+	}
+	if p.Bit(0) == 0 {
+		return nil, false, errModulusNotPrime
+	}
+	if ms.LegendreSymbol(r, p) != 1 {
+		return nil, false, errors.New("value is not a quadratic residue")
+	}
+	return r, false, nil
+}
+
+// checkedRoot проверяет r^2 = a (mod p) и возвращает меньший корень; для
+// составного p, прошедшего критерий Эйлера, проверка не проходит
+func checkedRoot(r, a, p *big.Int) (*big.Int, error) {
+	square := new(big.Int).Mul(r, r)
+	if square.Mod(square, p).Cmp(a) != 0 {
+		return nil, errModulusNotPrime
+	}
+	return smallerRoot(r, p), nil
+}
+
+// smallerRoot выбирает меньший из корней r и p - r
+func smallerRoot(r, p *big.Int) *big.Int {
+	other := new(big.Int).Sub(p, r)
+	if other.Cmp(r) < 0 {
+		return other
+	}
+	return r
+}
+
+// CRT решает систему x = residues[i] (mod moduli[i]) для произвольных
+// положительных модулей (не обязательно попарно взаимно простых). Возвращает
+// наименьшее неотрицательное решение и НОК модулей; если система несовместна,
+// возвращается ошибка.
+func (ms *MathService) CRT(residues, moduli []*big.Int) (*big.Int, *big.Int, error) {
+	if len(residues) != len(moduli) || len(moduli) == 0 {
+		return nil, nil, errors.New("residues and moduli must be non-empty and of equal length")
+	}
+
+	x := big.NewInt(0)
+	lcm := big.NewInt(1)
+	for i, m := range moduli {
+		if m.Sign() <= 0 {
+			return nil, nil, errors.New("moduli must be positive")
+		}
+		a := new(big.Int).Mod(residues[i], m)
+
+		// x + lcm*k = a (mod m) <=> (lcm/g)*k = (a - x)/g (mod m/g)
+		g := ms.GCD(lcm, m)
+		diff := new(big.Int).Sub(a, x)
+		quo, rem := new(big.Int).QuoRem(diff, g, new(big.Int))
+		if rem.Sign() != 0 {
+			return nil, nil, errors.New("system of congruences is inconsistent")
+		}
+
+		mg := new(big.Int).Quo(m, g)
+		inv, err := ms.ModInverse(new(big.Int).Quo(lcm, g), mg)
+		if err != nil {
+			return nil, nil, err
+		}
+		k := quo.Mul(quo, inv)
+		k.Mod(k, mg)
+
+		x.Add(x, new(big.Int).Mul(lcm, k))
+		lcm.Mul(lcm, mg)
+		x.Mod(x, lcm)
+	}
+	return x, lcm, nil
+}
+
+// validateFactorization проверяет, что множители разложения корректны
+func validateFactorization(factors []PrimePower) error {
+	for _, f := range factors {
+		if f.Prime == nil || f.Prime.Cmp(big.NewInt(2)) < 0 || f.Exponent < 1 {
+			return errors.New("invalid factorization entry")
+		}
+	}
+	return nil
+}
+
+// primePowerTotient возвращает phi(p^k) = p^(k-1) * (p - 1)
+func primePowerTotient(f PrimePower) *big.Int {
+	phi := new(big.Int).Exp(f.Prime, big.NewInt(int64(f.Exponent-1)), nil)
+	return phi.Mul(phi, new(big.Int).Sub(f.Prime, big.NewInt(1)))
+}
+
+// EulerPhi вычисляет функцию Эйлера по разложению числа на простые
+func (ms *MathService) EulerPhi(factors []PrimePower) (*big.Int, error) {
+	if err := validateFactorization(factors); err != nil {
+		return nil, err
+	}
+
+	phi := big.NewInt(1)
+	for _, f := range factors {
+		phi.Mul(phi, primePowerTotient(f))
+	}
+	return phi, nil
+}
+
+// CarmichaelLambda вычисляет функцию Кармайкла (показатель группы (Z/nZ)*)
+// по разложению числа: НОК значений lambda(p^k), где lambda(2^k) = 2^(k-2)
+// при k >= 3, а в остальных случаях lambda(p^k) = phi(p^k).
+func (ms *MathService) CarmichaelLambda(factors []PrimePower) (*big.Int, error) {
+	if err := validateFactorization(factors); err != nil {
+		return nil, err
+	}
+
+	lambda := big.NewInt(1)
+	for _, f := range factors {
+		l := primePowerTotient(f)
+		if f.Prime.Cmp(big.NewInt(2)) == 0 && f.Exponent >= 3 {
+			l.Rsh(l, 1)
+		}
+		g := ms.GCD(lambda, l)
+		lambda.Mul(lambda, l.Quo(l, g))
+	}
+	return lambda, nil
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+	"testing"
+)
+
+// randomPrime1Mod8 возвращает случайное простое p = 1 (mod 8): для таких p
+// Тонелли-Шенкс проходит хотя бы три итерации по степеням двойки
+func randomPrime1Mod8(t *testing.T, bits int) *big.Int {
+	t.Helper()
+	for {
+		p, err := rand.Prime(rand.Reader, bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p.Int64()&7 == 1 {
+			return p
+		}
+	}
+}
+
+func TestModularSquareRoots(t *testing.T) {
+	ms := NewMathService()
+	methods := []struct {
+		name string
+		sqrt func(a, p *big.Int) (*big.Int, error)
+	}{
+		{"TonelliShanks", ms.TonelliShanks},
+		{"Cipolla", ms.Cipolla},
+	}
+
+	primes := []*big.Int{
+		big.NewInt(65537),      // p - 1 = 2^16
+		big.NewInt(3<<30 + 1),  // p - 1 = 3 * 2^30
+		big.NewInt(1000000007), // p = 3 (mod 4)
+	}
+	for i := 0; i < 8; i++ {
+		primes = append(primes, randomPrime1Mod8(t, 128))
+	}
+
+	for _, p := range primes {
+		for i := 0; i < 4; i++ {
+			x, _ := rand.Int(rand.Reader, p)
+			a := new(big.Int).Mul(x, x)
+			a.Mod(a, p)
+			want := smallerRoot(x, p)
+
+			for _, m := range methods {
+				r, err := m.sqrt(a, p)
+				if err != nil {
+					t.Fatalf("%s(%s, %s): %v", m.name, a, p, err)
+				}
+				if r.Cmp(want) != 0 {
+					t.Errorf("%s(%s, %s) = %s, want the smaller root %s", m.name, a, p, r, want)
+				}
+			}
+		}
+
+		// -1 - невычет при p = 3 (mod 4), p - 1 при p = 1 (mod 8) - вычет
+		minusOne := new(big.Int).Sub(p, big.NewInt(1))
+		for _, m := range methods {
+			_, err := m.sqrt(minusOne, p)
+			if (err == nil) != (p.Bit(1) == 0) {
+				t.Errorf("%s(-1, %s): err = %v", m.name, p, err)
+			}
+		}
+	}
+}
+
+func TestModularSquareRootsCompositeModulus(t *testing.T) {
+	ms := NewMathService()
+	methods := map[string]func(a, p *big.Int) (*big.Int, error){
+		"TonelliShanks": ms.TonelliShanks,
+		"Cipolla":       ms.Cipolla,
+	}
+
+	// Для составного модуля допустима ошибка или настоящий корень, но не
+	// число, квадрат которого не равен a
+	for _, n := range []int64{15, 21, 561, 1105, 41041, 825265} {
+		nb := big.NewInt(n)
+		for a := int64(1); a < 300; a++ {
+			ab := big.NewInt(a)
+			for name, sqrt := range methods {
+				r, err := sqrt(ab, nb)
+				if err != nil {
+					continue
+				}
+				square := new(big.Int).Mul(r, r)
+				if square.Mod(square, nb).Cmp(new(big.Int).Mod(ab, nb)) != 0 {
+					t.Errorf("%s(%d, %d) = %s, not a square root", name, a, n, r)
+				}
+			}
+		}
+	}
+
+	// Число Кармайкла (6k+1)(12k+1)(18k+1) при нечетном k: z^((n-1)/2) = 1
+	// для всех z, взаимно простых с n, а наименьший делитель больше границы
+	// поиска, поэтому "невычет" не находится и срабатывает ограничение
+	carmichael := mustBigInt(t, "1296198694153288947529")
+	if limit := nonResidueSearchLimit(carmichael); limit >= 6*1000051+1 {
+		t.Fatalf("search limit %d reaches the smallest factor", limit)
+	}
+	for name, sqrt := range methods {
+		if _, err := sqrt(big.NewInt(5), carmichael); !errors.Is(err, errModulusNotPrime) {
+			t.Errorf("%s modulo a Carmichael number: err = %v, want errModulusNotPrime", name, err)
+		}
+	}
+
+	for name, sqrt := range methods {
+		for _, n := range []int64{-7, 0, 1, 4} {
+			if _, err := sqrt(big.NewInt(3), big.NewInt(n)); !errors.Is(err, errModulusNotPrime) {
+				t.Errorf("%s(3, %d): err = %v, want errModulusNotPrime", name, n, err)
+			}
+		}
+	}
+}
+
+func TestCRT(t *testing.T) {
+	ms := NewMathService()
+	tests := []struct {
+		residues, moduli []int64
+		x, lcm           int64
+	}{
+		{[]int64{2, 3, 2}, []int64{3, 5, 7}, 23, 105},
+		{[]int64{3, 5}, []int64{4, 6}, 11, 12},       // НОД 2
+		{[]int64{2, 8}, []int64{6, 9}, 8, 18},        // НОД 3
+		{[]int64{1, 1, 1}, []int64{4, 6, 10}, 1, 60}, // попарно не взаимно простые
+		{[]int64{-1, 5, 11}, []int64{12, 18, 30}, 131, 180},
+		{[]int64{7, 7}, []int64{10, 10}, 7, 10}, // повторяющийся модуль
+	}
+	for _, tt := range tests {
+		x, lcm, err := ms.CRT(bigInts(tt.residues...), bigInts(tt.moduli...))
+		if err != nil {
+			t.Errorf("CRT(%v, %v): %v", tt.residues, tt.moduli, err)
+			continue
+		}
+		if x.Int64() != tt.x || lcm.Int64() != tt.lcm {
+			t.Errorf("CRT(%v, %v) = %s mod %s, want %d mod %d", tt.residues, tt.moduli, x, lcm, tt.x, tt.lcm)
+		}
+	}
+
+	inconsistent := []struct{ residues, moduli []int64 }{
+		{[]int64{1, 2}, []int64{4, 6}},
+		{[]int64{0, 1}, []int64{10, 15}},
+		{[]int64{1, 2, 3}, []int64{3, 5, 9}}, // x = 1 (mod 3), но x = 3 (mod 9)
+		{[]int64{3, 4}, []int64{7, 7}},
+	}
+	for _, tt := range inconsistent {
+		if x, _, err := ms.CRT(bigInts(tt.residues...), bigInts(tt.moduli...)); err == nil {
+			t.Errorf("CRT(%v, %v) = %s for an inconsistent system", tt.residues, tt.moduli, x)
+		}
+	}
+
+	if _, _, err := ms.CRT(bigInts(1, 2), bigInts(5, 0)); err == nil {
+		t.Error("zero modulus accepted")
+	}
+	if _, _, err := ms.CRT(bigInts(1), bigInts(5, 7)); err == nil {
+		t.Error("length mismatch accepted")
+	}
+}
@@ -0,0 +1,211 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math/big"
+)
+
+type PaillierPublicKey struct {
+	N        *big.Int
+	NSquared *big.Int
+	G        *big.Int // g = n + 1
+}
+
+type PaillierPrivateKey struct {
+	PublicKey *PaillierPublicKey
+	Lambda    *big.Int // lambda = lcm(p-1, q-1)
+	Mu        *big.Int // mu = lambda^(-1) mod n
+	P         *big.Int
+	Q         *big.Int
+}
+
+// PaillierService аддитивно гомоморфная криптосистема Пэйе:
+// E(m) = g^m * r^n mod n^2, E(a) * E(b) = E(a + b mod n), E(a)^k = E(k*a mod n)
+type PaillierService struct {
+	mathService  *MathService
+	keyGenerator *RSAKeyGenerator
+	publicKey    *PaillierPublicKey
+	privateKey   *PaillierPrivateKey
+}
+
+// NewPaillierService создает сервис с модулем n длины bitLength; простые
+// p и q ищутся так же, как для RSA
+func NewPaillierService(testType PrimalityTestType, minProbability float64, bitLength int) *PaillierService {
+	ms := NewMathService()
+	return &PaillierService{
+		mathService:  ms,
+		keyGenerator: NewRSAKeyGenerator(testType, minProbability, bitLength/2, ms),
+	}
+}
+
+func (ps *PaillierService) GenerateKeys() error {
+	one := big.NewInt(1)
+	for {
+		p, _, err := ps.keyGenerator.GeneratePrime()
+		if err != nil {
+			return err
+		}
+		q, _, err := ps.keyGenerator.GeneratePrime()
+		if err != nil {
+			return err
+		}
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		pMinus1 := new(big.Int).Sub(p, one)
+		qMinus1 := new(big.Int).Sub(q, one)
+
+		// Условие НОД(n, (p-1)(q-1)) = 1 выполняется для простых одной длины,
+		// но проверяется явно
+		phi := new(big.Int).Mul(pMinus1, qMinus1)
+		if ps.mathService.GCD(n, phi).Cmp(one) != 0 {
+			continue
+		}
+
+		lambda := phi.Quo(phi, ps.mathService.GCD(pMinus1, qMinus1))
+		mu, err := ps.mathService.ModInverse(lambda, n)
+		if err != nil {
+			continue
+		}
+
+		pub := &PaillierPublicKey{
+			N:        n,
+			NSquared: new(big.Int).Mul(n, n),
+			G:        new(big.Int).Add(n, one),
+		}
+		ps.publicKey = pub
+		ps.privateKey = &PaillierPrivateKey{PublicKey: pub, Lambda: lambda, Mu: mu, P: p, Q: q}
+		return nil
+	}
+}
+
+func (ps *PaillierService) GetPublicKey() *PaillierPublicKey {
+	return ps.publicKey
+}
+
+func (ps *PaillierService) GetPrivateKey() *PaillierPrivateKey {
+	return ps.privateKey
+}
+
+// Encrypt шифрует m из [0, n): c = (1 + m*n) * r^n mod n^2, так как
+// (n + 1)^m = 1 + m*n (mod n^2)
+func (ps *PaillierService) Encrypt(message *big.Int) (*big.Int, error) {
+	if ps.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	pub := ps.publicKey
+	if message.Sign() < 0 || message.Cmp(pub.N) >= 0 {
+		return nil, errors.New("message out of range [0, n)")
+	}
+
+	r, err := ps.randomUnit()
+	if err != nil {
+		return nil, err
+	}
+
+	c := new(big.Int).Mul(message, pub.N)
+	c.Add(c, big.NewInt(1))
+	c.Mul(c, ps.mathService.ModPow(r, pub.N, pub.NSquared))
+	return c.Mod(c, pub.NSquared), nil
+}
+
+// Decrypt вычисляет m = L(c^lambda mod n^2) * mu mod n, L(x) = (x - 1) / n
+func (ps *PaillierService) Decrypt(ciphertext *big.Int) (*big.Int, error) {
+	if ps.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	if err := ps.checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+	pub := ps.publicKey
+
+	u := ps.mathService.ModPow(ciphertext, ps.privateKey.Lambda, pub.NSquared)
+	u.Sub(u, big.NewInt(1))
+	u.Quo(u, pub.N)
+	u.Mul(u, ps.privateKey.Mu)
+	return u.Mod(u, pub.N), nil
+}
+
+// Add возвращает шифротекст суммы: E(a) * E(b) = E(a + b mod n)
+func (ps *PaillierService) Add(c1, c2 *big.Int) (*big.Int, error) {
+	if err := ps.checkCiphertext(c1); err != nil {
+		return nil, err
+	}
+	if err := ps.checkCiphertext(c2); err != nil {
+		return nil, err
+	}
+	c := new(big.Int).Mul(c1, c2)
+	return c.Mod(c, ps.publicKey.NSquared), nil
+}
+
+// AddPlain прибавляет открытое число: E(a) * g^k = E(a + k mod n)
+func (ps *PaillierService) AddPlain(ciphertext, k *big.Int) (*big.Int, error) {
+	if err := ps.checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+	pub := ps.publicKey
+	gk := new(big.Int).Mod(k, pub.N)
+	gk.Mul(gk, pub.N).Add(gk, big.NewInt(1))
+
+	c := new(big.Int).Mul(ciphertext, gk)
+	return c.Mod(c, pub.NSquared), nil
+}
+
+// MulPlain умножает зашифрованное число на открытое: E(a)^k = E(k*a mod n).
+// Отрицательные k берутся по модулю n.
+func (ps *PaillierService) MulPlain(ciphertext, k *big.Int) (*big.Int, error) {
+	if err := ps.checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+	pub := ps.publicKey
+	return ps.mathService.ModPow(ciphertext, new(big.Int).Mod(k, pub.N), pub.NSquared), nil
+}
+
+// Rerandomize меняет случайность шифротекста, не меняя открытого текста:
+// c * r^n = E(m) с новым r
+func (ps *PaillierService) Rerandomize(ciphertext *big.Int) (*big.Int, error) {
+	if err := ps.checkCiphertext(ciphertext); err != nil {
+		return nil, err
+	}
+	r, err := ps.randomUnit()
+	if err != nil {
+		return nil, err
+	}
+	pub := ps.publicKey
+	c := ps.mathService.ModPow(r, pub.N, pub.NSquared)
+	c.Mul(c, ciphertext)
+	return c.Mod(c, pub.NSquared), nil
+}
+
+// randomUnit выбирает случайное r из [1, n), взаимно простое с n
+func (ps *PaillierService) randomUnit() (*big.Int, error) {
+	n := ps.publicKey.N
+	one := big.NewInt(1)
+	for {
+		r, err := rand.Int(rand.Reader, n)
+		if err != nil {
+			return nil, err
+		}
+		if r.Sign() > 0 && ps.mathService.GCD(r, n).Cmp(one) == 0 {
+			return r, nil
+		}
+	}
+}
+
+// checkCiphertext проверяет, что c лежит в (Z/n^2 Z)*
+func (ps *PaillierService) checkCiphertext(c *big.Int) error {
+	if ps.publicKey == nil {
+		return errors.New("keys not generated")
+	}
+	pub := ps.publicKey
+	if c.Sign() <= 0 || c.Cmp(pub.NSquared) >= 0 {
+		return errors.New("ciphertext out of range")
+	}
+	if ps.mathService.GCD(c, pub.N).Cmp(big.NewInt(1)) != 0 {
+		return errors.New("ciphertext is not invertible modulo n^2")
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package main
+
+import "math/big"
+
+type PollardAttackResult struct {
+	P          *big.Int
+	Q          *big.Int
+	Iterations int
+	Success    bool
+}
+
+// PollardAttackService факторизация модуля методами Полларда: p-1 находит p,
+// если p-1 гладкое, ро-метод - малые простые множители за O(sqrt(p)) шагов
+type PollardAttackService struct {
+	mathService *MathService
+}
+
+func NewPollardAttackService() *PollardAttackService {
+	return &PollardAttackService{
+		mathService: NewMathService(),
+	}
+}
+
+// PMinus1 метод p-1: a = 2^(B!) mod n, делитель ищется как gcd(a - 1, n)
+func (pas *PollardAttackService) PMinus1(publicKey *RSAPublicKey, bound int) *PollardAttackResult {
+	result := &PollardAttackResult{
+		Success: false,
+	}
+
+	n := publicKey.N
+	one := big.NewInt(1)
+	a := big.NewInt(2)
+	j := new(big.Int)
+	aMinus1 := new(big.Int)
+
+	for i := 2; i <= bound; i++ {
+		result.Iterations = i - 1
+		a = pas.mathService.ModPow(a, j.SetInt64(int64(i)), n)
+
+		// НОД проверяется периодически и на последнем шаге
+		if i%64 != 0 && i != bound {
+			continue
+		}
+		factor := pas.mathService.GCD(aMinus1.Sub(a, one), n)
+		if factor.Cmp(n) == 0 {
+			// Все множители найдены одновременно: шаг слишком крупный
+			return result
+		}
+		if factor.Cmp(one) != 0 {
+			return pas.success(result, n, factor)
+		}
+	}
+	return result
+}
+
+// Rho ро-метод Полларда с f(x) = x^2 + c и поиском цикла по Флойду.
+// При неудаче (gcd = n) повторяется с другим c.
+func (pas *PollardAttackService) Rho(publicKey *RSAPublicKey, maxIterations int) *PollardAttackResult {
+	result := &PollardAttackResult{
+		Success: false,
+	}
+
+	n := publicKey.N
+	one := big.NewInt(1)
+	diff := new(big.Int)
+
+	for c := int64(1); c <= 10; c++ {
+		cBig := big.NewInt(c)
+		f := func(x *big.Int) *big.Int {
+			x.Mul(x, x).Add(x, cBig)
+			return x.Mod(x, n)
+		}
+
+		x := big.NewInt(2)
+		y := big.NewInt(2)
+		for result.Iterations < maxIterations {
+			result.Iterations++
+			f(x)
+			f(f(y))
+
+			factor := pas.mathService.GCD(diff.Sub(x, y).Abs(diff), n)
+			if factor.Cmp(n) == 0 {
+				break
+			}
+			if factor.Cmp(one) != 0 {
+				return pas.success(result, n, factor)
+			}
+		}
+	}
+	return result
+}
+
+func (pas *PollardAttackService) success(result *PollardAttackResult, n, factor *big.Int) *PollardAttackResult {
+	result.P = factor
+	result.Q = new(big.Int).Div(n, factor)
+	result.Success = true
+	return result
+}
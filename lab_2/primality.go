@@ -2,74 +2,171 @@ package main
 
 import (
 	"crypto/rand"
+	"fmt"
 	"math"
 	"math/big"
 )
 
+// PrimalityTest тест простоты. Test сообщает об ошибках явно; IsProbablyPrime -
+// упрощенная обертка над Test (см. probablyPrime).
 type PrimalityTest interface {
 	IsProbablyPrime(n *big.Int, minProbability float64) bool
+	Test(n *big.Int, minProbability float64) (*PrimalityResult, error)
+}
+
+// defaultMinProbability используется IsProbablyPrime вместо недопустимой
+// вероятности
+const defaultMinProbability = 0.99999
+
+// probablyPrime общая реализация IsProbablyPrime. Ответ "составное" дается
+// только по результату теста: недопустимая minProbability (вне [1/2, 1) или
+// NaN) заменяется на defaultMinProbability, а отказ генератора случайных
+// чисел, после которого ответа нет, приводит к панике. Чтобы получить эти
+// ошибки как значения, используйте Test.
+func probablyPrime(test PrimalityTest, n *big.Int, minProbability float64) bool {
+	if !validMinProbability(minProbability) {
+		minProbability = defaultMinProbability
+	}
+	result, err := test.Test(n, minProbability)
+	if err != nil {
+		panic(err)
+	}
+	return result.Probable
+}
+
+// validMinProbability проверяет minProbability из [1/2, 1). Меньшие значения
+// бессмысленны (один раунд любого теста уже дает вероятность не ниже 1/2) и
+// обычно означают, что вместо вероятности передана допустимая ошибка.
+func validMinProbability(minProbability float64) bool {
+	return !math.IsNaN(minProbability) && minProbability >= 0.5 && minProbability < 1
+}
+
+// ErrorGuarantee характер оценки вероятности ошибки теста
+type ErrorGuarantee int
+
+const (
+	GuaranteeExact         ErrorGuarantee = iota // ответ точный (найден свидетель или n мало)
+	GuaranteeProbabilistic                       // ErrorBound - доказанная верхняя оценка
+	GuaranteeHeuristic                           // контрпримеры неизвестны, оценка не доказана
+	GuaranteeNone                                // существуют составные числа, проходящие тест при любых основаниях
+)
+
+func (g ErrorGuarantee) String() string {
+	switch g {
+	case GuaranteeExact:
+		return "точно"
+	case GuaranteeProbabilistic:
+		return "вероятностная оценка"
+	case GuaranteeHeuristic:
+		return "эвристика"
+	default:
+		return "без гарантий"
+	}
+}
+
+// PrimalityResult подробный результат теста простоты
+type PrimalityResult struct {
+	Probable   bool
+	Rounds     int        // выполнено раундов со случайными основаниями
+	Witnesses  []*big.Int // использованные основания; при Probable = false последнее - свидетель составности
+	ErrorBound float64    // верхняя оценка вероятности признать составное простым (1 - оценки нет)
+	Guarantee  ErrorGuarantee
+}
+
+// exactResult результат, не зависящий от случайности
+func exactResult(probable bool, witnesses ...*big.Int) *PrimalityResult {
+	return &PrimalityResult{Probable: probable, Witnesses: witnesses, Guarantee: GuaranteeExact}
 }
 
 type BasePrimalityTest struct {
 	mathService *MathService
 	testName    string
+	roundError  float64 // доля "лжецов" среди оснований для составного n
+	guarantee   ErrorGuarantee
 }
 
+// NewBasePrimalityTest создает основу теста с оценкой ошибки 1/2 за раунд
 func NewBasePrimalityTest(ms *MathService, name string) *BasePrimalityTest {
-	return &BasePrimalityTest{mathService: ms, testName: name}
+	return &BasePrimalityTest{
+		mathService: ms,
+		testName:    name,
+		roundError:  0.5,
+		guarantee:   GuaranteeProbabilistic,
+	}
 }
 
-func (bpt *BasePrimalityTest) calculateRounds(minProbability float64) int {
-	if minProbability >= 1.0 || minProbability < 0.5 {
-		minProbability = 0.99999
+// calculateRounds возвращает число раундов k, при котором roundError^k не
+// превосходит 1 - minProbability
+func (bpt *BasePrimalityTest) calculateRounds(minProbability float64) (int, error) {
+	if !validMinProbability(minProbability) {
+		return 0, fmt.Errorf("%s: minimum probability must be in [0.5, 1), got %v", bpt.testName, minProbability)
 	}
 	errorProb := 1.0 - minProbability
-	rounds := int(math.Ceil(math.Log(errorProb) / math.Log(0.5)))
+	rounds := int(math.Ceil(math.Log(errorProb) / math.Log(bpt.roundError)))
 	if rounds < 1 {
 		rounds = 1
 	}
-	return rounds
+	return rounds, nil
 }
 
 func (bpt *BasePrimalityTest) performTest(n *big.Int, minProbability float64,
-	iterationFunc func(*big.Int, *big.Int) bool) bool {
+	iterationFunc func(*big.Int, *big.Int) bool) (*PrimalityResult, error) {
 
-	if n.Cmp(big.NewInt(2)) == 0 {
-		return true
+	rounds, err := bpt.calculateRounds(minProbability)
+	if err != nil {
+		return nil, err
 	}
-	if n.Cmp(big.NewInt(2)) < 0 {
-		return false
+
+	if n.Cmp(big.NewInt(2)) < 0 || (n.Bit(0) == 0 && n.Cmp(big.NewInt(2)) != 0) {
+		return exactResult(false), nil
 	}
-	if new(big.Int).Mod(n, big.NewInt(2)).Cmp(big.NewInt(0)) == 0 {
-		return false
+	if n.Cmp(big.NewInt(4)) < 0 {
+		return exactResult(true), nil
 	}
 
-	rounds := bpt.calculateRounds(minProbability)
-
+	result := &PrimalityResult{Guarantee: bpt.guarantee}
 	for i := 0; i < rounds; i++ {
+		// Основание из [2, n-2]
 		a, err := rand.Int(rand.Reader, new(big.Int).Sub(n, big.NewInt(3)))
 		if err != nil {
-			return false
+			return nil, fmt.Errorf("%s: random base generation failed: %w", bpt.testName, err)
 		}
 		a.Add(a, big.NewInt(2))
+		result.Rounds++
+		result.Witnesses = append(result.Witnesses, a)
 
 		if !iterationFunc(n, a) {
-			return false
+			// Найден свидетель составности - ответ точный
+			result.Guarantee = GuaranteeExact
+			return result, nil
 		}
 	}
-	return true
+
+	result.Probable = true
+	result.ErrorBound = 1
+	if bpt.guarantee == GuaranteeProbabilistic {
+		result.ErrorBound = math.Pow(bpt.roundError, float64(result.Rounds))
+	}
+	return result, nil
 }
 
-// FermatTest тест простоты Ферма
+// FermatTest тест простоты Ферма. Числа Кармайкла проходят тест для всех
+// взаимно простых с ними оснований, поэтому оценки ошибки нет.
 type FermatTest struct {
 	*BasePrimalityTest
 }
 
 func NewFermatTest(ms *MathService) *FermatTest {
-	return &FermatTest{BasePrimalityTest: NewBasePrimalityTest(ms, "Fermat")}
+	base := NewBasePrimalityTest(ms, "Fermat")
+	base.guarantee = GuaranteeNone
+	return &FermatTest{BasePrimalityTest: base}
 }
 
 func (ft *FermatTest) IsProbablyPrime(n *big.Int, minProbability float64) bool {
+	return probablyPrime(ft, n, minProbability)
+}
+
+func (ft *FermatTest) Test(n *big.Int, minProbability float64) (*PrimalityResult, error) {
 	return ft.performTest(n, minProbability, func(n, a *big.Int) bool {
 		exp := new(big.Int).Sub(n, big.NewInt(1))
 		result := ft.mathService.ModPow(a, exp, n)
@@ -87,6 +184,12 @@ func NewSolovayStrassenTest(ms *MathService) *SolovayStrassenTest {
 }
 
 func (sst *SolovayStrassenTest) IsProbablyPrime(n *big.Int, minProbability float64) bool {
+	return probablyPrime(sst, n, minProbability)
+}
+
+// Test для составного n не более половины оснований - эйлеровы лжецы,
+// ошибка за раунд не превосходит 1/2
+func (sst *SolovayStrassenTest) Test(n *big.Int, minProbability float64) (*PrimalityResult, error) {
 	return sst.performTest(n, minProbability, func(n, a *big.Int) bool {
 		jacobi := sst.mathService.JacobiSymbol(a, n)
 		exp := new(big.Int).Sub(n, big.NewInt(1))
@@ -106,33 +209,285 @@ type MillerRabinTest struct {
 	*BasePrimalityTest
 }
 
+// NewMillerRabinTest по теореме Рабина для составного n не более четверти
+// оснований - сильные лжецы, поэтому ошибка за раунд не превосходит 1/4
 func NewMillerRabinTest(ms *MathService) *MillerRabinTest {
-	return &MillerRabinTest{BasePrimalityTest: NewBasePrimalityTest(ms, "Miller-Rabin")}
+	base := NewBasePrimalityTest(ms, "Miller-Rabin")
+	base.roundError = 0.25
+	return &MillerRabinTest{BasePrimalityTest: base}
 }
 
 func (mrt *MillerRabinTest) IsProbablyPrime(n *big.Int, minProbability float64) bool {
-	return mrt.performTest(n, minProbability, func(n, a *big.Int) bool {
-		nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
-		s := 0
-		d := new(big.Int).Set(nMinus1)
-
-		for new(big.Int).Mod(d, big.NewInt(2)).Cmp(big.NewInt(0)) == 0 {
-			s++
-			d.Div(d, big.NewInt(2))
-		}
+	return probablyPrime(mrt, n, minProbability)
+}
+
+func (mrt *MillerRabinTest) Test(n *big.Int, minProbability float64) (*PrimalityResult, error) {
+	return mrt.performTest(n, minProbability, mrt.strongProbablePrime)
+}
+
+// strongProbablePrime проверяет, что нечетное n > 2 является сильным вероятно
+// простым по основанию a (один раунд Миллера-Рабина)
+func (bpt *BasePrimalityTest) strongProbablePrime(n, a *big.Int) bool {
+	nMinus1 := new(big.Int).Sub(n, big.NewInt(1))
+	s := 0
+	d := new(big.Int).Set(nMinus1)
 
-		x := mrt.mathService.ModPow(a, d, n)
+	for new(big.Int).Mod(d, big.NewInt(2)).Cmp(big.NewInt(0)) == 0 {
+		s++
+		d.Div(d, big.NewInt(2))
+	}
+
+	x := bpt.mathService.ModPow(a, d, n)
 
-		if x.Cmp(big.NewInt(1)) == 0 || x.Cmp(nMinus1) == 0 {
+	if x.Cmp(big.NewInt(1)) == 0 || x.Cmp(nMinus1) == 0 {
+		return true
+	}
+
+	for i := 0; i < s-1; i++ {
+		x = bpt.mathService.ModPow(x, big.NewInt(2), n)
+		if x.Cmp(nMinus1) == 0 {
 			return true
 		}
+	}
+	return false
+}
+
+// smallPrimeCheck быстро решает вопрос о простоте для малых n и n с малыми
+// делителями. Второе значение false означает, что нужен полноценный тест.
+func smallPrimeCheck(n *big.Int) (isPrime bool, decided bool) {
+	if n.Cmp(big.NewInt(2)) < 0 {
+		return false, true
+	}
+	if n.IsUint64() && n.Uint64() < 4 {
+		return true, true
+	}
+	if n.Bit(0) == 0 {
+		return false, true
+	}
+
+	word := new(big.Int)
+	for _, p := range smallPrimes[:64] {
+		if n.IsUint64() && n.Uint64() == uint64(p) {
+			return true, true
+		}
+		if word.Mod(n, word.SetUint64(uint64(p))).Sign() == 0 {
+			return false, true
+		}
+	}
+	return false, false
+}
 
-		for i := 0; i < s-1; i++ {
-			x = mrt.mathService.ModPow(x, big.NewInt(2), n)
-			if x.Cmp(nMinus1) == 0 {
-				return true
+// deterministicMRBases первые 12 простых: по Соренсону и Вебстеру (2015) этот
+// набор оснований дает точный ответ для всех n < 318665857834031151167461
+// (~3.19 * 10^23), то есть для любых 64-битных чисел
+var deterministicMRBases = []int64{2, 3, 5, 7, 11, 13, 17, 19, 23, 29, 31, 37}
+
+// DeterministicMillerRabinTest детерминированный тест Миллера-Рабина с
+// фиксированным набором оснований. Для чисел длиннее 64 бит после фиксированных
+// оснований выполняются случайные раунды по minProbability.
+type DeterministicMillerRabinTest struct {
+	*BasePrimalityTest
+}
+
+func NewDeterministicMillerRabinTest(ms *MathService) *DeterministicMillerRabinTest {
+	base := NewBasePrimalityTest(ms, "Deterministic Miller-Rabin")
+	base.roundError = 0.25
+	return &DeterministicMillerRabinTest{BasePrimalityTest: base}
+}
+
+func (dmr *DeterministicMillerRabinTest) IsProbablyPrime(n *big.Int, minProbability float64) bool {
+	return probablyPrime(dmr, n, minProbability)
+}
+
+// Test для n до 64 бит дает точный ответ; для больших n оценка ошибки
+// обеспечивается только случайными раундами (фиксированные основания
+// вероятностной гарантии не дают)
+func (dmr *DeterministicMillerRabinTest) Test(n *big.Int, minProbability float64) (*PrimalityResult, error) {
+	if _, err := dmr.calculateRounds(minProbability); err != nil {
+		return nil, err
+	}
+	if isPrime, decided := smallPrimeCheck(n); decided {
+		return exactResult(isPrime), nil
+	}
+
+	var witnesses []*big.Int
+	for _, base := range deterministicMRBases {
+		a := big.NewInt(base)
+		witnesses = append(witnesses, a)
+		if !dmr.strongProbablePrime(n, a) {
+			return exactResult(false, witnesses...), nil
+		}
+	}
+	if n.BitLen() <= 64 {
+		return exactResult(true, witnesses...), nil
+	}
+
+	result, err := dmr.performTest(n, minProbability, dmr.strongProbablePrime)
+	if err != nil {
+		return nil, err
+	}
+	result.Witnesses = append(witnesses, result.Witnesses...)
+	return result, nil
+}
+
+// StrongLucasTest сильный тест Люка с параметрами Селфриджа (метод A):
+// D - первое из 5, -7, 9, -11, ... с символом Якоби (D/n) = -1, P = 1, Q = (1-D)/4.
+// Тест детерминированный, minProbability не используется.
+type StrongLucasTest struct {
+	*BasePrimalityTest
+}
+
+func NewStrongLucasTest(ms *MathService) *StrongLucasTest {
+	base := NewBasePrimalityTest(ms, "Strong Lucas")
+	base.guarantee = GuaranteeNone
+	return &StrongLucasTest{BasePrimalityTest: base}
+}
+
+func (slt *StrongLucasTest) IsProbablyPrime(n *big.Int, minProbability float64) bool {
+	return probablyPrime(slt, n, minProbability)
+}
+
+// Test сильные псевдопростые Люка существуют (5459, 5777, ...), а параметры
+// фиксированы, поэтому оценки ошибки нет
+func (slt *StrongLucasTest) Test(n *big.Int, minProbability float64) (*PrimalityResult, error) {
+	if isPrime, decided := smallPrimeCheck(n); decided {
+		return exactResult(isPrime), nil
+	}
+	if !slt.strongLucasProbablePrime(n) {
+		return exactResult(false), nil
+	}
+	return &PrimalityResult{Probable: true, ErrorBound: 1, Guarantee: GuaranteeNone}, nil
+}
+
+// selfridgeParameters подбирает D по методу Селфриджа. ok = false, если n
+// составное (полный квадрат или найден общий делитель с D).
+func (bpt *BasePrimalityTest) selfridgeParameters(n *big.Int) (d int64, ok bool) {
+	// Для полного квадрата (D/n) никогда не равен -1
+	root := new(big.Int).Sqrt(n)
+	if root.Mul(root, root).Cmp(n) == 0 {
+		return 0, false
+	}
+
+	dAbs := new(big.Int)
+	d = 5
+	for {
+		dAbs.SetInt64(d)
+		dMod := new(big.Int).Mod(dAbs, n)
+		switch bpt.mathService.JacobiSymbol(dMod, n) {
+		case -1:
+			return d, true
+		case 0:
+			// gcd(D, n) > 1: n составное, если только n != |D|
+			if dAbs.Abs(dAbs).Cmp(n) != 0 {
+				return 0, false
 			}
 		}
+
+		if d > 0 {
+			d = -(d + 2)
+		} else {
+			d = -d + 2
+		}
+	}
+}
+
+// strongLucasProbablePrime проверяет для нечетного n без малых делителей условие
+// сильного вероятно простого Люка: при n + 1 = d * 2^s либо U_d = 0 (mod n),
+// либо V_{d*2^r} = 0 (mod n) для некоторого 0 <= r < s
+func (bpt *BasePrimalityTest) strongLucasProbablePrime(n *big.Int) bool {
+	dParam, ok := bpt.selfridgeParameters(n)
+	if !ok {
 		return false
-	})
+	}
+
+	D := new(big.Int).Mod(big.NewInt(dParam), n)
+	Q := new(big.Int).Mod(big.NewInt((1-dParam)/4), n)
+
+	// n + 1 = d * 2^s
+	d := new(big.Int).Add(n, big.NewInt(1))
+	s := 0
+	for d.Bit(0) == 0 {
+		d.Rsh(d, 1)
+		s++
+	}
+
+	// half вычисляет x / 2 (mod n) для нечетного n
+	half := func(x *big.Int) *big.Int {
+		if x.Bit(0) == 1 {
+			x.Add(x, n)
+		}
+		return x.Rsh(x, 1)
+	}
+
+	// Бинарный метод по битам d от старшего: U_1 = 1, V_1 = P = 1, Q^1 = Q
+	U := big.NewInt(1)
+	V := big.NewInt(1)
+	Qk := new(big.Int).Set(Q)
+	tmp := new(big.Int)
+
+	for i := d.BitLen() - 2; i >= 0; i-- {
+		// U_2k = U_k * V_k, V_2k = V_k^2 - 2Q^k, Q^2k = (Q^k)^2
+		U.Mul(U, V).Mod(U, n)
+		V.Mul(V, V).Sub(V, tmp.Lsh(Qk, 1)).Mod(V, n)
+		Qk.Mul(Qk, Qk).Mod(Qk, n)
+
+		if d.Bit(i) == 1 {
+			// U_2k+1 = (P*U_2k + V_2k) / 2, V_2k+1 = (D*U_2k + P*V_2k) / 2
+			newU := new(big.Int).Add(U, V)
+			newV := new(big.Int).Mul(D, U)
+			newV.Add(newV, V)
+			U = half(newU.Mod(newU, n))
+			V = half(newV.Mod(newV, n))
+			Qk.Mul(Qk, Q).Mod(Qk, n)
+		}
+	}
+
+	if U.Sign() == 0 || V.Sign() == 0 {
+		return true
+	}
+	for r := 1; r < s; r++ {
+		V.Mul(V, V).Sub(V, tmp.Lsh(Qk, 1)).Mod(V, n)
+		if V.Sign() == 0 {
+			return true
+		}
+		Qk.Mul(Qk, Qk).Mod(Qk, n)
+	}
+	return false
+}
+
+// BPSWTest тест Бэйли-Померанса-Селфриджа-Уогстаффа: пробное деление,
+// Миллер-Рабин по основанию 2 и сильный тест Люка. Составные числа, проходящие
+// этот тест, неизвестны; minProbability не используется.
+type BPSWTest struct {
+	*BasePrimalityTest
+}
+
+func NewBPSWTest(ms *MathService) *BPSWTest {
+	base := NewBasePrimalityTest(ms, "Baillie-PSW")
+	base.guarantee = GuaranteeHeuristic
+	return &BPSWTest{BasePrimalityTest: base}
+}
+
+func (bt *BPSWTest) IsProbablyPrime(n *big.Int, minProbability float64) bool {
+	return probablyPrime(bt, n, minProbability)
+}
+
+// Test для n < 2^64 ответ точный (проверено перебором всех псевдопростых
+// по основанию 2), для больших n гарантия эвристическая
+func (bt *BPSWTest) Test(n *big.Int, minProbability float64) (*PrimalityResult, error) {
+	if isPrime, decided := smallPrimeCheck(n); decided {
+		return exactResult(isPrime), nil
+	}
+
+	two := big.NewInt(2)
+	if !bt.strongProbablePrime(n, two) {
+		return exactResult(false, two), nil
+	}
+	if !bt.strongLucasProbablePrime(n) {
+		return exactResult(false), nil
+	}
+	if n.BitLen() <= 64 {
+		return exactResult(true, two), nil
+	}
+	return &PrimalityResult{Probable: true, Witnesses: []*big.Int{two}, ErrorBound: 1, Guarantee: GuaranteeHeuristic}, nil
 }
@@ -0,0 +1,179 @@
+package main
+
+import (
+	"math"
+	"math/big"
+	"testing"
+)
+
+func bigInts(values ...int64) []*big.Int {
+	out := make([]*big.Int, len(values))
+	for i, v := range values {
+		out[i] = big.NewInt(v)
+	}
+	return out
+}
+
+func mustBigInt(t *testing.T, s string) *big.Int {
+	t.Helper()
+	n, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("bad integer %q", s)
+	}
+	return n
+}
+
+var (
+	// Числа Кармайкла; 216821881 = 331 * 661 * 991 не отсеивается пробным делением
+	carmichaelNumbers = bigInts(561, 1105, 1729, 2465, 2821, 6601, 8911, 216821881)
+
+	// Сильные псевдопростые по основанию 2 (OEIS A001262) и по первым
+	// нескольким простым основаниям (Jaeschke)
+	strongBase2Pseudoprimes = bigInts(2047, 3277, 4033, 4681, 8321,
+		2152302898747, 3474749660383, 341550071728321)
+
+	// Сильные псевдопростые Люка с параметрами Селфриджа (OEIS A217255);
+	// у 176399 = 419 * 421 и 324899 = 569 * 571 нет малых делителей
+	strongLucasPseudoprimes = bigInts(5459, 5777, 10877, 16109, 18971, 176399, 324899)
+)
+
+func TestPseudoprimesFoolOnlyTheirOwnTest(t *testing.T) {
+	bpt := NewBasePrimalityTest(NewMathService(), "test")
+	two := big.NewInt(2)
+
+	for _, n := range strongBase2Pseudoprimes {
+		if !bpt.strongProbablePrime(n, two) {
+			t.Errorf("%s should be a strong pseudoprime to base 2", n)
+		}
+	}
+	for _, n := range strongLucasPseudoprimes {
+		if !bpt.strongLucasProbablePrime(n) {
+			t.Errorf("%s should be a strong Lucas pseudoprime", n)
+		}
+		if bpt.strongProbablePrime(n, two) {
+			t.Errorf("%s is also a strong pseudoprime to base 2", n)
+		}
+	}
+	for _, n := range strongBase2Pseudoprimes {
+		if bpt.strongLucasProbablePrime(n) {
+			t.Errorf("%s is also a strong Lucas pseudoprime", n)
+		}
+	}
+}
+
+func TestCompositesRejected(t *testing.T) {
+	ms := NewMathService()
+	var composites []*big.Int
+	composites = append(composites, carmichaelNumbers...)
+	composites = append(composites, strongBase2Pseudoprimes...)
+	composites = append(composites, strongLucasPseudoprimes...)
+
+	for _, testType := range []PrimalityTestType{TestBPSW, TestDeterministicMillerRabin, TestMillerRabin} {
+		test := NewPrimalityTest(testType, ms)
+		for _, n := range composites {
+			result, err := test.Test(n, 0.999999)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Probable {
+				t.Errorf("%T: composite %s reported as prime", test, n)
+			}
+		}
+	}
+
+	// Проходит основания 2..31 и отсеивается только основанием 37
+	n := mustBigInt(t, "3825123056546413051")
+	result, err := NewDeterministicMillerRabinTest(ms).Test(n, 0.99)
+	if err != nil || result.Probable {
+		t.Errorf("deterministic Miller-Rabin accepted %s: %v", n, err)
+	}
+	if last := result.Witnesses[len(result.Witnesses)-1]; last.Int64() != 37 {
+		t.Errorf("witness = %s, want 37", last)
+	}
+}
+
+func TestKnownPrimesAccepted(t *testing.T) {
+	ms := NewMathService()
+	primes := append(bigInts(2, 3, 5, 311, 313, 65537, 2147483647, 4294967291),
+		mustBigInt(t, "18446744073709551557"),                    // наибольшее простое < 2^64
+		mustBigInt(t, "170141183460469231731687303715884105727"), // 2^127 - 1
+	)
+
+	for _, testType := range []PrimalityTestType{TestFermat, TestSolovayStrassen, TestMillerRabin,
+		TestStrongLucas, TestBPSW, TestDeterministicMillerRabin} {
+		test := NewPrimalityTest(testType, ms)
+		for _, p := range primes {
+			result, err := test.Test(p, 0.999999)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !result.Probable {
+				t.Errorf("%T: prime %s rejected", test, p)
+			}
+		}
+	}
+}
+
+func TestDeterministicTestsMatchProbablyPrime(t *testing.T) {
+	ms := NewMathService()
+	tests := []PrimalityTest{NewBPSWTest(ms), NewDeterministicMillerRabinTest(ms)}
+
+	check := func(n *big.Int) {
+		want := n.ProbablyPrime(20)
+		for _, test := range tests {
+			result, err := test.Test(n, 0.99)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if result.Probable != want {
+				t.Errorf("%T(%s) = %v, want %v", test, n, result.Probable, want)
+			}
+		}
+	}
+
+	for i := int64(2); i < 20000; i++ {
+		check(big.NewInt(i))
+	}
+	// Нечетные числа около 2^40 и 2^64, где пробное деление уже не решает
+	for _, start := range []*big.Int{new(big.Int).Lsh(big.NewInt(1), 40), mustBigInt(t, "18446744073709550000")} {
+		n := new(big.Int).Add(start, big.NewInt(1))
+		for i := 0; i < 500; i++ {
+			check(n)
+			n.Add(n, big.NewInt(2))
+		}
+	}
+}
+
+func TestMinProbabilityBoundaries(t *testing.T) {
+	ms := NewMathService()
+	prime, composite := big.NewInt(97), big.NewInt(91)
+	invalid := []float64{-1, 0, 0.3, 0.4999, 1, 1.5, math.NaN(), math.Inf(1)}
+
+	for _, testType := range []PrimalityTestType{TestFermat, TestSolovayStrassen, TestMillerRabin, TestDeterministicMillerRabin} {
+		test := NewPrimalityTest(testType, ms)
+		for _, p := range invalid {
+			if _, err := test.Test(prime, p); err == nil {
+				t.Errorf("%T.Test(97, %v): expected error", test, p)
+			}
+			// Недопустимая вероятность заменяется значением по умолчанию
+			if !test.IsProbablyPrime(prime, p) {
+				t.Errorf("%T.IsProbablyPrime(97, %v) = false", test, p)
+			}
+			if test.IsProbablyPrime(composite, p) {
+				t.Errorf("%T.IsProbablyPrime(91, %v) = true", test, p)
+			}
+		}
+	}
+
+	mr := NewMillerRabinTest(ms)
+	rounds := map[float64]int{0.5: 1, 0.75: 1, 0.9375: 2, 0.99999: 9}
+	for p, want := range rounds {
+		got, err := mr.calculateRounds(p)
+		if err != nil || got != want {
+			t.Errorf("calculateRounds(%v) = %d, %v, want %d", p, got, err, want)
+		}
+	}
+	if got, _ := mr.calculateRounds(math.Nextafter(1, 0)); got < 26 {
+		t.Errorf("calculateRounds(1-ulp) = %d, want at least 26", got)
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"lab_4/primes"
+)
+
+const sieveLimit = 4096 // граница таблицы малых простых для пробного деления
+
+// smallPrimes нечетные простые числа меньше sieveLimit (решето Эратосфена)
+var smallPrimes = func() []uint32 {
+	composite := make([]bool, sieveLimit)
+	var primes []uint32
+	for i := 3; i < sieveLimit; i += 2 {
+		if composite[i] {
+			continue
+		}
+		primes = append(primes, uint32(i))
+		for j := i * i; j < sieveLimit; j += 2 * i {
+			composite[j] = true
+		}
+	}
+	return primes
+}()
+
+// PrimeGenerationStats статистика генерации одного простого числа
+type PrimeGenerationStats struct {
+	Bits            int           // размер простого в битах
+	Workers         int           // число потоков поиска
+	Bases           int           // число случайных баз, с которых начинался поиск
+	Candidates      int           // всего рассмотрено кандидатов
+	SieveRejections int           // отброшено просеиванием малыми простыми
+	TestCalls       int           // вызовы настроенного теста простоты
+	Duration        time.Duration // время генерации
+}
+
+// generatePrime ищет простое число длины bits параллельным поиском
+// lab_4/primes: потоки перебирают непересекающиеся последовательности
+// кандидатов, остатки от деления на малые простые обновляются инкрементально,
+// и настроенный тест простоты вызывается только для кандидатов, не отсеянных
+// решетом. Первый найденный результат останавливает остальные потоки.
+func (kg *RSAKeyGenerator) generatePrime(ctx context.Context, bits int) (*big.Int, PrimeGenerationStats, error) {
+	stats := PrimeGenerationStats{Bits: bits}
+	start := time.Now()
+
+	if bits < 3 {
+		return nil, stats, errors.New("prime size too small")
+	}
+	if kg.provable {
+		prime, err := kg.generateProvablePrime(bits)
+		stats.Workers = 1
+		stats.Duration = time.Since(start)
+		return prime, stats, err
+	}
+
+	prime, search, err := primes.Search(ctx, primes.SearchConfig{
+		Bits:       bits,
+		Workers:    kg.workers,
+		TopTwoBits: true,
+		IsPrime: func(n *big.Int) (bool, error) {
+			result, err := kg.primalityTest.Test(n, kg.minProbability)
+			if err != nil {
+				return false, err
+			}
+			return result.Probable, nil
+		},
+	})
+	stats.Workers = search.Workers
+	stats.Bases = int(search.Bases)
+	stats.Candidates = int(search.Candidates)
+	stats.SieveRejections = int(search.SieveRejections)
+	stats.TestCalls = int(search.TestCalls)
+	stats.Duration = time.Since(start)
+	if err != nil {
+		return nil, stats, err
+	}
+	return prime, stats, nil
+}
+
+// generateProvablePrime строит доказуемо простое число (lab_4/primes) и
+// перепроверяет его сертификат независимым верификатором
+func (kg *RSAKeyGenerator) generateProvablePrime(bits int) (*big.Int, error) {
+	prime, cert, err := primes.GenerateProvablePrime(bits)
+	if err != nil {
+		return nil, err
+	}
+	if err := primes.VerifyCertificate(prime, cert); err != nil {
+		return nil, fmt.Errorf("primality certificate rejected: %w", err)
+	}
+	kg.lastCertificates = append(kg.lastCertificates, cert)
+	return prime, nil
+}
+
+// SetProvablePrimes включает генерацию доказуемо простых чисел с сертификатами
+// Поклингтона вместо вероятностного теста
+func (kg *RSAKeyGenerator) SetProvablePrimes(enabled bool) {
+	kg.provable = enabled
+}
+
+// LastCertificates возвращает сертификаты простоты всех простых последней
+// сгенерированной пары ключей (пусто, если доказуемая генерация выключена)
+func (kg *RSAKeyGenerator) LastCertificates() []*primes.Certificate {
+	return append([]*primes.Certificate(nil), kg.lastCertificates...)
+}
+
+// GeneratePrime генерирует одно простое число и возвращает статистику поиска
+func (kg *RSAKeyGenerator) GeneratePrime() (*big.Int, PrimeGenerationStats, error) {
+	return kg.generatePrime(context.Background(), kg.bitLength)
+}
+
+// GeneratePrimeContext как GeneratePrime, но с возможностью отмены через ctx
+func (kg *RSAKeyGenerator) GeneratePrimeContext(ctx context.Context) (*big.Int, PrimeGenerationStats, error) {
+	return kg.generatePrime(ctx, kg.bitLength)
+}
+
+// SetWorkers задает число потоков поиска простых чисел (<= 0 - по числу
+// процессоров, значение по умолчанию)
+func (kg *RSAKeyGenerator) SetWorkers(workers int) {
+	kg.workers = workers
+}
+
+// LastStats возвращает статистику по простым числам, сгенерированным при
+// последнем вызове GenerateKeyPair (включая отброшенные пары)
+func (kg *RSAKeyGenerator) LastStats() []PrimeGenerationStats {
+	return append([]PrimeGenerationStats(nil), kg.lastStats...)
+}
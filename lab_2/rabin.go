@@ -0,0 +1,222 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"math/big"
+)
+
+// rabinTagLen длина избыточности (усеченный SHA-256 сообщения), по которой
+// среди четырех квадратных корней выбирается исходное сообщение
+const rabinTagLen = 8
+
+type RabinPublicKey struct {
+	N *big.Int
+}
+
+type RabinPrivateKey struct {
+	PublicKey *RabinPublicKey
+	P         *big.Int // p = 3 mod 4
+	Q         *big.Int // q = 3 mod 4
+}
+
+// RabinService криптосистема Рабина: c = m^2 mod n. Взлом эквивалентен
+// разложению n, но расшифрование дает четыре корня, и нужное сообщение
+// выбирается по избыточности.
+type RabinService struct {
+	mathService  *MathService
+	keyGenerator *RSAKeyGenerator
+	publicKey    *RabinPublicKey
+	privateKey   *RabinPrivateKey
+}
+
+func NewRabinService(testType PrimalityTestType, minProbability float64, bitLength int) *RabinService {
+	ms := NewMathService()
+	return &RabinService{
+		mathService:  ms,
+		keyGenerator: NewRSAKeyGenerator(testType, minProbability, bitLength/2, ms),
+	}
+}
+
+// generateBlumPrimes возвращает два различных простых p = q = 3 (mod 4);
+// n = pq - число Блюма, -1 по модулю каждого множителя - невычет
+func generateBlumPrimes(kg *RSAKeyGenerator) (*big.Int, *big.Int, error) {
+	three := big.NewInt(3)
+	four := big.NewInt(4)
+	var found []*big.Int
+	for len(found) < 2 {
+		p, _, err := kg.GeneratePrime()
+		if err != nil {
+			return nil, nil, err
+		}
+		if new(big.Int).Mod(p, four).Cmp(three) != 0 {
+			continue
+		}
+		if len(found) == 1 && found[0].Cmp(p) == 0 {
+			continue
+		}
+		found = append(found, p)
+	}
+	return found[0], found[1], nil
+}
+
+func (rs *RabinService) GenerateKeys() error {
+	p, q, err := generateBlumPrimes(rs.keyGenerator)
+	if err != nil {
+		return err
+	}
+	pub := &RabinPublicKey{N: new(big.Int).Mul(p, q)}
+	rs.publicKey = pub
+	rs.privateKey = &RabinPrivateKey{PublicKey: pub, P: p, Q: q}
+	return nil
+}
+
+func (rs *RabinService) GetPublicKey() *RabinPublicKey {
+	return rs.publicKey
+}
+
+func (rs *RabinService) GetPrivateKey() *RabinPrivateKey {
+	return rs.privateKey
+}
+
+// Encrypt возводит m из [0, n) в квадрат по модулю n
+func (rs *RabinService) Encrypt(message *big.Int) (*big.Int, error) {
+	if rs.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	n := rs.publicKey.N
+	if message.Sign() < 0 || message.Cmp(n) >= 0 {
+		return nil, errors.New("message out of range [0, n)")
+	}
+	c := new(big.Int).Mul(message, message)
+	return c.Mod(c, n), nil
+}
+
+// DecryptRoots возвращает все четыре квадратных корня из c по модулю n.
+// Для p = 3 mod 4 корень по модулю p равен c^((p+1)/4), корни по модулю n
+// собираются по КТО из пар (+-mp, +-mq).
+func (rs *RabinService) DecryptRoots(ciphertext *big.Int) ([]*big.Int, error) {
+	if rs.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	priv := rs.privateKey
+	n := priv.PublicKey.N
+	if ciphertext.Sign() < 0 || ciphertext.Cmp(n) >= 0 {
+		return nil, errors.New("ciphertext out of range")
+	}
+
+	moduli := []*big.Int{priv.P, priv.Q}
+	rootsMod := make([]*big.Int, 2)
+	for i, p := range moduli {
+		if rs.mathService.LegendreSymbol(new(big.Int).Mod(ciphertext, p), p) == -1 {
+			return nil, errors.New("ciphertext is not a quadratic residue")
+		}
+		exp := new(big.Int).Add(p, big.NewInt(1))
+		exp.Rsh(exp, 2)
+		rootsMod[i] = rs.mathService.ModPow(ciphertext, exp, p)
+	}
+
+	var roots []*big.Int
+	for _, sp := range []int{1, -1} {
+		for _, sq := range []int{1, -1} {
+			residues := []*big.Int{signedRoot(rootsMod[0], sp, priv.P), signedRoot(rootsMod[1], sq, priv.Q)}
+			x, _, err := rs.mathService.CRT(residues, moduli)
+			if err != nil {
+				return nil, err
+			}
+			roots = append(roots, x)
+		}
+	}
+	return roots, nil
+}
+
+// signedRoot возвращает r или p - r
+func signedRoot(r *big.Int, sign int, p *big.Int) *big.Int {
+	if sign > 0 || r.Sign() == 0 {
+		return r
+	}
+	return new(big.Int).Sub(p, r)
+}
+
+// MaxMessageLen наибольшая длина сообщения для EncryptBytes
+func (rs *RabinService) MaxMessageLen() (int, error) {
+	if rs.publicKey == nil {
+		return 0, errors.New("keys not generated")
+	}
+	size := (rs.publicKey.N.BitLen()+7)/8 - 3 - rabinTagLen
+	if size <= 0 {
+		return 0, errors.New("Rabin key too small")
+	}
+	return size, nil
+}
+
+// EncryptBytes шифрует сообщение с избыточностью. Блок длины k - 1 байт:
+//
+//	0x01 || 0x00... || 0x02 || сообщение || SHA-256(сообщение)[:8]
+//
+// Старший байт 0x01 гарантирует m^2 > n даже для коротких сообщений.
+func (rs *RabinService) EncryptBytes(message []byte) (*big.Int, error) {
+	size, err := rs.MaxMessageLen()
+	if err != nil {
+		return nil, err
+	}
+	if len(message) > size {
+		return nil, errors.New("message too long for Rabin key")
+	}
+
+	k := (rs.publicKey.N.BitLen() + 7) / 8
+	tag := sha256.Sum256(message)
+	block := make([]byte, k-1)
+	block[0] = 0x01
+	offset := len(block) - rabinTagLen - len(message)
+	block[offset-1] = 0x02
+	copy(block[offset:], message)
+	copy(block[offset+len(message):], tag[:rabinTagLen])
+	return rs.Encrypt(OS2IP(block))
+}
+
+// DecryptBytes находит четыре корня и возвращает единственный, у которого
+// совпадает избыточность
+func (rs *RabinService) DecryptBytes(ciphertext *big.Int) ([]byte, error) {
+	roots, err := rs.DecryptRoots(ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	k := (rs.publicKey.N.BitLen() + 7) / 8
+
+	var message []byte
+	matches := 0
+	for _, root := range roots {
+		if candidate, ok := parseRabinBlock(root, k-1); ok {
+			message = candidate
+			matches++
+		}
+	}
+	switch matches {
+	case 0:
+		return nil, errors.New("no square root carries valid redundancy")
+	case 1:
+		return message, nil
+	default:
+		return nil, errors.New("ambiguous Rabin decryption")
+	}
+}
+
+// parseRabinBlock разбирает блок EncryptBytes и проверяет метку
+func parseRabinBlock(root *big.Int, length int) ([]byte, bool) {
+	block, err := I2OSP(root, length)
+	if err != nil || block[0] != 0x01 {
+		return nil, false
+	}
+	rest := bytes.TrimLeft(block[1:], "\x00")
+	if len(rest) < 1+rabinTagLen || rest[0] != 0x02 {
+		return nil, false
+	}
+	message := rest[1 : len(rest)-rabinTagLen]
+	tag := sha256.Sum256(message)
+	if !bytes.Equal(tag[:rabinTagLen], rest[len(rest)-rabinTagLen:]) {
+		return nil, false
+	}
+	return message, true
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"math/bits"
+	"testing"
+)
+
+func TestRabinDecryptRootsSmallKey(t *testing.T) {
+	// p = 7, q = 11: корни из 15 = 20^2 (mod 77) - это 13, 20, 57, 64
+	rs := NewRabinService(TestMillerRabin, 0.9999, 8)
+	pub := &RabinPublicKey{N: big.NewInt(77)}
+	rs.publicKey = pub
+	rs.privateKey = &RabinPrivateKey{PublicKey: pub, P: big.NewInt(7), Q: big.NewInt(11)}
+
+	c, err := rs.Encrypt(big.NewInt(20))
+	if err != nil || c.Int64() != 15 {
+		t.Fatalf("Encrypt(20) = %v, %v, want 15", c, err)
+	}
+	roots, err := rs.DecryptRoots(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[int64]bool{}
+	for _, r := range roots {
+		got[r.Int64()] = true
+	}
+	for _, want := range []int64{13, 20, 57, 64} {
+		if !got[want] {
+			t.Errorf("roots of 15 mod 77 = %v, missing %d", roots, want)
+		}
+	}
+
+	// 3 - невычет по модулю 7
+	if _, err := rs.DecryptRoots(big.NewInt(3)); err == nil {
+		t.Error("roots of a non-residue returned")
+	}
+	for _, m := range []int64{-1, 77} {
+		if _, err := rs.Encrypt(big.NewInt(m)); err == nil {
+			t.Errorf("Encrypt(%d) accepted", m)
+		}
+	}
+}
+
+func TestRabinRoundTrip(t *testing.T) {
+	rs := NewRabinService(TestMillerRabin, 0.9999, 512)
+	if err := rs.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	priv := rs.GetPrivateKey()
+	three, four := big.NewInt(3), big.NewInt(4)
+	if new(big.Int).Mod(priv.P, four).Cmp(three) != 0 || new(big.Int).Mod(priv.Q, four).Cmp(three) != 0 || priv.P.Cmp(priv.Q) == 0 {
+		t.Fatalf("p = %s, q = %s are not distinct Blum primes", priv.P, priv.Q)
+	}
+
+	// Все четыре корня различны и дают шифротекст
+	m, _ := rand.Int(rand.Reader, priv.PublicKey.N)
+	square, _ := rs.Encrypt(m)
+	roots, err := rs.DecryptRoots(square)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seen := map[string]bool{}
+	for _, r := range roots {
+		if c, _ := rs.Encrypt(r); c.Cmp(square) != 0 {
+			t.Errorf("root %s does not square to the ciphertext", r)
+		}
+		seen[r.String()] = true
+	}
+	if len(seen) != 4 || !seen[m.String()] {
+		t.Errorf("roots %v: want 4 distinct roots including m", roots)
+	}
+
+	size, err := rs.MaxMessageLen()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, length := range []int{0, 1, size / 2, size} {
+		message := make([]byte, length)
+		rand.Read(message)
+		c, err := rs.EncryptBytes(message)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", length, err)
+		}
+		plain, err := rs.DecryptBytes(c)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", length, err)
+		}
+		if !bytes.Equal(plain, message) {
+			t.Errorf("%d bytes: round trip mismatch", length)
+		}
+	}
+	if _, err := rs.EncryptBytes(make([]byte, size+1)); err == nil {
+		t.Error("message longer than MaxMessageLen accepted")
+	}
+
+	// Квадрат случайного числа не несет избыточности
+	if _, err := rs.DecryptBytes(square); err == nil {
+		t.Error("ciphertext without redundancy decrypted")
+	}
+}
+
+func TestGoldwasserMicali(t *testing.T) {
+	gs := NewGoldwasserMicaliService(TestMillerRabin, 0.9999, 256)
+	if _, err := gs.EncryptBit(1); err == nil {
+		t.Error("encrypted without keys")
+	}
+	if err := gs.GenerateKeys(); err != nil {
+		t.Fatal(err)
+	}
+	ms := NewMathService()
+	pub := gs.GetPublicKey()
+	if ms.JacobiSymbol(pub.X, pub.N) != 1 || ms.LegendreSymbol(pub.X, gs.privateKey.P) != -1 {
+		t.Fatal("x is not a pseudosquare")
+	}
+
+	message := []byte("Goldwasser-Micali")
+	ciphertext, err := gs.Encrypt(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ciphertext) != 8*len(message) {
+		t.Fatalf("%d ciphertexts for %d bytes", len(ciphertext), len(message))
+	}
+	plain, err := gs.Decrypt(ciphertext)
+	if err != nil || !bytes.Equal(plain, message) {
+		t.Fatalf("Decrypt = %q, %v", plain, err)
+	}
+	again, _ := gs.Encrypt(message)
+	if again[0].Cmp(ciphertext[0]) == 0 {
+		t.Error("encryption is deterministic")
+	}
+
+	// E(a) * E(b) = E(a XOR b)
+	for a := uint(0); a <= 1; a++ {
+		for b := uint(0); b <= 1; b++ {
+			ca, _ := gs.EncryptBit(a)
+			cb, _ := gs.EncryptBit(b)
+			sum, err := gs.Xor(ca, cb)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if bit, _ := gs.DecryptBit(sum); bit != a^b {
+				t.Errorf("%d XOR %d = %d", a, b, bit)
+			}
+		}
+	}
+
+	if _, err := gs.EncryptBit(2); err == nil {
+		t.Error("bit 2 encrypted")
+	}
+	if _, err := gs.Decrypt(ciphertext[:7]); err == nil {
+		t.Error("7 ciphertexts decrypted")
+	}
+	for _, c := range []*big.Int{big.NewInt(0), pub.N} {
+		if _, err := gs.DecryptBit(c); err == nil {
+			t.Errorf("ciphertext %s outside (0, n) accepted", c)
+		}
+	}
+	// Элемент с символом Якоби -1 не может быть шифротекстом
+	for y := int64(2); ; y++ {
+		c := big.NewInt(y)
+		if ms.JacobiSymbol(c, pub.N) == -1 {
+			if _, err := gs.DecryptBit(c); err == nil {
+				t.Errorf("ciphertext %d with Jacobi symbol -1 accepted", y)
+			}
+			break
+		}
+	}
+}
+
+func TestBlumBlumShubKnownOutput(t *testing.T) {
+	// n = 499 * 547, 19 бит: по 4 младших бита с шага; эталон вычислен на Python
+	g, err := NewBlumBlumShub(big.NewInt(499*547), big.NewInt(12345))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.BitsPerStep() != 4 {
+		t.Errorf("BitsPerStep() = %d, want 4", g.BitsPerStep())
+	}
+	out := make([]byte, 16)
+	g.Read(out[:5]) // чтения разной длины не меняют поток
+	g.Read(out[5:])
+	if got := hex.EncodeToString(out); got != "fc63ff19e9ba6ebf1793b52aa8052fd4" {
+		t.Errorf("output = %s", got)
+	}
+
+	n := big.NewInt(499 * 547)
+	invalid := []struct {
+		name    string
+		n, seed *big.Int
+	}{
+		{"n = 3 (mod 4)", big.NewInt(3 * 7 * 11 * 1009), big.NewInt(2)},
+		{"small modulus", big.NewInt(7 * 11), big.NewInt(2)},
+		{"seed shares a factor", n, big.NewInt(499 * 3)},
+		{"seed 1", n, big.NewInt(1)},
+		{"seed n - 1", n, new(big.Int).Sub(n, big.NewInt(1))},
+		{"nil seed", n, nil},
+	}
+	for _, tt := range invalid {
+		if _, err := NewBlumBlumShub(tt.n, tt.seed); err == nil {
+			t.Errorf("%s: generator created", tt.name)
+		}
+	}
+}
+
+func TestBlumBlumShubGenerated(t *testing.T) {
+	g, err := GenerateBlumBlumShub(TestMillerRabin, 0.9999, 512)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Modulus().BitLen() != 512 || g.BitsPerStep() != 9 {
+		t.Fatalf("%d-bit modulus, %d bits per step", g.Modulus().BitLen(), g.BitsPerStep())
+	}
+
+	// Грубая проверка равномерности: доля единиц в 32 КБ
+	out := make([]byte, 1<<15)
+	if n, err := g.Read(out); n != len(out) || err != nil {
+		t.Fatalf("Read = %d, %v", n, err)
+	}
+	ones := 0
+	for _, b := range out {
+		ones += bits.OnesCount8(b)
+	}
+	if ratio := float64(ones) / float64(8*len(out)); ratio < 0.49 || ratio > 0.51 {
+		t.Errorf("fraction of ones %.4f", ratio)
+	}
+
+	// Генератор подходит как источник для rand.Int
+	if _, err := rand.Int(g, big.NewInt(1000)); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Формат поблочного шифрования:
+//
+//	magic (8 байт) || длина блока k (2 байта, big-endian) || блок_0 || ... || блок_m
+//
+// Каждый блок - шифротекст RSA-OAEP SHA-256 длины k. Метка OAEP содержит номер
+// блока и признак последнего блока, поэтому перестановка, повтор или отбрасывание
+// хвоста обнаруживаются при расшифровании. Подлинность данных при этом не
+// гарантируется (зашифровать блоки может любой владелец открытого ключа), для
+// нее служит гибридная схема с AES-GCM.
+var chunkedMagic = []byte("RSACHK01")
+
+const chunkedLabelPrefix = "lab_2 chunked OAEP"
+
+var errChunkedFormat = errors.New("not a chunked RSA stream")
+
+// chunkLabel метка OAEP блока: префикс || номер (4 байта) || признак последнего
+func chunkLabel(index uint32, final bool) []byte {
+	label := make([]byte, len(chunkedLabelPrefix)+5)
+	copy(label, chunkedLabelPrefix)
+	binary.BigEndian.PutUint32(label[len(chunkedLabelPrefix):], index)
+	if final {
+		label[len(label)-1] = 1
+	}
+	return label
+}
+
+// ChunkSize возвращает наибольшую длину открытого текста в одном блоке:
+// k - 2*hLen - 2 для OAEP SHA-256
+func (rs *RSAService) ChunkSize() (int, error) {
+	if rs.publicKey == nil {
+		return 0, errors.New("keys not generated")
+	}
+	size := rs.publicKey.modulusLen() - 2*sha256.Size - 2
+	if size <= 0 {
+		return 0, errors.New("RSA key too small for OAEP chunks")
+	}
+	return size, nil
+}
+
+// EncryptBytes шифрует сообщение произвольной длины: оно делится на блоки
+// по ChunkSize байт, каждый блок дополняется OAEP и переводится в число
+// через OS2IP
+func (rs *RSAService) EncryptBytes(message []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := rs.EncryptStream(&buf, bytes.NewReader(message)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecryptBytes расшифровывает результат EncryptBytes и собирает сообщение
+func (rs *RSAService) DecryptBytes(ciphertext []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if _, err := rs.DecryptStream(&buf, bytes.NewReader(ciphertext)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// EncryptStream поблочно шифрует src в dst, не загружая данные в память
+// целиком. Возвращает число прочитанных байт открытого текста.
+func (rs *RSAService) EncryptStream(dst io.Writer, src io.Reader) (int64, error) {
+	size, err := rs.ChunkSize()
+	if err != nil {
+		return 0, err
+	}
+	k := rs.publicKey.modulusLen()
+
+	w := bufio.NewWriter(dst)
+	w.Write(chunkedMagic)
+	binary.Write(w, binary.BigEndian, uint16(k))
+
+	r := bufio.NewReader(src)
+	chunk := make([]byte, size)
+	defer zeroBytes(chunk)
+	var total int64
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(r, chunk)
+		if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+			return total, err
+		}
+		total += int64(n)
+
+		// Блок последний, если за ним нет данных
+		final := n < size
+		if !final {
+			if _, err := r.Peek(1); err == io.EOF {
+				final = true
+			} else if err != nil {
+				return total, err
+			}
+		}
+
+		block, err := rs.EncryptOAEP(sha256.New(), chunk[:n], chunkLabel(index, final))
+		if err != nil {
+			return total, fmt.Errorf("encrypt chunk %d: %w", index, err)
+		}
+		if _, err := w.Write(block); err != nil {
+			return total, err
+		}
+		if final {
+			break
+		}
+	}
+	return total, w.Flush()
+}
+
+// DecryptStream расшифровывает поток EncryptStream из src в dst. Возвращает
+// число записанных байт открытого текста. При ошибке в dst могут остаться
+// уже расшифрованные блоки.
+func (rs *RSAService) DecryptStream(dst io.Writer, src io.Reader) (int64, error) {
+	if rs.privateKey == nil {
+		return 0, errors.New("keys not generated")
+	}
+	k := rs.publicKey.modulusLen()
+
+	r := bufio.NewReader(src)
+	header := make([]byte, len(chunkedMagic)+2)
+	if _, err := io.ReadFull(r, header); err != nil || !bytes.Equal(header[:len(chunkedMagic)], chunkedMagic) {
+		return 0, errChunkedFormat
+	}
+	if int(binary.BigEndian.Uint16(header[len(chunkedMagic):])) != k {
+		return 0, errors.New("chunked stream was encrypted for a different key size")
+	}
+
+	block := make([]byte, k)
+	var total int64
+	for index := uint32(0); ; index++ {
+		if _, err := io.ReadFull(r, block); err != nil {
+			if err == io.EOF {
+				return total, errors.New("chunked stream is truncated")
+			}
+			return total, fmt.Errorf("read chunk %d: %w", index, err)
+		}
+
+		final := false
+		if _, err := r.Peek(1); err == io.EOF {
+			final = true
+		} else if err != nil {
+			return total, err
+		}
+
+		chunk, err := rs.DecryptOAEP(sha256.New(), block, chunkLabel(index, final))
+		if err != nil {
+			return total, fmt.Errorf("chunk %d: %w", index, err)
+		}
+		n, err := dst.Write(chunk)
+		total += int64(n)
+		zeroBytes(chunk)
+		if err != nil {
+			return total, err
+		}
+		if final {
+			return total, nil
+		}
+	}
+}
+
+// EncryptFileChunked шифрует файл inputPath поблочно в outputPath
+func (rs *RSAService) EncryptFileChunked(inputPath, outputPath string) error {
+	return transformFile(inputPath, outputPath, 0644, rs.EncryptStream)
+}
+
+// DecryptFileChunked расшифровывает поблочный файл inputPath в outputPath;
+// при ошибке неполный выходной файл удаляется
+func (rs *RSAService) DecryptFileChunked(inputPath, outputPath string) error {
+	return transformFile(inputPath, outputPath, 0600, rs.DecryptStream)
+}
+
+// transformFile пропускает содержимое файла через потоковое преобразование
+func transformFile(inputPath, outputPath string, perm os.FileMode, transform func(io.Writer, io.Reader) (int64, error)) error {
+	in, err := os.Open(inputPath)
+	if err != nil {
+		return fmt.Errorf("open input file: %w", err)
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(outputPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+	if err != nil {
+		return fmt.Errorf("create output file: %w", err)
+	}
+
+	if _, err := transform(out, in); err != nil {
+		out.Close()
+		os.Remove(outputPath)
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/iotest"
+)
+
+// chunkedBlocks делит поток EncryptBytes на заголовок и блоки длины k
+func chunkedBlocks(t *testing.T, ciphertext []byte, k int) ([]byte, [][]byte) {
+	t.Helper()
+	headerLen := len(chunkedMagic) + 2
+	body := ciphertext[headerLen:]
+	if len(body)%k != 0 {
+		t.Fatalf("stream body of %d bytes is not a multiple of %d", len(body), k)
+	}
+	var blocks [][]byte
+	for len(body) > 0 {
+		blocks = append(blocks, body[:k])
+		body = body[k:]
+	}
+	return ciphertext[:headerLen], blocks
+}
+
+func joinChunked(header []byte, blocks ...[]byte) []byte {
+	return bytes.Join(append([][]byte{header}, blocks...), nil)
+}
+
+func TestChunkedRoundTripAtBoundaries(t *testing.T) {
+	rs, _ := testRSAService(t)
+	size, err := rs.ChunkSize()
+	if err != nil {
+		t.Fatal(err)
+	}
+	k := rs.GetPublicKey().modulusLen()
+	if size != k-2*32-2 {
+		t.Fatalf("ChunkSize() = %d for a %d-byte modulus", size, k)
+	}
+
+	tests := []struct {
+		length, blocks int
+	}{
+		{0, 1}, // пустое сообщение - один последний блок
+		{1, 1},
+		{size - 1, 1},
+		{size, 1},
+		{size + 1, 2},
+		{2 * size, 2},
+		{2*size + 1, 3},
+		{5*size - 1, 5},
+	}
+	for _, tt := range tests {
+		message := make([]byte, tt.length)
+		rand.Read(message)
+
+		ciphertext, err := rs.EncryptBytes(message)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", tt.length, err)
+		}
+		if _, blocks := chunkedBlocks(t, ciphertext, k); len(blocks) != tt.blocks {
+			t.Errorf("%d bytes: %d blocks, want %d", tt.length, len(blocks), tt.blocks)
+		}
+		plain, err := rs.DecryptBytes(ciphertext)
+		if err != nil {
+			t.Fatalf("%d bytes: %v", tt.length, err)
+		}
+		if !bytes.Equal(plain, message) {
+			t.Errorf("%d bytes: round trip mismatch", tt.length)
+		}
+	}
+}
+
+func TestChunkedStreamWithShortReads(t *testing.T) {
+	rs, _ := testRSAService(t)
+	size, _ := rs.ChunkSize()
+	message := make([]byte, 3*size)
+	rand.Read(message)
+
+	// Источник отдает по одному байту: граница блока определяется не по Read
+	var ciphertext bytes.Buffer
+	n, err := rs.EncryptStream(&ciphertext, iotest.OneByteReader(bytes.NewReader(message)))
+	if err != nil || n != int64(len(message)) {
+		t.Fatalf("EncryptStream = %d, %v", n, err)
+	}
+	var plain bytes.Buffer
+	n, err = rs.DecryptStream(&plain, iotest.HalfReader(&ciphertext))
+	if err != nil || n != int64(len(message)) {
+		t.Fatalf("DecryptStream = %d, %v", n, err)
+	}
+	if !bytes.Equal(plain.Bytes(), message) {
+		t.Error("round trip mismatch")
+	}
+}
+
+func TestChunkedRejectsModifiedStreams(t *testing.T) {
+	rs, _ := testRSAService(t)
+	size, _ := rs.ChunkSize()
+	k := rs.GetPublicKey().modulusLen()
+	message := make([]byte, 3*size+10)
+	rand.Read(message)
+
+	ciphertext, err := rs.EncryptBytes(message)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, blocks := chunkedBlocks(t, ciphertext, k)
+	if len(blocks) != 4 {
+		t.Fatalf("%d blocks, want 4", len(blocks))
+	}
+
+	otherKeySize := append([]byte(nil), header...)
+	otherKeySize[len(otherKeySize)-1] ^= 0x80
+
+	tests := []struct {
+		name   string
+		stream []byte
+	}{
+		{"swapped blocks", joinChunked(header, blocks[1], blocks[0], blocks[2], blocks[3])},
+		{"swapped last blocks", joinChunked(header, blocks[0], blocks[1], blocks[3], blocks[2])},
+		{"first block dropped", joinChunked(header, blocks[1:]...)},
+		{"last block dropped", joinChunked(header, blocks[:3]...)},
+		{"middle block dropped", joinChunked(header, blocks[0], blocks[2], blocks[3])},
+		{"truncated inside a block", ciphertext[:len(ciphertext)-1]},
+		{"header only", header},
+		{"last block repeated", joinChunked(header, append(blocks, blocks[3])...)},
+		{"zero block appended", joinChunked(header, append(blocks, make([]byte, k))...)},
+		{"trailing byte", append(append([]byte(nil), ciphertext...), 0)},
+		{"other key size", joinChunked(otherKeySize, blocks...)},
+		{"bad magic", append([]byte("RSACHK00"), ciphertext[len(chunkedMagic):]...)},
+		{"empty", nil},
+	}
+	for _, tt := range tests {
+		if _, err := rs.DecryptBytes(tt.stream); err == nil {
+			t.Errorf("%s: modified stream decrypted", tt.name)
+		}
+	}
+
+	if _, err := rs.DecryptBytes(ciphertext[:4]); !errors.Is(err, errChunkedFormat) {
+		t.Errorf("short header: err = %v, want errChunkedFormat", err)
+	}
+}
+
+func TestChunkedFiles(t *testing.T) {
+	rs, _ := testRSAService(t)
+	dir := t.TempDir()
+	input := filepath.Join(dir, "input.bin")
+	data := make([]byte, 5_000)
+	rand.Read(data)
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rs.EncryptFileChunked(input, input+".rsa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.DecryptFileChunked(input+".rsa", input+".out"); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := os.ReadFile(input + ".out")
+	if err != nil || !bytes.Equal(restored, data) {
+		t.Fatalf("restored file differs (%v)", err)
+	}
+
+	// При ошибке неполный результат удаляется
+	encrypted, _ := os.ReadFile(input + ".rsa")
+	truncated := filepath.Join(dir, "truncated.rsa")
+	os.WriteFile(truncated, encrypted[:len(encrypted)-10], 0600)
+	if err := rs.DecryptFileChunked(truncated, truncated+".out"); err == nil {
+		t.Fatal("truncated file decrypted")
+	}
+	if _, err := os.Stat(truncated + ".out"); !os.IsNotExist(err) {
+		t.Errorf("partial output left behind: %v", err)
+	}
+}
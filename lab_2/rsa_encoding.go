@@ -0,0 +1,661 @@
+package main
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// KeyFormat формат DER-кодирования ключа
+type KeyFormat int
+
+const (
+	FormatPKCS1 KeyFormat = iota // PKCS#1 (RSA PUBLIC KEY / RSA PRIVATE KEY)
+	FormatPKCS8                  // SPKI для открытого ключа, PKCS#8 для закрытого
+)
+
+// Типы PEM-блоков
+const (
+	pemTypePKCS1Public      = "RSA PUBLIC KEY"
+	pemTypePKCS1Private     = "RSA PRIVATE KEY"
+	pemTypePublic           = "PUBLIC KEY"
+	pemTypePrivate          = "PRIVATE KEY"
+	pemTypeEncryptedPrivate = "ENCRYPTED PRIVATE KEY"
+)
+
+// Параметры шифрования закрытого ключа паролем (PBES2: PBKDF2-HMAC-SHA256 + AES-256-CBC)
+const (
+	pbkdf2Iterations = 600000
+	pbkdf2SaltSize   = 16
+	pbes2KeySize     = 32
+
+	// Верхняя граница числа итераций в разбираемом ключе: иначе файл с
+	// IterationCount = 2^31 заставит DecodePrivateKeyPEM считать PBKDF2 часами
+	maxPBKDF2Iterations = 10000000
+)
+
+var (
+	oidRSAEncryption  = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidPBES2          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 13}
+	oidPBKDF2         = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 5, 12}
+	oidHMACWithSHA256 = asn1.ObjectIdentifier{1, 2, 840, 113549, 2, 9}
+	oidAES256CBC      = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 1, 42}
+)
+
+var errIncorrectPassphrase = errors.New("incorrect passphrase or corrupted key")
+
+// ASN.1-структуры (RFC 8017, RFC 5280, RFC 5208, RFC 8018)
+
+type algorithmIdentifier struct {
+	Algorithm  asn1.ObjectIdentifier
+	Parameters asn1.RawValue `asn1:"optional"`
+}
+
+type pkcs1PublicKey struct {
+	N *big.Int
+	E *big.Int
+}
+
+type pkcs1PrivateKey struct {
+	Version int
+	N       *big.Int
+	E       *big.Int
+	D       *big.Int
+	P       *big.Int
+	Q       *big.Int
+	Dp      *big.Int
+	Dq      *big.Int
+	Qinv    *big.Int
+
+	AdditionalPrimes []pkcs1AdditionalPrime `asn1:"optional,omitempty"`
+}
+
+// pkcs1AdditionalPrime OtherPrimeInfo из RFC 8017 (версия 1, многопростой ключ)
+type pkcs1AdditionalPrime struct {
+	Prime *big.Int
+	Exp   *big.Int
+	Coeff *big.Int
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type pkcs8PrivateKey struct {
+	Version    int
+	Algorithm  algorithmIdentifier
+	PrivateKey []byte
+}
+
+type encryptedPrivateKeyInfo struct {
+	Algorithm     algorithmIdentifier
+	EncryptedData []byte
+}
+
+type pbes2Params struct {
+	KeyDerivationFunc algorithmIdentifier
+	EncryptionScheme  algorithmIdentifier
+}
+
+type pbkdf2Params struct {
+	Salt           []byte
+	IterationCount int
+	KeyLength      int `asn1:"optional"`
+	PRF            algorithmIdentifier
+}
+
+// MarshalPKCS1PublicKey кодирует открытый ключ в DER PKCS#1 (RSAPublicKey)
+func MarshalPKCS1PublicKey(pub *RSAPublicKey) ([]byte, error) {
+	return asn1.Marshal(pkcs1PublicKey{N: pub.N, E: pub.E})
+}
+
+// ParsePKCS1PublicKey разбирает DER PKCS#1 RSAPublicKey
+func ParsePKCS1PublicKey(der []byte) (*RSAPublicKey, error) {
+	var key pkcs1PublicKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("parse PKCS#1 public key: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("parse PKCS#1 public key: trailing data")
+	}
+	if key.N.Sign() <= 0 || key.E.Sign() <= 0 {
+		return nil, errors.New("parse PKCS#1 public key: non-positive modulus or exponent")
+	}
+	return &RSAPublicKey{N: key.N, E: key.E}, nil
+}
+
+// MarshalPKCS1PrivateKey кодирует закрытый ключ в DER PKCS#1 (RSAPrivateKey)
+func MarshalPKCS1PrivateKey(priv *RSAPrivateKey) ([]byte, error) {
+	if priv.P == nil || priv.Q == nil {
+		return nil, errors.New("private key has no prime factors")
+	}
+	if priv.Dp == nil || priv.Dq == nil || priv.Qinv == nil {
+		if err := priv.Precompute(NewMathService()); err != nil {
+			return nil, err
+		}
+	}
+
+	key := pkcs1PrivateKey{
+		Version: 0,
+		N:       priv.PublicKey.N,
+		E:       priv.PublicKey.E,
+		D:       priv.D,
+		P:       priv.P,
+		Q:       priv.Q,
+		Dp:      priv.Dp,
+		Dq:      priv.Dq,
+		Qinv:    priv.Qinv,
+	}
+	if len(priv.AdditionalPrimes) > 0 {
+		key.Version = 1
+		for _, r := range priv.AdditionalPrimes {
+			key.AdditionalPrimes = append(key.AdditionalPrimes, pkcs1AdditionalPrime(r))
+		}
+	}
+	return asn1.Marshal(key)
+}
+
+// ParsePKCS1PrivateKey разбирает DER PKCS#1 RSAPrivateKey (версия 1 -
+// многопростой ключ)
+func ParsePKCS1PrivateKey(der []byte) (*RSAPrivateKey, error) {
+	var key pkcs1PrivateKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("parse PKCS#1 private key: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("parse PKCS#1 private key: trailing data")
+	}
+	if key.Version > 1 || (key.Version == 1) != (len(key.AdditionalPrimes) > 0) {
+		return nil, errors.New("parse PKCS#1 private key: version does not match prime count")
+	}
+
+	priv := &RSAPrivateKey{
+		PublicKey: &RSAPublicKey{N: key.N, E: key.E},
+		D:         key.D,
+		P:         key.P,
+		Q:         key.Q,
+		Dp:        key.Dp,
+		Dq:        key.Dq,
+		Qinv:      key.Qinv,
+	}
+	for _, r := range key.AdditionalPrimes {
+		priv.AdditionalPrimes = append(priv.AdditionalPrimes, CRTPrime(r))
+	}
+	if primesProduct(priv).Cmp(key.N) != 0 {
+		return nil, errors.New("parse PKCS#1 private key: n is not the product of the primes")
+	}
+	if err := validatePrivateExponents(priv); err != nil {
+		return nil, fmt.Errorf("parse PKCS#1 private key: %w", err)
+	}
+	return priv, nil
+}
+
+// validatePrivateExponents проверяет, что e*d = 1 (mod r-1) для каждого
+// простого r (что равносильно e*d = 1 (mod lambda(n))), а параметры КТО
+// совпадают с вычисленными заново. Иначе подпись через КТО с искаженным
+// Dp или Qinv выдала бы неверный результат, раскрывающий множитель n.
+func validatePrivateExponents(priv *RSAPrivateKey) error {
+	one := big.NewInt(1)
+	e, d := priv.PublicKey.E, priv.D
+	if e.Sign() <= 0 || d.Sign() <= 0 {
+		return errors.New("non-positive exponent")
+	}
+
+	ed := new(big.Int).Mul(e, d)
+	for _, r := range priv.Primes() {
+		if r.Cmp(one) <= 0 {
+			return errors.New("prime must be greater than 1")
+		}
+		rMinus1 := new(big.Int).Sub(r, one)
+		if new(big.Int).Mod(ed, rMinus1).Cmp(one) != 0 {
+			return errors.New("e*d is not 1 modulo lambda(n)")
+		}
+	}
+
+	expected := &RSAPrivateKey{PublicKey: priv.PublicKey, D: d, P: priv.P, Q: priv.Q}
+	for _, r := range priv.AdditionalPrimes {
+		expected.AdditionalPrimes = append(expected.AdditionalPrimes, CRTPrime{Prime: r.Prime})
+	}
+	if err := expected.Precompute(NewMathService()); err != nil {
+		return err
+	}
+	if priv.Dp.Cmp(expected.Dp) != 0 || priv.Dq.Cmp(expected.Dq) != 0 || priv.Qinv.Cmp(expected.Qinv) != 0 {
+		return errors.New("CRT parameters do not match d, p and q")
+	}
+	for i, r := range priv.AdditionalPrimes {
+		want := expected.AdditionalPrimes[i]
+		if r.Exp.Cmp(want.Exp) != 0 || r.Coeff.Cmp(want.Coeff) != 0 {
+			return fmt.Errorf("CRT parameters of additional prime %d do not match", i+3)
+		}
+	}
+	return nil
+}
+
+// primesProduct произведение всех простых закрытого ключа
+func primesProduct(priv *RSAPrivateKey) *big.Int {
+	product := big.NewInt(1)
+	for _, r := range priv.Primes() {
+		product.Mul(product, r)
+	}
+	return product
+}
+
+// MarshalPKIXPublicKey кодирует открытый ключ в DER SubjectPublicKeyInfo
+func MarshalPKIXPublicKey(pub *RSAPublicKey) ([]byte, error) {
+	pkcs1, err := MarshalPKCS1PublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+		PublicKey: asn1.BitString{Bytes: pkcs1, BitLength: 8 * len(pkcs1)},
+	})
+}
+
+// ParsePKIXPublicKey разбирает DER SubjectPublicKeyInfo с ключом RSA
+func ParsePKIXPublicKey(der []byte) (*RSAPublicKey, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, fmt.Errorf("parse SPKI public key: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("parse SPKI public key: trailing data")
+	}
+	if !spki.Algorithm.Algorithm.Equal(oidRSAEncryption) {
+		return nil, fmt.Errorf("parse SPKI public key: unsupported algorithm %v", spki.Algorithm.Algorithm)
+	}
+	return ParsePKCS1PublicKey(spki.PublicKey.RightAlign())
+}
+
+// MarshalPKCS8PrivateKey кодирует закрытый ключ в DER PKCS#8 PrivateKeyInfo
+func MarshalPKCS8PrivateKey(priv *RSAPrivateKey) ([]byte, error) {
+	pkcs1, err := MarshalPKCS1PrivateKey(priv)
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(pkcs8PrivateKey{
+		Version:    0,
+		Algorithm:  algorithmIdentifier{Algorithm: oidRSAEncryption, Parameters: asn1.NullRawValue},
+		PrivateKey: pkcs1,
+	})
+}
+
+// ParsePKCS8PrivateKey разбирает DER PKCS#8 PrivateKeyInfo с ключом RSA
+func ParsePKCS8PrivateKey(der []byte) (*RSAPrivateKey, error) {
+	var key pkcs8PrivateKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("parse PKCS#8 private key: %w", err)
+	}
+	if len(rest) > 0 {
+		return nil, errors.New("parse PKCS#8 private key: trailing data")
+	}
+	if !key.Algorithm.Algorithm.Equal(oidRSAEncryption) {
+		return nil, fmt.Errorf("parse PKCS#8 private key: unsupported algorithm %v", key.Algorithm.Algorithm)
+	}
+	return ParsePKCS1PrivateKey(key.PrivateKey)
+}
+
+// EncodePublicKeyPEM кодирует открытый ключ в PEM выбранного формата
+func EncodePublicKeyPEM(pub *RSAPublicKey, format KeyFormat) ([]byte, error) {
+	switch format {
+	case FormatPKCS1:
+		der, err := MarshalPKCS1PublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS1Public, Bytes: der}), nil
+	case FormatPKCS8:
+		der, err := MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypePublic, Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("unsupported key format: %d", format)
+	}
+}
+
+// DecodePublicKeyPEM разбирает PEM с открытым ключом (RSA PUBLIC KEY или PUBLIC KEY)
+func DecodePublicKeyPEM(data []byte) (*RSAPublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case pemTypePKCS1Public:
+		return ParsePKCS1PublicKey(block.Bytes)
+	case pemTypePublic:
+		return ParsePKIXPublicKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block type %q", block.Type)
+	}
+}
+
+// EncodePrivateKeyPEM кодирует закрытый ключ в PEM. Если передан пароль, ключ
+// сохраняется как ENCRYPTED PRIVATE KEY (PKCS#8 + PBES2) независимо от формата.
+func EncodePrivateKeyPEM(priv *RSAPrivateKey, format KeyFormat, passphrase []byte) ([]byte, error) {
+	if len(passphrase) > 0 {
+		der, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		encrypted, err := encryptPKCS8(der, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypeEncryptedPrivate, Bytes: encrypted}), nil
+	}
+
+	switch format {
+	case FormatPKCS1:
+		der, err := MarshalPKCS1PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS1Private, Bytes: der}), nil
+	case FormatPKCS8:
+		der, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			return nil, err
+		}
+		return pem.EncodeToMemory(&pem.Block{Type: pemTypePrivate, Bytes: der}), nil
+	default:
+		return nil, fmt.Errorf("unsupported key format: %d", format)
+	}
+}
+
+// DecodePrivateKeyPEM разбирает PEM с закрытым ключом. Для ENCRYPTED PRIVATE KEY
+// требуется пароль.
+func DecodePrivateKeyPEM(data []byte, passphrase []byte) (*RSAPrivateKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+
+	switch block.Type {
+	case pemTypePKCS1Private:
+		return ParsePKCS1PrivateKey(block.Bytes)
+	case pemTypePrivate:
+		return ParsePKCS8PrivateKey(block.Bytes)
+	case pemTypeEncryptedPrivate:
+		if len(passphrase) == 0 {
+			return nil, errors.New("passphrase required for encrypted private key")
+		}
+		der, err := decryptPKCS8(block.Bytes, passphrase)
+		if err != nil {
+			return nil, err
+		}
+		return ParsePKCS8PrivateKey(der)
+	default:
+		return nil, fmt.Errorf("unexpected PEM block type %q", block.Type)
+	}
+}
+
+// encryptPKCS8 шифрует PrivateKeyInfo по PBES2 (RFC 8018) с PBKDF2-HMAC-SHA256 и AES-256-CBC
+func encryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	salt := make([]byte, pbkdf2SaltSize)
+	iv := make([]byte, aes.BlockSize)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return nil, err
+	}
+
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), salt, pbkdf2Iterations, pbes2KeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	// Дополнение PKCS#7
+	padLen := aes.BlockSize - len(der)%aes.BlockSize
+	padded := append(append([]byte(nil), der...), bytes.Repeat([]byte{byte(padLen)}, padLen)...)
+	encrypted := make([]byte, len(padded))
+	cipher.NewCBCEncrypter(block, iv).CryptBlocks(encrypted, padded)
+
+	kdfParams, err := asn1.Marshal(pbkdf2Params{
+		Salt:           salt,
+		IterationCount: pbkdf2Iterations,
+		PRF:            algorithmIdentifier{Algorithm: oidHMACWithSHA256, Parameters: asn1.NullRawValue},
+	})
+	if err != nil {
+		return nil, err
+	}
+	ivParams, err := asn1.Marshal(iv)
+	if err != nil {
+		return nil, err
+	}
+	params, err := asn1.Marshal(pbes2Params{
+		KeyDerivationFunc: algorithmIdentifier{Algorithm: oidPBKDF2, Parameters: asn1.RawValue{FullBytes: kdfParams}},
+		EncryptionScheme:  algorithmIdentifier{Algorithm: oidAES256CBC, Parameters: asn1.RawValue{FullBytes: ivParams}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(encryptedPrivateKeyInfo{
+		Algorithm:     algorithmIdentifier{Algorithm: oidPBES2, Parameters: asn1.RawValue{FullBytes: params}},
+		EncryptedData: encrypted,
+	})
+}
+
+// decryptPKCS8 расшифровывает EncryptedPrivateKeyInfo, зашифрованный encryptPKCS8
+// (или совместимым инструментом, например openssl pkcs8 -v2 aes-256-cbc -v2prf hmacWithSHA256)
+func decryptPKCS8(der, passphrase []byte) ([]byte, error) {
+	var info encryptedPrivateKeyInfo
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		return nil, fmt.Errorf("parse encrypted private key: %w", err)
+	}
+	if !info.Algorithm.Algorithm.Equal(oidPBES2) {
+		return nil, fmt.Errorf("unsupported key encryption algorithm %v", info.Algorithm.Algorithm)
+	}
+
+	var params pbes2Params
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		return nil, fmt.Errorf("parse PBES2 parameters: %w", err)
+	}
+	if !params.KeyDerivationFunc.Algorithm.Equal(oidPBKDF2) || !params.EncryptionScheme.Algorithm.Equal(oidAES256CBC) {
+		return nil, errors.New("only PBKDF2 with AES-256-CBC is supported")
+	}
+
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		return nil, fmt.Errorf("parse PBKDF2 parameters: %w", err)
+	}
+	if !kdf.PRF.Algorithm.Equal(oidHMACWithSHA256) {
+		return nil, errors.New("only HMAC-SHA256 PRF is supported")
+	}
+	if kdf.IterationCount <= 0 || kdf.IterationCount > maxPBKDF2Iterations {
+		return nil, fmt.Errorf("PBKDF2 iteration count %d out of range [1, %d]", kdf.IterationCount, maxPBKDF2Iterations)
+	}
+
+	var iv []byte
+	if _, err := asn1.Unmarshal(params.EncryptionScheme.Parameters.FullBytes, &iv); err != nil {
+		return nil, fmt.Errorf("parse AES parameters: %w", err)
+	}
+	if len(iv) != aes.BlockSize || len(info.EncryptedData) == 0 || len(info.EncryptedData)%aes.BlockSize != 0 {
+		return nil, errIncorrectPassphrase
+	}
+
+	key, err := pbkdf2.Key(sha256.New, string(passphrase), kdf.Salt, kdf.IterationCount, pbes2KeySize)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+
+	decrypted := make([]byte, len(info.EncryptedData))
+	cipher.NewCBCDecrypter(block, iv).CryptBlocks(decrypted, info.EncryptedData)
+
+	padLen := int(decrypted[len(decrypted)-1])
+	if padLen == 0 || padLen > aes.BlockSize {
+		return nil, errIncorrectPassphrase
+	}
+	expected := bytes.Repeat([]byte{byte(padLen)}, padLen)
+	if subtle.ConstantTimeCompare(decrypted[len(decrypted)-padLen:], expected) != 1 {
+		return nil, errIncorrectPassphrase
+	}
+	return decrypted[:len(decrypted)-padLen], nil
+}
+
+// jwk представление ключа RSA в JSON Web Key (RFC 7517, RFC 7518 6.3)
+type jwk struct {
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	D   string `json:"d,omitempty"`
+	P   string `json:"p,omitempty"`
+	Q   string `json:"q,omitempty"`
+	Dp  string `json:"dp,omitempty"`
+	Dq  string `json:"dq,omitempty"`
+	Qi  string `json:"qi,omitempty"`
+
+	Oth []jwkOtherPrime `json:"oth,omitempty"`
+}
+
+// jwkOtherPrime член "oth" JWK (RFC 7518, 6.3.2.7)
+type jwkOtherPrime struct {
+	R string `json:"r"`
+	D string `json:"d"`
+	T string `json:"t"`
+}
+
+func encodeJWKInt(x *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(x.Bytes())
+}
+
+func decodeJWKInt(s, name string) (*big.Int, error) {
+	if s == "" {
+		return nil, fmt.Errorf("JWK member %q is missing", name)
+	}
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("JWK member %q: %w", name, err)
+	}
+	return new(big.Int).SetBytes(b), nil
+}
+
+// MarshalJWK кодирует открытый ключ в JWK
+func (pub *RSAPublicKey) MarshalJWK() ([]byte, error) {
+	return json.Marshal(jwk{Kty: "RSA", N: encodeJWKInt(pub.N), E: encodeJWKInt(pub.E)})
+}
+
+// MarshalJWK кодирует закрытый ключ в JWK вместе с параметрами КТО
+func (priv *RSAPrivateKey) MarshalJWK() ([]byte, error) {
+	if priv.P == nil || priv.Q == nil {
+		return nil, errors.New("private key has no prime factors")
+	}
+	if priv.Dp == nil || priv.Dq == nil || priv.Qinv == nil {
+		if err := priv.Precompute(NewMathService()); err != nil {
+			return nil, err
+		}
+	}
+
+	key := jwk{
+		Kty: "RSA",
+		N:   encodeJWKInt(priv.PublicKey.N),
+		E:   encodeJWKInt(priv.PublicKey.E),
+		D:   encodeJWKInt(priv.D),
+		P:   encodeJWKInt(priv.P),
+		Q:   encodeJWKInt(priv.Q),
+		Dp:  encodeJWKInt(priv.Dp),
+		Dq:  encodeJWKInt(priv.Dq),
+		Qi:  encodeJWKInt(priv.Qinv),
+	}
+	for _, r := range priv.AdditionalPrimes {
+		key.Oth = append(key.Oth, jwkOtherPrime{
+			R: encodeJWKInt(r.Prime),
+			D: encodeJWKInt(r.Exp),
+			T: encodeJWKInt(r.Coeff),
+		})
+	}
+	return json.Marshal(key)
+}
+
+// ParseJWK разбирает JWK ключа RSA. Закрытый ключ возвращается, только если
+// JWK содержит член "d".
+func ParseJWK(data []byte) (*RSAPublicKey, *RSAPrivateKey, error) {
+	var key jwk
+	if err := json.Unmarshal(data, &key); err != nil {
+		return nil, nil, fmt.Errorf("parse JWK: %w", err)
+	}
+	if key.Kty != "RSA" {
+		return nil, nil, fmt.Errorf("unsupported JWK key type %q", key.Kty)
+	}
+
+	n, err := decodeJWKInt(key.N, "n")
+	if err != nil {
+		return nil, nil, err
+	}
+	e, err := decodeJWKInt(key.E, "e")
+	if err != nil {
+		return nil, nil, err
+	}
+	pub := &RSAPublicKey{N: n, E: e}
+	if key.D == "" {
+		return pub, nil, nil
+	}
+
+	priv := &RSAPrivateKey{PublicKey: pub}
+	members := []struct {
+		value string
+		name  string
+		dst   **big.Int
+	}{
+		{key.D, "d", &priv.D},
+		{key.P, "p", &priv.P},
+		{key.Q, "q", &priv.Q},
+		{key.Dp, "dp", &priv.Dp},
+		{key.Dq, "dq", &priv.Dq},
+		{key.Qi, "qi", &priv.Qinv},
+	}
+	for _, m := range members {
+		if *m.dst, err = decodeJWKInt(m.value, m.name); err != nil {
+			return nil, nil, err
+		}
+	}
+	for _, oth := range key.Oth {
+		var r CRTPrime
+		if r.Prime, err = decodeJWKInt(oth.R, "r"); err != nil {
+			return nil, nil, err
+		}
+		if r.Exp, err = decodeJWKInt(oth.D, "d"); err != nil {
+			return nil, nil, err
+		}
+		if r.Coeff, err = decodeJWKInt(oth.T, "t"); err != nil {
+			return nil, nil, err
+		}
+		priv.AdditionalPrimes = append(priv.AdditionalPrimes, r)
+	}
+	if primesProduct(priv).Cmp(n) != 0 {
+		return nil, nil, errors.New("parse JWK: n is not the product of the primes")
+	}
+	if err := validatePrivateExponents(priv); err != nil {
+		return nil, nil, fmt.Errorf("parse JWK: %w", err)
+	}
+	return pub, priv, nil
+}
@@ -0,0 +1,353 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"strings"
+	"testing"
+)
+
+// testPrivateKey возвращает копию закрытого ключа тестового сервиса, которую
+// можно портить, не затрагивая другие тесты
+func testPrivateKey(t *testing.T) *RSAPrivateKey {
+	t.Helper()
+	_, std := testRSAService(t)
+	priv, err := RSAPrivateKeyFromStdlib(std, NewMathService())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return priv
+}
+
+func requireSamePrivateKey(t *testing.T, got, want *RSAPrivateKey) {
+	t.Helper()
+	pairs := []struct {
+		name     string
+		got, exp *big.Int
+	}{
+		{"n", got.PublicKey.N, want.PublicKey.N},
+		{"e", got.PublicKey.E, want.PublicKey.E},
+		{"d", got.D, want.D},
+		{"p", got.P, want.P},
+		{"q", got.Q, want.Q},
+		{"dp", got.Dp, want.Dp},
+		{"dq", got.Dq, want.Dq},
+		{"qinv", got.Qinv, want.Qinv},
+	}
+	for _, p := range pairs {
+		if p.got.Cmp(p.exp) != 0 {
+			t.Errorf("%s differs after round trip", p.name)
+		}
+	}
+	if len(got.AdditionalPrimes) != len(want.AdditionalPrimes) {
+		t.Fatalf("%d additional primes, want %d", len(got.AdditionalPrimes), len(want.AdditionalPrimes))
+	}
+	for i, r := range got.AdditionalPrimes {
+		w := want.AdditionalPrimes[i]
+		if r.Prime.Cmp(w.Prime) != 0 || r.Exp.Cmp(w.Exp) != 0 || r.Coeff.Cmp(w.Coeff) != 0 {
+			t.Errorf("additional prime %d differs after round trip", i+3)
+		}
+	}
+}
+
+func TestPKCS1DERMatchesX509(t *testing.T) {
+	_, std := testRSAService(t)
+	priv := testPrivateKey(t)
+
+	der, err := MarshalPKCS1PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(der, x509.MarshalPKCS1PrivateKey(std)) {
+		t.Error("PKCS#1 private key DER differs from crypto/x509")
+	}
+	parsed, err := ParsePKCS1PrivateKey(x509.MarshalPKCS1PrivateKey(std))
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireSamePrivateKey(t, parsed, priv)
+
+	pubDER, err := MarshalPKCS1PublicKey(priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pubDER, x509.MarshalPKCS1PublicKey(&std.PublicKey)) {
+		t.Error("PKCS#1 public key DER differs from crypto/x509")
+	}
+	pub, err := ParsePKCS1PublicKey(pubDER)
+	if err != nil || pub.N.Cmp(std.N) != 0 || pub.E.Int64() != int64(std.E) {
+		t.Errorf("ParsePKCS1PublicKey: %v", err)
+	}
+}
+
+func TestPKCS8AndPKIXInteropWithX509(t *testing.T) {
+	_, std := testRSAService(t)
+	priv := testPrivateKey(t)
+
+	der, err := MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdKey, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		t.Fatalf("crypto/x509 cannot parse our PKCS#8: %v", err)
+	}
+	if !stdKey.(*rsa.PrivateKey).Equal(std) {
+		t.Error("crypto/x509 parsed a different key")
+	}
+
+	stdDER, err := x509.MarshalPKCS8PrivateKey(std)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePKCS8PrivateKey(stdDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireSamePrivateKey(t, parsed, priv)
+
+	pubDER, err := MarshalPKIXPublicKey(priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdPubDER, err := x509.MarshalPKIXPublicKey(&std.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(pubDER, stdPubDER) {
+		t.Error("SPKI DER differs from crypto/x509")
+	}
+	if _, err := ParsePKIXPublicKey(stdPubDER); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestPEMRoundTrip(t *testing.T) {
+	priv := testPrivateKey(t)
+
+	for _, format := range []KeyFormat{FormatPKCS1, FormatPKCS8} {
+		pubPEM, err := EncodePublicKeyPEM(priv.PublicKey, format)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pub, err := DecodePublicKeyPEM(pubPEM)
+		if err != nil || pub.N.Cmp(priv.PublicKey.N) != 0 {
+			t.Errorf("public key PEM format %d: %v", format, err)
+		}
+
+		privPEM, err := EncodePrivateKeyPEM(priv, format, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		parsed, err := DecodePrivateKeyPEM(privPEM, nil)
+		if err != nil {
+			t.Fatalf("private key PEM format %d: %v", format, err)
+		}
+		requireSamePrivateKey(t, parsed, priv)
+	}
+}
+
+func TestEncryptedPEM(t *testing.T) {
+	if testing.Short() {
+		t.Skip("PBKDF2 with 600000 iterations")
+	}
+	priv := testPrivateKey(t)
+	passphrase := []byte("correct horse battery staple")
+
+	data, err := EncodePrivateKeyPEM(priv, FormatPKCS1, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(data, []byte(pemTypeEncryptedPrivate)) {
+		t.Fatalf("passphrase given but PEM type is not %q", pemTypeEncryptedPrivate)
+	}
+
+	parsed, err := DecodePrivateKeyPEM(data, passphrase)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireSamePrivateKey(t, parsed, priv)
+
+	if _, err := DecodePrivateKeyPEM(data, []byte("wrong")); err == nil {
+		t.Error("decrypted with a wrong passphrase")
+	}
+	if _, err := DecodePrivateKeyPEM(data, nil); err == nil {
+		t.Error("decrypted without a passphrase")
+	}
+}
+
+// withIterationCount подменяет число итераций PBKDF2 в EncryptedPrivateKeyInfo
+func withIterationCount(t *testing.T, der []byte, iterations int) []byte {
+	t.Helper()
+	var info encryptedPrivateKeyInfo
+	var params pbes2Params
+	var kdf pbkdf2Params
+	if _, err := asn1.Unmarshal(der, &info); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(info.Algorithm.Parameters.FullBytes, &params); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := asn1.Unmarshal(params.KeyDerivationFunc.Parameters.FullBytes, &kdf); err != nil {
+		t.Fatal(err)
+	}
+
+	kdf.IterationCount = iterations
+	kdfDER, err := asn1.Marshal(kdf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	params.KeyDerivationFunc.Parameters = asn1.RawValue{FullBytes: kdfDER}
+	paramsDER, err := asn1.Marshal(params)
+	if err != nil {
+		t.Fatal(err)
+	}
+	info.Algorithm.Parameters = asn1.RawValue{FullBytes: paramsDER}
+	out, err := asn1.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return out
+}
+
+func TestDecryptPKCS8RejectsIterationCount(t *testing.T) {
+	if testing.Short() {
+		t.Skip("PBKDF2 with 600000 iterations")
+	}
+	der, err := encryptPKCS8([]byte("private key info"), []byte("pass"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, iterations := range []int{0, -1, maxPBKDF2Iterations + 1, 1 << 31} {
+		_, err := decryptPKCS8(withIterationCount(t, der, iterations), []byte("pass"))
+		if err == nil || !strings.Contains(err.Error(), "iteration count") {
+			t.Errorf("iteration count %d: %v, want range error", iterations, err)
+		}
+	}
+}
+
+func TestJWKRoundTrip(t *testing.T) {
+	priv := testPrivateKey(t)
+
+	data, err := priv.MarshalJWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, parsed, err := ParseJWK(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pub.N.Cmp(priv.PublicKey.N) != 0 {
+		t.Error("JWK public part differs")
+	}
+	requireSamePrivateKey(t, parsed, priv)
+
+	pubData, err := priv.PublicKey.MarshalJWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, parsed, err = ParseJWK(pubData)
+	if err != nil || parsed != nil || pub.E.Cmp(priv.PublicKey.E) != 0 {
+		t.Errorf("public JWK: %v, private = %v", err, parsed)
+	}
+}
+
+func TestMultiPrimeKeyRoundTrip(t *testing.T) {
+	std, err := rsa.GenerateMultiPrimeKey(rand.Reader, 3, 1536)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := RSAPrivateKeyFromStdlib(std, NewMathService())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	der, err := MarshalPKCS1PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParsePKCS1PrivateKey(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireSamePrivateKey(t, parsed, priv)
+
+	data, err := priv.MarshalJWK()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, parsed, err = ParseJWK(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	requireSamePrivateKey(t, parsed, priv)
+}
+
+// TestParseRejectsInconsistentCRT портит по одному параметру КТО и проверяет,
+// что PKCS#1, PKCS#8 и JWK отвергают такой ключ
+func TestParseRejectsInconsistentCRT(t *testing.T) {
+	std, err := rsa.GenerateMultiPrimeKey(rand.Reader, 3, 1536)
+	if err != nil {
+		t.Fatal(err)
+	}
+	corruptions := map[string]func(k *RSAPrivateKey){
+		"d":     func(k *RSAPrivateKey) { k.D.Add(k.D, big.NewInt(2)) },
+		"dp":    func(k *RSAPrivateKey) { k.Dp.Add(k.Dp, big.NewInt(1)) },
+		"dq":    func(k *RSAPrivateKey) { k.Dq.Add(k.Dq, big.NewInt(1)) },
+		"qinv":  func(k *RSAPrivateKey) { k.Qinv.Add(k.Qinv, big.NewInt(1)) },
+		"exp3":  func(k *RSAPrivateKey) { k.AdditionalPrimes[0].Exp.Add(k.AdditionalPrimes[0].Exp, big.NewInt(1)) },
+		"coeff": func(k *RSAPrivateKey) { k.AdditionalPrimes[0].Coeff.Add(k.AdditionalPrimes[0].Coeff, big.NewInt(1)) },
+	}
+
+	for name, corrupt := range corruptions {
+		priv, err := RSAPrivateKeyFromStdlib(std, NewMathService())
+		if err != nil {
+			t.Fatal(err)
+		}
+		corrupt(priv)
+
+		der, err := MarshalPKCS1PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParsePKCS1PrivateKey(der); err == nil {
+			t.Errorf("%s: PKCS#1 accepted inconsistent key", name)
+		}
+		pkcs8, err := MarshalPKCS8PrivateKey(priv)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := ParsePKCS8PrivateKey(pkcs8); err == nil {
+			t.Errorf("%s: PKCS#8 accepted inconsistent key", name)
+		}
+		privPEM := pem.EncodeToMemory(&pem.Block{Type: pemTypePKCS1Private, Bytes: der})
+		if _, err := DecodePrivateKeyPEM(privPEM, nil); err == nil {
+			t.Errorf("%s: PEM accepted inconsistent key", name)
+		}
+		jwkData, err := priv.MarshalJWK()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, _, err := ParseJWK(jwkData); err == nil {
+			t.Errorf("%s: JWK accepted inconsistent key", name)
+		}
+	}
+
+	// Член "dp" со значением, которое не совпадает с d mod (p-1)
+	priv, _ := RSAPrivateKeyFromStdlib(std, NewMathService())
+	data, _ := priv.MarshalJWK()
+	var raw map[string]any
+	json.Unmarshal(data, &raw)
+	raw["dp"] = raw["dq"]
+	data, _ = json.Marshal(raw)
+	if _, _, err := ParseJWK(data); err == nil {
+		t.Error("JWK with swapped dp accepted")
+	}
+}
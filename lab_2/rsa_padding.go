@@ -0,0 +1,240 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"errors"
+	"hash"
+	"io"
+	"math/big"
+)
+
+var (
+	errMessageTooLong = errors.New("message too long for RSA key size")
+	errDecryption     = errors.New("decryption error")
+)
+
+// I2OSP переводит неотрицательное число в строку октетов длины length (RFC 8017, 4.1)
+func I2OSP(x *big.Int, length int) ([]byte, error) {
+	if x.Sign() < 0 || (x.BitLen()+7)/8 > length {
+		return nil, errors.New("integer too large")
+	}
+	return x.FillBytes(make([]byte, length)), nil
+}
+
+// OS2IP переводит строку октетов в неотрицательное число (RFC 8017, 4.2)
+func OS2IP(octets []byte) *big.Int {
+	return new(big.Int).SetBytes(octets)
+}
+
+// modulusLen возвращает длину модуля в байтах
+func (pub *RSAPublicKey) modulusLen() int {
+	return (pub.N.BitLen() + 7) / 8
+}
+
+// mgf1XOR складывает out с маской MGF1(seed) (RFC 8017, B.2.1)
+func mgf1XOR(out []byte, h hash.Hash, seed []byte) {
+	var counter [4]byte
+	var digest []byte
+
+	done := 0
+	for done < len(out) {
+		h.Reset()
+		h.Write(seed)
+		h.Write(counter[:])
+		digest = h.Sum(digest[:0])
+
+		for i := 0; i < len(digest) && done < len(out); i++ {
+			out[done] ^= digest[i]
+			done++
+		}
+
+		for i := 3; i >= 0; i-- {
+			counter[i]++
+			if counter[i] != 0 {
+				break
+			}
+		}
+	}
+}
+
+// EncryptOAEP шифрует сообщение по схеме RSAES-OAEP (RFC 8017, 7.1.1) открытым
+// ключом сервиса. Хеш-функция используется и для метки, и для MGF1.
+func (rs *RSAService) EncryptOAEP(h hash.Hash, message, label []byte) ([]byte, error) {
+	if rs.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+
+	k := rs.publicKey.modulusLen()
+	hLen := h.Size()
+	if len(message) > k-2*hLen-2 {
+		return nil, errMessageTooLong
+	}
+
+	h.Reset()
+	h.Write(label)
+	lHash := h.Sum(nil)
+
+	// EM = 0x00 || maskedSeed || maskedDB, DB = lHash || PS || 0x01 || M
+	em := make([]byte, k)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+	copy(db, lHash)
+	db[len(db)-len(message)-1] = 0x01
+	copy(db[len(db)-len(message):], message)
+
+	if _, err := io.ReadFull(rand.Reader, seed); err != nil {
+		return nil, err
+	}
+
+	mgf1XOR(db, h, seed)
+	mgf1XOR(seed, h, db)
+
+	return rs.encryptBlock(em, k)
+}
+
+// DecryptOAEP расшифровывает шифротекст RSAES-OAEP (RFC 8017, 7.1.2). Проверка
+// дополнения выполняется за постоянное время, при любой ошибке возвращается
+// одна и та же ошибка, чтобы не создавать оракул Манжера.
+func (rs *RSAService) DecryptOAEP(h hash.Hash, ciphertext, label []byte) ([]byte, error) {
+	if rs.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+
+	k := rs.publicKey.modulusLen()
+	hLen := h.Size()
+	if len(ciphertext) != k || k < 2*hLen+2 {
+		return nil, errDecryption
+	}
+
+	em, err := rs.decryptBlock(ciphertext, k)
+	if err != nil {
+		return nil, errDecryption
+	}
+
+	h.Reset()
+	h.Write(label)
+	lHash := h.Sum(nil)
+
+	firstByteIsZero := subtle.ConstantTimeByteEq(em[0], 0)
+	seed := em[1 : 1+hLen]
+	db := em[1+hLen:]
+
+	mgf1XOR(seed, h, db)
+	mgf1XOR(db, h, seed)
+
+	lHashGood := subtle.ConstantTimeCompare(db[:hLen], lHash)
+
+	// Поиск разделителя 0x01 без ветвлений, зависящих от данных
+	var lookingForIndex, index, invalid int
+	lookingForIndex = 1
+	rest := db[hLen:]
+	for i := 0; i < len(rest); i++ {
+		equals0 := subtle.ConstantTimeByteEq(rest[i], 0)
+		equals1 := subtle.ConstantTimeByteEq(rest[i], 1)
+		index = subtle.ConstantTimeSelect(lookingForIndex&equals1, i, index)
+		lookingForIndex = subtle.ConstantTimeSelect(equals1, 0, lookingForIndex)
+		invalid = subtle.ConstantTimeSelect(lookingForIndex&^equals0, 1, invalid)
+	}
+
+	if firstByteIsZero&lHashGood&^invalid&^lookingForIndex != 1 {
+		return nil, errDecryption
+	}
+
+	return append([]byte(nil), rest[index+1:]...), nil
+}
+
+// EncryptPKCS1v15 шифрует сообщение по схеме RSAES-PKCS1-v1_5 (RFC 8017, 7.2.1)
+func (rs *RSAService) EncryptPKCS1v15(message []byte) ([]byte, error) {
+	if rs.publicKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+
+	k := rs.publicKey.modulusLen()
+	if len(message) > k-11 {
+		return nil, errMessageTooLong
+	}
+
+	// EM = 0x00 || 0x02 || PS || 0x00 || M, PS - ненулевые случайные байты
+	em := make([]byte, k)
+	em[1] = 2
+	ps := em[2 : len(em)-len(message)-1]
+	if err := nonZeroRandomBytes(ps); err != nil {
+		return nil, err
+	}
+	copy(em[len(em)-len(message):], message)
+
+	return rs.encryptBlock(em, k)
+}
+
+// DecryptPKCS1v15 расшифровывает шифротекст RSAES-PKCS1-v1_5 с проверкой
+// дополнения за постоянное время
+func (rs *RSAService) DecryptPKCS1v15(ciphertext []byte) ([]byte, error) {
+	if rs.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+
+	k := rs.publicKey.modulusLen()
+	if len(ciphertext) != k || k < 11 {
+		return nil, errDecryption
+	}
+
+	em, err := rs.decryptBlock(ciphertext, k)
+	if err != nil {
+		return nil, errDecryption
+	}
+
+	firstByteIsZero := subtle.ConstantTimeByteEq(em[0], 0)
+	secondByteIsTwo := subtle.ConstantTimeByteEq(em[1], 2)
+
+	// Индекс первого нулевого байта после PS
+	var lookingForIndex, index int
+	lookingForIndex = 1
+	for i := 2; i < len(em); i++ {
+		equals0 := subtle.ConstantTimeByteEq(em[i], 0)
+		index = subtle.ConstantTimeSelect(lookingForIndex&equals0, i, index)
+		lookingForIndex = subtle.ConstantTimeSelect(equals0, 0, lookingForIndex)
+	}
+
+	// PS должно быть не короче 8 байт
+	validPS := subtle.ConstantTimeLessOrEq(2+8, index)
+
+	if firstByteIsZero&secondByteIsTwo&^lookingForIndex&validPS != 1 {
+		return nil, errDecryption
+	}
+
+	return append([]byte(nil), em[index+1:]...), nil
+}
+
+// nonZeroRandomBytes заполняет срез случайными ненулевыми байтами
+func nonZeroRandomBytes(s []byte) error {
+	if _, err := io.ReadFull(rand.Reader, s); err != nil {
+		return err
+	}
+	for i := range s {
+		for s[i] == 0 {
+			if _, err := io.ReadFull(rand.Reader, s[i:i+1]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// encryptBlock применяет RSAEP к дополненному блоку длины k
+func (rs *RSAService) encryptBlock(em []byte, k int) ([]byte, error) {
+	c, err := rs.Encrypt(OS2IP(em))
+	if err != nil {
+		return nil, err
+	}
+	return I2OSP(c, k)
+}
+
+// decryptBlock применяет RSADP к шифротексту длины k
+func (rs *RSAService) decryptBlock(ciphertext []byte, k int) ([]byte, error) {
+	m, err := rs.Decrypt(OS2IP(ciphertext))
+	if err != nil {
+		return nil, err
+	}
+	return I2OSP(m, k)
+}
@@ -0,0 +1,235 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"hash"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+var (
+	testKeyOnce sync.Once
+	testKey     *rsa.PrivateKey
+	testKeyErr  error
+)
+
+// testRSAService возвращает сервис с 2048-битным ключом crypto/rsa; ключ
+// создается один раз на весь запуск тестов
+func testRSAService(t *testing.T) (*RSAService, *rsa.PrivateKey) {
+	t.Helper()
+	testKeyOnce.Do(func() {
+		testKey, testKeyErr = rsa.GenerateKey(rand.Reader, 2048)
+	})
+	if testKeyErr != nil {
+		t.Fatal(testKeyErr)
+	}
+
+	rs := NewRSAService(TestMillerRabin, 0.9999, 1024)
+	priv, err := RSAPrivateKeyFromStdlib(testKey, rs.mathService)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := rs.SetKeys(priv.PublicKey, priv); err != nil {
+		t.Fatal(err)
+	}
+	return rs, testKey
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestI2OSP(t *testing.T) {
+	got, err := I2OSP(big.NewInt(0x0102), 4)
+	if err != nil || !bytes.Equal(got, []byte{0, 0, 1, 2}) {
+		t.Fatalf("I2OSP(0x0102, 4) = %x, %v", got, err)
+	}
+	if _, err := I2OSP(big.NewInt(0x010203), 2); err == nil {
+		t.Error("I2OSP accepted an integer that does not fit")
+	}
+	if OS2IP(got).Cmp(big.NewInt(0x0102)) != 0 {
+		t.Error("OS2IP does not invert I2OSP")
+	}
+}
+
+// Эталонные значения MGF1 (RFC 8017, B.2.1), широко используемые в тестах
+// реализаций: MGF1-SHA1("foo", 3), MGF1-SHA1("bar", 50), MGF1-SHA256("bar", 50)
+func TestMGF1KnownAnswers(t *testing.T) {
+	tests := []struct {
+		h    func() hash.Hash
+		seed string
+		want string
+	}{
+		{sha1.New, "foo", "1ac907"},
+		{sha1.New, "foo", "1ac9075cd4"},
+		{sha1.New, "bar", "bc0c655e01"},
+		{sha1.New, "bar", "bc0c655e016bc2931d85a2e675181adcef7f581f76df2739da74faac41627be2f7f415c89e983fd0ce80ced9878641cb4876"},
+		{sha256.New, "bar", "382576a7841021cc28fc4c0948753fb8312090cea942ea4c4e735d10dc724b155f9f6069f289d61daca0cb814502ef04eae1"},
+	}
+	for _, tt := range tests {
+		want := mustHex(t, tt.want)
+		out := make([]byte, len(want))
+		mgf1XOR(out, tt.h(), []byte(tt.seed))
+		if !bytes.Equal(out, want) {
+			t.Errorf("MGF1(%q, %d) = %x, want %x", tt.seed, len(want), out, want)
+		}
+	}
+}
+
+func TestOAEPRoundTrip(t *testing.T) {
+	rs, _ := testRSAService(t)
+	k := rs.publicKey.modulusLen()
+
+	for _, h := range []func() hash.Hash{sha1.New, sha256.New, sha512.New} {
+		maxLen := k - 2*h().Size() - 2
+		for _, size := range []int{0, 1, 32, maxLen} {
+			message := bytes.Repeat([]byte{0xA5}, size)
+			label := []byte("label")
+
+			ciphertext, err := rs.EncryptOAEP(h(), message, label)
+			if err != nil {
+				t.Fatalf("EncryptOAEP(%d bytes): %v", size, err)
+			}
+			plaintext, err := rs.DecryptOAEP(h(), ciphertext, label)
+			if err != nil || !bytes.Equal(plaintext, message) {
+				t.Fatalf("DecryptOAEP(%d bytes) = %x, %v", size, plaintext, err)
+			}
+			if _, err := rs.DecryptOAEP(h(), ciphertext, []byte("other")); err != errDecryption {
+				t.Errorf("wrong label: %v, want errDecryption", err)
+			}
+		}
+		if _, err := rs.EncryptOAEP(h(), make([]byte, maxLen+1), nil); err != errMessageTooLong {
+			t.Errorf("message of %d bytes: %v, want errMessageTooLong", maxLen+1, err)
+		}
+	}
+}
+
+func TestOAEPIsRandomized(t *testing.T) {
+	rs, _ := testRSAService(t)
+	c1, _ := rs.EncryptOAEP(sha256.New(), []byte("same"), nil)
+	c2, _ := rs.EncryptOAEP(sha256.New(), []byte("same"), nil)
+	if bytes.Equal(c1, c2) {
+		t.Error("two OAEP encryptions of the same message are equal")
+	}
+}
+
+func TestOAEPInteropWithStdlib(t *testing.T) {
+	rs, std := testRSAService(t)
+	message := []byte("interop with crypto/rsa")
+	label := []byte("lab_2")
+
+	ours, err := rs.EncryptOAEP(sha256.New(), message, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err := rsa.DecryptOAEP(sha256.New(), nil, std, ours, label)
+	if err != nil || !bytes.Equal(plaintext, message) {
+		t.Fatalf("crypto/rsa cannot decrypt our OAEP: %v", err)
+	}
+
+	theirs, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &std.PublicKey, message, label)
+	if err != nil {
+		t.Fatal(err)
+	}
+	plaintext, err = rs.DecryptOAEP(sha256.New(), theirs, label)
+	if err != nil || !bytes.Equal(plaintext, message) {
+		t.Fatalf("cannot decrypt crypto/rsa OAEP: %v", err)
+	}
+}
+
+func TestOAEPRejectsTampering(t *testing.T) {
+	rs, _ := testRSAService(t)
+	ciphertext, _ := rs.EncryptOAEP(sha256.New(), []byte("tamper"), nil)
+
+	for _, i := range []int{0, len(ciphertext) / 2, len(ciphertext) - 1} {
+		bad := append([]byte(nil), ciphertext...)
+		bad[i] ^= 0x01
+		if _, err := rs.DecryptOAEP(sha256.New(), bad, nil); err != errDecryption {
+			t.Errorf("flipped byte %d: %v, want errDecryption", i, err)
+		}
+	}
+	if _, err := rs.DecryptOAEP(sha256.New(), ciphertext[1:], nil); err != errDecryption {
+		t.Errorf("short ciphertext: %v, want errDecryption", err)
+	}
+}
+
+func TestPKCS1v15EncryptionInterop(t *testing.T) {
+	rs, std := testRSAService(t)
+	k := rs.publicKey.modulusLen()
+
+	for _, size := range []int{0, 16, k - 11} {
+		message := bytes.Repeat([]byte{0x5A}, size)
+
+		ours, err := rs.EncryptPKCS1v15(message)
+		if err != nil {
+			t.Fatalf("EncryptPKCS1v15(%d bytes): %v", size, err)
+		}
+		plaintext, err := rsa.DecryptPKCS1v15(nil, std, ours)
+		if err != nil || !bytes.Equal(plaintext, message) {
+			t.Fatalf("crypto/rsa cannot decrypt our PKCS#1 v1.5 (%d bytes): %v", size, err)
+		}
+
+		theirs, err := rsa.EncryptPKCS1v15(rand.Reader, &std.PublicKey, message)
+		if err != nil {
+			t.Fatal(err)
+		}
+		plaintext, err = rs.DecryptPKCS1v15(theirs)
+		if err != nil || !bytes.Equal(plaintext, message) {
+			t.Fatalf("cannot decrypt crypto/rsa PKCS#1 v1.5 (%d bytes): %v", size, err)
+		}
+	}
+	if _, err := rs.EncryptPKCS1v15(make([]byte, k-10)); err != errMessageTooLong {
+		t.Errorf("oversized message: %v, want errMessageTooLong", err)
+	}
+}
+
+// TestPKCS1v15RejectsMalformedPadding шифрует "сырые" блоки EM с нарушенной
+// структурой и проверяет, что все они отвергаются одной и той же ошибкой
+func TestPKCS1v15RejectsMalformedPadding(t *testing.T) {
+	rs, _ := testRSAService(t)
+	k := rs.publicKey.modulusLen()
+
+	valid := func() []byte {
+		em := make([]byte, k)
+		em[1] = 2
+		for i := 2; i < k-5; i++ {
+			em[i] = 0xFF
+		}
+		copy(em[k-4:], "msg!")
+		return em // 0x00 || 0x02 || PS || 0x00 || "msg!"
+	}
+	cases := map[string]func([]byte){
+		"first byte":      func(em []byte) { em[0] = 1 },
+		"block type":      func(em []byte) { em[1] = 1 },
+		"no separator":    func(em []byte) { em[k-5] = 0xFF },
+		"short padding":   func(em []byte) { em[5] = 0 },
+		"zero right away": func(em []byte) { em[2] = 0 },
+	}
+	for name, corrupt := range cases {
+		em := valid()
+		corrupt(em)
+		c := new(big.Int).Exp(OS2IP(em), rs.publicKey.E, rs.publicKey.N)
+		ciphertext, _ := I2OSP(c, k)
+		if _, err := rs.DecryptPKCS1v15(ciphertext); err != errDecryption {
+			t.Errorf("%s: %v, want errDecryption", name, err)
+		}
+	}
+
+	c := new(big.Int).Exp(OS2IP(valid()), rs.publicKey.E, rs.publicKey.N)
+	ciphertext, _ := I2OSP(c, k)
+	if plaintext, err := rs.DecryptPKCS1v15(ciphertext); err != nil || len(plaintext) != 4 {
+		t.Errorf("well-formed block rejected: %x, %v", plaintext, err)
+	}
+}
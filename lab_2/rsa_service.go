@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
+	"fmt"
 	"math/big"
+
+	"lab_4/primes"
 )
 
 type PrimalityTestType int
@@ -12,6 +16,9 @@ const (
 	TestFermat PrimalityTestType = iota
 	TestSolovayStrassen
 	TestMillerRabin
+	TestStrongLucas
+	TestBPSW
+	TestDeterministicMillerRabin
 )
 
 type RSAKeyGenerator struct {
@@ -19,90 +26,117 @@ type RSAKeyGenerator struct {
 	primalityTest  PrimalityTest
 	minProbability float64
 	bitLength      int
-}
 
-func NewRSAKeyGenerator(testType PrimalityTestType, minProbability float64, bitLength int, ms *MathService) *RSAKeyGenerator {
-	var test PrimalityTest
+	provable bool // доказуемо простые числа вместо вероятностного теста
+	workers  int  // потоков поиска простых; <= 0 - по числу процессоров
+
+	policy *KeyGenPolicy // длина модуля, число простых, e и проверки ключа
+
+	lastStats        []PrimeGenerationStats // статистика последней генерации ключей
+	lastCertificates []*primes.Certificate  // сертификаты простоты при provable
+	lastRetries      []KeyGenRetry          // причины повторов последней генерации
+}
 
+// NewPrimalityTest создает тест простоты заданного типа (по умолчанию Миллер-Рабин)
+func NewPrimalityTest(testType PrimalityTestType, ms *MathService) PrimalityTest {
 	switch testType {
 	case TestFermat:
-		test = NewFermatTest(ms)
+		return NewFermatTest(ms)
 	case TestSolovayStrassen:
-		test = NewSolovayStrassenTest(ms)
+		return NewSolovayStrassenTest(ms)
 	case TestMillerRabin:
-		test = NewMillerRabinTest(ms)
+		return NewMillerRabinTest(ms)
+	case TestStrongLucas:
+		return NewStrongLucasTest(ms)
+	case TestBPSW:
+		return NewBPSWTest(ms)
+	case TestDeterministicMillerRabin:
+		return NewDeterministicMillerRabinTest(ms)
 	default:
-		test = NewMillerRabinTest(ms)
+		return NewMillerRabinTest(ms)
 	}
+}
 
+func NewRSAKeyGenerator(testType PrimalityTestType, minProbability float64, bitLength int, ms *MathService) *RSAKeyGenerator {
 	return &RSAKeyGenerator{
 		mathService:    ms,
-		primalityTest:  test,
+		primalityTest:  NewPrimalityTest(testType, ms),
 		minProbability: minProbability,
 		bitLength:      bitLength,
+		policy:         NewKeyGenPolicy(2 * bitLength),
 	}
 }
 
-func (kg *RSAKeyGenerator) generatePrime() (*big.Int, error) {
-	for {
-		candidate, err := rand.Prime(rand.Reader, kg.bitLength) // дает вероятно простое
-		if err != nil {
-			return nil, err
-		}
+func (kg *RSAKeyGenerator) GenerateKeyPair() (*RSAPublicKey, *RSAPrivateKey, error) {
+	return kg.GenerateKeyPairContext(context.Background())
+}
 
-		if kg.primalityTest.IsProbablyPrime(candidate, kg.minProbability) {
-			return candidate, nil
-		}
+// GenerateKeyPairContext генерирует пару ключей по политике генератора;
+// отмена ctx прерывает поиск простых. Каждый отброшенный кандидат
+// записывается в LastRetries с нарушенным правилом.
+func (kg *RSAKeyGenerator) GenerateKeyPairContext(ctx context.Context) (*RSAPublicKey, *RSAPrivateKey, error) {
+	policy := kg.policy
+	if err := policy.Validate(); err != nil {
+		return nil, nil, err
 	}
-}
 
-func (kg *RSAKeyGenerator) GenerateKeyPair() (*RSAPublicKey, *RSAPrivateKey, error) {
-	for {
-		p, err := kg.generatePrime()
-		if err != nil {
-			return nil, nil, err
+	kg.lastStats = nil
+	kg.lastCertificates = nil
+	kg.lastRetries = nil
+	e := new(big.Int).Set(policy.PublicExponent)
+	one := big.NewInt(1)
+
+	for attempt := 1; ; attempt++ {
+		factors := make([]*big.Int, 0, policy.PrimeCount)
+		for _, bits := range policy.primeSizes() {
+			for {
+				r, stats, err := kg.generatePrime(ctx, bits)
+				if err != nil {
+					return nil, nil, err
+				}
+				kg.lastStats = append(kg.lastStats, stats)
+
+				// d существует, только если e обратима по модулю каждого r - 1
+				if kg.mathService.GCD(e, new(big.Int).Sub(r, one)).Cmp(one) != 0 {
+					kg.retry(attempt, RuleExponentCoprime, fmt.Sprintf("простое r%d", len(factors)+1))
+					continue
+				}
+				factors = append(factors, r)
+				break
+			}
 		}
 
-		q, err := kg.generatePrime()
-		if err != nil {
-			return nil, nil, err
+		// Защита от атаки Ферма: простые не должны быть близки друг к другу
+		if detail, ok := policy.checkPrimeDistance(factors); !ok {
+			kg.retry(attempt, RulePrimeDistance, detail)
+			continue
 		}
 
-		// Защита от атаки Ферма: |p - q| должно быть достаточно большим
-		diff := new(big.Int).Sub(p, q)
-		diff.Abs(diff)
-		minDiff := new(big.Int).Exp(big.NewInt(2), big.NewInt(int64(kg.bitLength/2-100)), nil)
-		// для защиты можно генерить новую пару, если не прошло условие
-
-		if diff.Cmp(minDiff) < 0 {
+		n := big.NewInt(1)
+		phi := big.NewInt(1)
+		for _, r := range factors {
+			n.Mul(n, r)
+			phi.Mul(phi, new(big.Int).Sub(r, one))
+		}
+		if n.BitLen() != policy.ModulusBits {
+			kg.retry(attempt, RuleModulusSize, fmt.Sprintf("%d бит вместо %d", n.BitLen(), policy.ModulusBits))
 			continue
 		}
 
-		n := new(big.Int).Mul(p, q)
-
-		// φ(n) = (p-1)(q-1)
-		phi := new(big.Int).Mul(
-			new(big.Int).Sub(p, big.NewInt(1)),
-			new(big.Int).Sub(q, big.NewInt(1)),
-		)
-
-		// Выбираем e = 65537 (стандартное значение)
-		e := big.NewInt(65537)
-
-		// Вычисляем d
-		gcd, d, _ := kg.mathService.ExtendedGCD(e, phi)
-		if gcd.Cmp(big.NewInt(1)) != 0 {
+		d, err := kg.mathService.ModInverse(e, phi)
+		if err != nil {
+			kg.retry(attempt, RuleExponentCoprime, "e необратима по модулю phi(n)")
 			continue
 		}
 
-		if d.Sign() < 0 {
-			d.Add(d, phi)
+		// Защита от атаки Винера: d > n^WienerBound
+		if log2Big(d) <= policy.WienerBound*log2Big(n) {
+			kg.retry(attempt, RuleWienerBound, fmt.Sprintf("d < n^%.3f", policy.WienerBound))
+			continue
 		}
-
-		// Защита от атаки Винера: d должно быть > n^0.25
-		// Проверяем: d > n^0.25
-		nSqrtSqrt := new(big.Int).Sqrt(new(big.Int).Sqrt(n))
-		if d.Cmp(nSqrtSqrt) <= 0 {
+		// и от более сильной атаки Боне-Дерфи: d > n^0.292
+		if policy.BonehDurfeeCheck && BelowBonehDurfeeBound(n, d) {
+			kg.retry(attempt, RuleBonehDurfee, fmt.Sprintf("d < n^%.3f", BonehDurfeeExponent))
 			continue
 		}
 
@@ -110,14 +144,80 @@ func (kg *RSAKeyGenerator) GenerateKeyPair() (*RSAPublicKey, *RSAPrivateKey, err
 		privateKey := &RSAPrivateKey{
 			PublicKey: publicKey,
 			D:         d,
-			P:         p,
-			Q:         q,
+			P:         factors[0],
+			Q:         factors[1],
+		}
+		for _, r := range factors[2:] {
+			privateKey.AdditionalPrimes = append(privateKey.AdditionalPrimes, CRTPrime{Prime: r})
+		}
+		if err := privateKey.Precompute(kg.mathService); err != nil {
+			kg.retry(attempt, RuleCRT, err.Error())
+			continue
 		}
 
+		kg.keepCertificates(factors)
 		return publicKey, privateKey, nil
 	}
 }
 
+// keepCertificates оставляет только сертификаты простых итогового ключа
+func (kg *RSAKeyGenerator) keepCertificates(keyPrimes []*big.Int) {
+	var kept []*primes.Certificate
+	for _, r := range keyPrimes {
+		for _, cert := range kg.lastCertificates {
+			if cert.N.Cmp(r) == 0 {
+				kept = append(kept, cert)
+				break
+			}
+		}
+	}
+	kg.lastCertificates = kept
+}
+
+func (kg *RSAKeyGenerator) retry(attempt int, rule PolicyRule, detail string) {
+	kg.lastRetries = append(kg.lastRetries, KeyGenRetry{Attempt: attempt, Rule: rule, Detail: detail})
+}
+
+// SetPolicy задает политику генерации ключей
+func (kg *RSAKeyGenerator) SetPolicy(policy *KeyGenPolicy) error {
+	if policy == nil {
+		return errors.New("policy is nil")
+	}
+	if err := policy.Validate(); err != nil {
+		return err
+	}
+	p := *policy
+	p.PublicExponent = new(big.Int).Set(policy.PublicExponent)
+	kg.policy = &p
+	return nil
+}
+
+// Policy возвращает копию текущей политики генерации ключей
+func (kg *RSAKeyGenerator) Policy() *KeyGenPolicy {
+	p := *kg.policy
+	p.PublicExponent = new(big.Int).Set(kg.policy.PublicExponent)
+	return &p
+}
+
+// SetPublicExponent задает открытую экспоненту e (нечетная, 3 <= e < 2^256)
+func (kg *RSAKeyGenerator) SetPublicExponent(e *big.Int) error {
+	policy := kg.Policy()
+	policy.PublicExponent = e
+	return kg.SetPolicy(policy)
+}
+
+// SetPrimeCount задает число простых множителей модуля (многопростой RSA)
+func (kg *RSAKeyGenerator) SetPrimeCount(count int) error {
+	policy := kg.Policy()
+	policy.PrimeCount = count
+	return kg.SetPolicy(policy)
+}
+
+// LastRetries возвращает причины повторов при последнем вызове GenerateKeyPair
+func (kg *RSAKeyGenerator) LastRetries() []KeyGenRetry {
+	return append([]KeyGenRetry(nil), kg.lastRetries...)
+}
+
 type RSAPublicKey struct {
 	N *big.Int
 	E *big.Int
@@ -128,6 +228,75 @@ type RSAPrivateKey struct {
 	D         *big.Int
 	P         *big.Int
 	Q         *big.Int
+
+	// Параметры Китайской теоремы об остатках
+	Dp   *big.Int // d mod (p-1)
+	Dq   *big.Int // d mod (q-1)
+	Qinv *big.Int // q^(-1) mod p
+
+	// Третий и последующие простые многопростого RSA (RFC 8017, OtherPrimeInfo)
+	AdditionalPrimes []CRTPrime
+}
+
+// CRTPrime дополнительный простой множитель r_i с параметрами КТО:
+// d_i = d mod (r_i - 1), t_i = (r_1 * ... * r_(i-1))^(-1) mod r_i
+type CRTPrime struct {
+	Prime *big.Int
+	Exp   *big.Int
+	Coeff *big.Int
+}
+
+// crtComplete сообщает, что параметры КТО дополнительных простых вычислены
+func (key *RSAPrivateKey) crtComplete() bool {
+	for _, r := range key.AdditionalPrimes {
+		if r.Exp == nil || r.Coeff == nil {
+			return false
+		}
+	}
+	return true
+}
+
+// Primes возвращает все простые множители модуля
+func (key *RSAPrivateKey) Primes() []*big.Int {
+	list := []*big.Int{key.P, key.Q}
+	for _, r := range key.AdditionalPrimes {
+		list = append(list, r.Prime)
+	}
+	return list
+}
+
+// Precompute вычисляет параметры КТО (dP, dQ, qInv) по p, q и d
+func (key *RSAPrivateKey) Precompute(ms *MathService) error {
+	if key.P == nil || key.Q == nil || key.D == nil {
+		return errors.New("private key has no prime factors")
+	}
+
+	one := big.NewInt(1)
+	key.Dp = new(big.Int).Mod(key.D, new(big.Int).Sub(key.P, one))
+	key.Dq = new(big.Int).Mod(key.D, new(big.Int).Sub(key.Q, one))
+
+	gcd, qInv, _ := ms.ExtendedGCD(new(big.Int).Mod(key.Q, key.P), key.P)
+	if gcd.Cmp(one) != 0 {
+		return errors.New("q is not invertible modulo p")
+	}
+	key.Qinv = qInv.Mod(qInv, key.P)
+
+	// Коэффициенты Гарнера для дополнительных простых
+	product := new(big.Int).Mul(key.P, key.Q)
+	for i := range key.AdditionalPrimes {
+		r := &key.AdditionalPrimes[i]
+		if r.Prime == nil {
+			return errors.New("additional prime is missing")
+		}
+		r.Exp = new(big.Int).Mod(key.D, new(big.Int).Sub(r.Prime, one))
+		coeff, err := ms.ModInverse(product, r.Prime)
+		if err != nil {
+			return fmt.Errorf("additional prime %d is not coprime to the others", i+3)
+		}
+		r.Coeff = coeff
+		product.Mul(product, r.Prime)
+	}
+	return nil
 }
 
 type RSAService struct {
@@ -135,6 +304,10 @@ type RSAService struct {
 	mathService  *MathService
 	publicKey    *RSAPublicKey
 	privateKey   *RSAPrivateKey
+
+	faultCheck    bool                       // проверять результат КТО открытой экспонентой
+	faultInjector func(mq *big.Int) *big.Int // искажение половины по модулю q, задается только signWithFault
+	blinding      bool                       // маскировать вход закрытой операции случайным r^e
 }
 
 func NewRSAService(testType PrimalityTestType, minProbability float64, bitLength int) *RSAService {
@@ -144,11 +317,32 @@ func NewRSAService(testType PrimalityTestType, minProbability float64, bitLength
 	return &RSAService{
 		keyGenerator: kg,
 		mathService:  ms,
+		faultCheck:   true,
+		blinding:     true,
 	}
 }
 
+// SetBlinding включает или выключает ослепление входа закрытой операции:
+// вместо x обрабатывается x * r^e mod n со случайным r, а результат
+// умножается на r^(-1). Время операции перестает зависеть от входных данных,
+// выбранных противником. По умолчанию включено.
+func (rs *RSAService) SetBlinding(enabled bool) {
+	rs.blinding = enabled
+}
+
+// SetFaultCheck включает или выключает проверку результата закрытой операции
+// открытой экспонентой (защита от атаки Bellcore). По умолчанию включена.
+func (rs *RSAService) SetFaultCheck(enabled bool) {
+	rs.faultCheck = enabled
+}
+
 func (rs *RSAService) GenerateKeys() error {
-	pubKey, privKey, err := rs.keyGenerator.GenerateKeyPair()
+	return rs.GenerateKeysContext(context.Background())
+}
+
+// GenerateKeysContext генерирует ключи с возможностью отмены через ctx
+func (rs *RSAService) GenerateKeysContext(ctx context.Context) error {
+	pubKey, privKey, err := rs.keyGenerator.GenerateKeyPairContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -158,6 +352,66 @@ func (rs *RSAService) GenerateKeys() error {
 	return nil
 }
 
+// SetKeys устанавливает ранее созданные или импортированные ключи.
+// privateKey может быть nil, если сервис используется только для шифрования.
+func (rs *RSAService) SetKeys(publicKey *RSAPublicKey, privateKey *RSAPrivateKey) error {
+	if publicKey == nil || publicKey.N == nil || publicKey.E == nil {
+		return errors.New("invalid public key")
+	}
+	if privateKey != nil {
+		if privateKey.PublicKey == nil || privateKey.PublicKey.N.Cmp(publicKey.N) != 0 {
+			return errors.New("private key does not match public key")
+		}
+		if privateKey.Dp == nil && privateKey.P != nil && privateKey.Q != nil {
+			if err := privateKey.Precompute(rs.mathService); err != nil {
+				return err
+			}
+		}
+	}
+
+	rs.publicKey = publicKey
+	rs.privateKey = privateKey
+	return nil
+}
+
+// KeyGenerationStats возвращает статистику генерации простых чисел при
+// последнем вызове GenerateKeys
+func (rs *RSAService) KeyGenerationStats() []PrimeGenerationStats {
+	return rs.keyGenerator.LastStats()
+}
+
+// SetKeyGenerationWorkers задает число потоков поиска простых чисел
+func (rs *RSAService) SetKeyGenerationWorkers(workers int) {
+	rs.keyGenerator.SetWorkers(workers)
+}
+
+// SetKeyGenPolicy задает политику генерации ключей (длина модуля, число
+// простых, e, минимальное |p - q| и граница Винера)
+func (rs *RSAService) SetKeyGenPolicy(policy *KeyGenPolicy) error {
+	return rs.keyGenerator.SetPolicy(policy)
+}
+
+// KeyGenPolicy возвращает копию текущей политики генерации ключей
+func (rs *RSAService) KeyGenPolicy() *KeyGenPolicy {
+	return rs.keyGenerator.Policy()
+}
+
+// KeyGenerationRetries возвращает причины повторов при последнем вызове GenerateKeys
+func (rs *RSAService) KeyGenerationRetries() []KeyGenRetry {
+	return rs.keyGenerator.LastRetries()
+}
+
+// SetProvablePrimes включает генерацию ключей из доказуемо простых чисел
+func (rs *RSAService) SetProvablePrimes(enabled bool) {
+	rs.keyGenerator.SetProvablePrimes(enabled)
+}
+
+// PrimeCertificates возвращает сертификаты простоты p и q, если ключи
+// сгенерированы с SetProvablePrimes(true)
+func (rs *RSAService) PrimeCertificates() []*primes.Certificate {
+	return rs.keyGenerator.LastCertificates()
+}
+
 func (rs *RSAService) GetPublicKey() *RSAPublicKey {
 	return rs.publicKey
 }
@@ -184,6 +438,138 @@ func (rs *RSAService) Decrypt(ciphertext *big.Int) (*big.Int, error) {
 		return nil, errors.New("keys not generated")
 	}
 
-	message := rs.mathService.ModPow(ciphertext, rs.privateKey.D, rs.publicKey.N)
-	return message, nil
+	if ciphertext.Sign() < 0 || ciphertext.Cmp(rs.publicKey.N) >= 0 {
+		return nil, errors.New("ciphertext out of range")
+	}
+
+	return rs.privateOperation(ciphertext)
+}
+
+// Sign вычисляет подпись s = m^d mod n (без хеширования и дополнения)
+func (rs *RSAService) Sign(message *big.Int) (*big.Int, error) {
+	if rs.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+
+	if message.Sign() < 0 || message.Cmp(rs.publicKey.N) >= 0 {
+		return nil, errors.New("message too large")
+	}
+
+	return rs.privateOperation(message)
+}
+
+// Verify проверяет подпись: s^e mod n == m
+func (rs *RSAService) Verify(message, signature *big.Int) (bool, error) {
+	if rs.publicKey == nil {
+		return false, errors.New("keys not generated")
+	}
+
+	if signature.Sign() < 0 || signature.Cmp(rs.publicKey.N) >= 0 {
+		return false, nil
+	}
+
+	recovered := rs.mathService.ModPow(signature, rs.publicKey.E, rs.publicKey.N)
+	return recovered.Cmp(message) == 0, nil
+}
+
+// privateOperation возводит x в степень d по модулю n. При включенном
+// ослеплении вход предварительно маскируется случайным множителем.
+func (rs *RSAService) privateOperation(x *big.Int) (*big.Int, error) {
+	if !rs.blinding {
+		return rs.rawPrivateOperation(x)
+	}
+
+	n := rs.publicKey.N
+	r, rInv, err := rs.blindingFactor()
+	if err != nil {
+		return nil, err
+	}
+
+	// x' = x * r^e mod n, тогда x'^d = x^d * r mod n
+	blinded := rs.mathService.ModPow(r, rs.publicKey.E, n)
+	blinded.Mul(blinded, x)
+	blinded.Mod(blinded, n)
+
+	result, err := rs.rawPrivateOperation(blinded)
+	if err != nil {
+		return nil, err
+	}
+	result.Mul(result, rInv)
+	return result.Mod(result, n), nil
+}
+
+// blindingFactor выбирает случайное r из [2, n-1], обратимое по модулю n,
+// и возвращает r и r^(-1) mod n
+func (rs *RSAService) blindingFactor() (*big.Int, *big.Int, error) {
+	n := rs.publicKey.N
+	limit := new(big.Int).Sub(n, big.NewInt(2))
+	for {
+		r, err := rand.Int(rand.Reader, limit)
+		if err != nil {
+			return nil, nil, err
+		}
+		r.Add(r, big.NewInt(2))
+
+		rInv := new(big.Int).ModInverse(r, n)
+		if rInv != nil {
+			return r, rInv, nil
+		}
+	}
+}
+
+// rawPrivateOperation возводит x в степень d по модулю n лестницей Монтгомери
+// с постоянным временем. При наличии параметров КТО вычисления ведутся
+// по модулям p и q (примерно в 4 раза быстрее), а результат проверяется
+// открытой экспонентой.
+func (rs *RSAService) rawPrivateOperation(x *big.Int) (*big.Int, error) {
+	key := rs.privateKey
+	if key.Dp == nil || key.Dq == nil || key.Qinv == nil || !key.crtComplete() {
+		return rs.mathService.ModPowConstantTime(x, key.D, rs.publicKey.N)
+	}
+
+	// m1 = x^dP mod p, m2 = x^dQ mod q
+	m1, err := rs.mathService.ModPowConstantTime(x, key.Dp, key.P)
+	if err != nil {
+		return nil, err
+	}
+	m2, err := rs.mathService.ModPowConstantTime(x, key.Dq, key.Q)
+	if err != nil {
+		return nil, err
+	}
+	if rs.faultInjector != nil {
+		m2 = rs.faultInjector(m2)
+	}
+
+	// h = qInv * (m1 - m2) mod p, m = m2 + h*q
+	h := new(big.Int).Sub(m1, m2)
+	h.Mul(h, key.Qinv)
+	h.Mod(h, key.P)
+
+	result := new(big.Int).Mul(h, key.Q)
+	result.Add(result, m2)
+
+	// Дополнительные простые: m = m + R * ((m_i - m) * t_i mod r_i), R = r_1 * ... * r_(i-1)
+	if len(key.AdditionalPrimes) > 0 {
+		product := new(big.Int).Mul(key.P, key.Q)
+		for _, r := range key.AdditionalPrimes {
+			mi, err := rs.mathService.ModPowConstantTime(x, r.Exp, r.Prime)
+			if err != nil {
+				return nil, err
+			}
+			h.Sub(mi, result)
+			h.Mul(h, r.Coeff)
+			h.Mod(h, r.Prime)
+			result.Add(result, h.Mul(h, product))
+			product.Mul(product, r.Prime)
+		}
+	}
+
+	if rs.faultCheck {
+		check := rs.mathService.ModPow(result, rs.publicKey.E, rs.publicKey.N)
+		if check.Cmp(x) != 0 {
+			return nil, errors.New("fault detected in CRT computation")
+		}
+	}
+
+	return result, nil
 }
@@ -0,0 +1,341 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SignatureScheme схема дополнения подписи RSA
+type SignatureScheme int
+
+const (
+	SchemePKCS1v15 SignatureScheme = iota // RSASSA-PKCS1-v1_5
+	SchemePSS                             // RSASSA-PSS
+)
+
+func (s SignatureScheme) String() string {
+	switch s {
+	case SchemePKCS1v15:
+		return "PKCS#1 v1.5"
+	case SchemePSS:
+		return "PSS"
+	default:
+		return "Unknown"
+	}
+}
+
+var errVerification = errors.New("verification error")
+
+// digestInfoPrefixes префиксы DER-структуры DigestInfo для EMSA-PKCS1-v1_5 (RFC 8017, 9.2)
+var digestInfoPrefixes = map[crypto.Hash][]byte{
+	crypto.SHA256: {0x30, 0x31, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x01, 0x05, 0x00, 0x04, 0x20},
+	crypto.SHA384: {0x30, 0x41, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x02, 0x05, 0x00, 0x04, 0x30},
+	crypto.SHA512: {0x30, 0x51, 0x30, 0x0d, 0x06, 0x09, 0x60, 0x86, 0x48, 0x01, 0x65, 0x03, 0x04, 0x02, 0x03, 0x05, 0x00, 0x04, 0x40},
+}
+
+// checkDigest проверяет, что хеш поддерживается и дайджест имеет нужную длину
+func checkDigest(hash crypto.Hash, digest []byte) error {
+	if _, ok := digestInfoPrefixes[hash]; !ok {
+		return fmt.Errorf("unsupported hash function: %v", hash)
+	}
+	if len(digest) != hash.Size() {
+		return errors.New("digest length does not match hash function")
+	}
+	return nil
+}
+
+// emsaPKCS1v15Encode строит EM = 0x00 || 0x01 || PS (0xff) || 0x00 || DigestInfo
+func emsaPKCS1v15Encode(hash crypto.Hash, digest []byte, k int) ([]byte, error) {
+	prefix := digestInfoPrefixes[hash]
+	tLen := len(prefix) + len(digest)
+	if k < tLen+11 {
+		return nil, errMessageTooLong
+	}
+
+	em := make([]byte, k)
+	em[1] = 1
+	for i := 2; i < k-tLen-1; i++ {
+		em[i] = 0xff
+	}
+	copy(em[k-tLen:], prefix)
+	copy(em[k-len(digest):], digest)
+	return em, nil
+}
+
+// SignPKCS1v15 подписывает дайджест по схеме RSASSA-PKCS1-v1_5 (RFC 8017, 8.2.1)
+func (rs *RSAService) SignPKCS1v15(hash crypto.Hash, digest []byte) ([]byte, error) {
+	if rs.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	if err := checkDigest(hash, digest); err != nil {
+		return nil, err
+	}
+
+	k := rs.publicKey.modulusLen()
+	em, err := emsaPKCS1v15Encode(hash, digest, k)
+	if err != nil {
+		return nil, err
+	}
+
+	s, err := rs.Sign(OS2IP(em))
+	if err != nil {
+		return nil, err
+	}
+	return I2OSP(s, k)
+}
+
+// VerifyPKCS1v15 проверяет подпись RSASSA-PKCS1-v1_5 (RFC 8017, 8.2.2)
+func (rs *RSAService) VerifyPKCS1v15(hash crypto.Hash, digest, signature []byte) error {
+	if rs.publicKey == nil {
+		return errors.New("keys not generated")
+	}
+	if err := checkDigest(hash, digest); err != nil {
+		return err
+	}
+
+	k := rs.publicKey.modulusLen()
+	em, err := rs.openSignature(signature, k)
+	if err != nil {
+		return err
+	}
+
+	expected, err := emsaPKCS1v15Encode(hash, digest, k)
+	if err != nil {
+		return err
+	}
+	if subtle.ConstantTimeCompare(em, expected) != 1 {
+		return errVerification
+	}
+	return nil
+}
+
+// pssSaltLength длина соли PSS: длина хеша, но не больше, чем позволяет модуль
+func pssSaltLength(hLen, emLen int) int {
+	if maxSalt := emLen - hLen - 2; maxSalt < hLen {
+		return maxSalt
+	}
+	return hLen
+}
+
+// SignPSS подписывает дайджест по схеме RSASSA-PSS (RFC 8017, 8.1.1) с MGF1
+// на той же хеш-функции. Длина соли равна длине хеша (или максимально
+// возможной для малых модулей).
+func (rs *RSAService) SignPSS(hash crypto.Hash, digest []byte) ([]byte, error) {
+	if rs.privateKey == nil {
+		return nil, errors.New("keys not generated")
+	}
+	if err := checkDigest(hash, digest); err != nil {
+		return nil, err
+	}
+
+	k := rs.publicKey.modulusLen()
+	emBits := rs.publicKey.N.BitLen() - 1
+	emLen := (emBits + 7) / 8
+	hLen := hash.Size()
+	sLen := pssSaltLength(hLen, emLen)
+	if sLen < 0 {
+		return nil, errMessageTooLong
+	}
+
+	salt := make([]byte, sLen)
+	if _, err := io.ReadFull(rand.Reader, salt); err != nil {
+		return nil, err
+	}
+
+	// H = Hash(0x00 * 8 || mHash || salt)
+	h := hash.New()
+	h.Write(make([]byte, 8))
+	h.Write(digest)
+	h.Write(salt)
+	mPrimeHash := h.Sum(nil)
+
+	// EM = maskedDB || H || 0xbc, DB = PS || 0x01 || salt
+	em := make([]byte, emLen)
+	db := em[:emLen-hLen-1]
+	db[len(db)-sLen-1] = 0x01
+	copy(db[len(db)-sLen:], salt)
+	copy(em[emLen-hLen-1:], mPrimeHash)
+	em[emLen-1] = 0xbc
+
+	mgf1XOR(db, hash.New(), mPrimeHash)
+	db[0] &= 0xff >> uint(8*emLen-emBits)
+
+	s, err := rs.Sign(OS2IP(em))
+	if err != nil {
+		return nil, err
+	}
+	return I2OSP(s, k)
+}
+
+// VerifyPSS проверяет подпись RSASSA-PSS (RFC 8017, 8.1.2). Длина соли
+// определяется автоматически, поэтому принимаются подписи с любой солью.
+func (rs *RSAService) VerifyPSS(hash crypto.Hash, digest, signature []byte) error {
+	if rs.publicKey == nil {
+		return errors.New("keys not generated")
+	}
+	if err := checkDigest(hash, digest); err != nil {
+		return err
+	}
+
+	k := rs.publicKey.modulusLen()
+	emBits := rs.publicKey.N.BitLen() - 1
+	emLen := (emBits + 7) / 8
+	hLen := hash.Size()
+
+	m, err := rs.openSignature(signature, k)
+	if err != nil {
+		return err
+	}
+	// При emLen < k старший байт представителя обязан быть нулевым
+	if emLen < k {
+		if m[0] != 0 {
+			return errVerification
+		}
+		m = m[1:]
+	}
+
+	em := m
+	if emLen < hLen+2 || em[emLen-1] != 0xbc {
+		return errVerification
+	}
+
+	db := append([]byte(nil), em[:emLen-hLen-1]...)
+	mPrimeHash := em[emLen-hLen-1 : emLen-1]
+
+	topMask := byte(0xff >> uint(8*emLen-emBits))
+	if db[0]&^topMask != 0 {
+		return errVerification
+	}
+
+	mgf1XOR(db, hash.New(), mPrimeHash)
+	db[0] &= topMask
+
+	// DB = PS (нули) || 0x01 || salt
+	separator := bytes.IndexByte(db, 0x01)
+	if separator < 0 {
+		return errVerification
+	}
+	for _, b := range db[:separator] {
+		if b != 0 {
+			return errVerification
+		}
+	}
+	salt := db[separator+1:]
+
+	h := hash.New()
+	h.Write(make([]byte, 8))
+	h.Write(digest)
+	h.Write(salt)
+	if subtle.ConstantTimeCompare(h.Sum(nil), mPrimeHash) != 1 {
+		return errVerification
+	}
+	return nil
+}
+
+// openSignature применяет RSAVP1 и возвращает представитель сообщения длины k
+func (rs *RSAService) openSignature(signature []byte, k int) ([]byte, error) {
+	if len(signature) != k {
+		return nil, errVerification
+	}
+
+	s := OS2IP(signature)
+	if s.Cmp(rs.publicKey.N) >= 0 {
+		return nil, errVerification
+	}
+
+	m := rs.mathService.ModPow(s, rs.publicKey.E, rs.publicKey.N)
+	return I2OSP(m, k)
+}
+
+// SignDigest подписывает дайджест выбранной схемой
+func (rs *RSAService) SignDigest(scheme SignatureScheme, hash crypto.Hash, digest []byte) ([]byte, error) {
+	switch scheme {
+	case SchemePKCS1v15:
+		return rs.SignPKCS1v15(hash, digest)
+	case SchemePSS:
+		return rs.SignPSS(hash, digest)
+	default:
+		return nil, fmt.Errorf("unsupported signature scheme: %v", scheme)
+	}
+}
+
+// VerifyDigest проверяет подпись дайджеста выбранной схемой
+func (rs *RSAService) VerifyDigest(scheme SignatureScheme, hash crypto.Hash, digest, signature []byte) error {
+	switch scheme {
+	case SchemePKCS1v15:
+		return rs.VerifyPKCS1v15(hash, digest, signature)
+	case SchemePSS:
+		return rs.VerifyPSS(hash, digest, signature)
+	default:
+		return fmt.Errorf("unsupported signature scheme: %v", scheme)
+	}
+}
+
+// digestReader потоково хеширует данные из r
+func digestReader(hash crypto.Hash, r io.Reader) ([]byte, error) {
+	if _, ok := digestInfoPrefixes[hash]; !ok {
+		return nil, fmt.Errorf("unsupported hash function: %v", hash)
+	}
+	h := hash.New()
+	if _, err := io.Copy(h, r); err != nil {
+		return nil, err
+	}
+	return h.Sum(nil), nil
+}
+
+// SignReader подписывает поток данных, не загружая его в память целиком
+func (rs *RSAService) SignReader(scheme SignatureScheme, hash crypto.Hash, r io.Reader) ([]byte, error) {
+	digest, err := digestReader(hash, r)
+	if err != nil {
+		return nil, err
+	}
+	return rs.SignDigest(scheme, hash, digest)
+}
+
+// VerifyReader проверяет подпись потока данных
+func (rs *RSAService) VerifyReader(scheme SignatureScheme, hash crypto.Hash, r io.Reader, signature []byte) error {
+	digest, err := digestReader(hash, r)
+	if err != nil {
+		return err
+	}
+	return rs.VerifyDigest(scheme, hash, digest, signature)
+}
+
+// SignFile подписывает файл и записывает подпись в signaturePath
+func (rs *RSAService) SignFile(scheme SignatureScheme, hash crypto.Hash, path, signaturePath string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open input file: %w", err)
+	}
+	defer f.Close()
+
+	signature, err := rs.SignReader(scheme, hash, f)
+	if err != nil {
+		return fmt.Errorf("sign file: %w", err)
+	}
+	return os.WriteFile(signaturePath, signature, 0644)
+}
+
+// VerifyFile проверяет подпись файла, записанную SignFile
+func (rs *RSAService) VerifyFile(scheme SignatureScheme, hash crypto.Hash, path, signaturePath string) error {
+	signature, err := os.ReadFile(signaturePath)
+	if err != nil {
+		return fmt.Errorf("read signature file: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open input file: %w", err)
+	}
+	defer f.Close()
+
+	return rs.VerifyReader(scheme, hash, f, signature)
+}
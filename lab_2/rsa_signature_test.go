@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var signatureHashes = []crypto.Hash{crypto.SHA256, crypto.SHA384, crypto.SHA512}
+
+func hashOf(h crypto.Hash, data []byte) []byte {
+	hh := h.New()
+	hh.Write(data)
+	return hh.Sum(nil)
+}
+
+func TestSignatureRoundTrip(t *testing.T) {
+	rs, _ := testRSAService(t)
+	for _, scheme := range []SignatureScheme{SchemePKCS1v15, SchemePSS} {
+		for _, h := range signatureHashes {
+			digest := hashOf(h, []byte("round trip"))
+			signature, err := rs.SignDigest(scheme, h, digest)
+			if err != nil {
+				t.Fatalf("%v/%v: sign: %v", scheme, h, err)
+			}
+			if err := rs.VerifyDigest(scheme, h, digest, signature); err != nil {
+				t.Errorf("%v/%v: verify: %v", scheme, h, err)
+			}
+		}
+	}
+}
+
+func TestSignatureRejectsTampering(t *testing.T) {
+	rs, _ := testRSAService(t)
+	for _, scheme := range []SignatureScheme{SchemePKCS1v15, SchemePSS} {
+		digest := hashOf(crypto.SHA256, []byte("tamper"))
+		signature, err := rs.SignDigest(scheme, crypto.SHA256, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		otherDigest := hashOf(crypto.SHA256, []byte("tamper!"))
+		if err := rs.VerifyDigest(scheme, crypto.SHA256, otherDigest, signature); err != errVerification {
+			t.Errorf("%v: other digest: %v, want errVerification", scheme, err)
+		}
+		for _, i := range []int{0, len(signature) / 2, len(signature) - 1} {
+			bad := append([]byte(nil), signature...)
+			bad[i] ^= 0x01
+			if err := rs.VerifyDigest(scheme, crypto.SHA256, digest, bad); err != errVerification {
+				t.Errorf("%v: flipped byte %d: %v, want errVerification", scheme, i, err)
+			}
+		}
+		if err := rs.VerifyDigest(scheme, crypto.SHA256, digest, signature[1:]); err != errVerification {
+			t.Errorf("%v: short signature: %v, want errVerification", scheme, err)
+		}
+		if _, err := rs.SignDigest(scheme, crypto.SHA256, digest[1:]); err == nil {
+			t.Errorf("%v: signed a digest of wrong length", scheme)
+		}
+	}
+}
+
+func TestPKCS1v15SignatureInteropWithStdlib(t *testing.T) {
+	rs, std := testRSAService(t)
+	for _, h := range signatureHashes {
+		digest := hashOf(h, []byte("interop"))
+
+		ours, err := rs.SignPKCS1v15(h, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rsa.VerifyPKCS1v15(&std.PublicKey, h, digest, ours); err != nil {
+			t.Errorf("%v: crypto/rsa rejects our signature: %v", h, err)
+		}
+
+		theirs, err := rsa.SignPKCS1v15(nil, std, h, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(ours, theirs) {
+			t.Errorf("%v: PKCS#1 v1.5 signatures differ from crypto/rsa", h)
+		}
+		if err := rs.VerifyPKCS1v15(h, digest, theirs); err != nil {
+			t.Errorf("%v: cannot verify crypto/rsa signature: %v", h, err)
+		}
+	}
+}
+
+func TestPSSInteropWithStdlib(t *testing.T) {
+	rs, std := testRSAService(t)
+	for _, h := range signatureHashes {
+		digest := hashOf(h, []byte("interop"))
+
+		ours, err := rs.SignPSS(h, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		opts := &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash}
+		if err := rsa.VerifyPSS(&std.PublicKey, h, digest, ours, opts); err != nil {
+			t.Errorf("%v: crypto/rsa rejects our PSS signature: %v", h, err)
+		}
+
+		// Соль любой длины: длина хеша, максимальная и нулевая
+		for _, saltLen := range []int{rsa.PSSSaltLengthEqualsHash, rsa.PSSSaltLengthAuto, 0} {
+			theirs, err := rsa.SignPSS(rand.Reader, std, h, digest, &rsa.PSSOptions{SaltLength: saltLen})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := rs.VerifyPSS(h, digest, theirs); err != nil {
+				t.Errorf("%v: cannot verify crypto/rsa PSS signature (salt %d): %v", h, saltLen, err)
+			}
+		}
+	}
+}
+
+func TestSignReaderAndFile(t *testing.T) {
+	rs, _ := testRSAService(t)
+	data := bytes.Repeat([]byte("streamed data "), 10000)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.bin")
+	sigPath := filepath.Join(dir, "data.sig")
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, scheme := range []SignatureScheme{SchemePKCS1v15, SchemePSS} {
+		signature, err := rs.SignReader(scheme, crypto.SHA256, bytes.NewReader(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.VerifyDigest(scheme, crypto.SHA256, hashOf(crypto.SHA256, data), signature); err != nil {
+			t.Errorf("%v: reader signature does not match digest signature: %v", scheme, err)
+		}
+		if err := rs.VerifyReader(scheme, crypto.SHA256, bytes.NewReader(data[1:]), signature); err != errVerification {
+			t.Errorf("%v: truncated stream: %v, want errVerification", scheme, err)
+		}
+
+		if err := rs.SignFile(scheme, crypto.SHA512, path, sigPath); err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.VerifyFile(scheme, crypto.SHA512, path, sigPath); err != nil {
+			t.Errorf("%v: VerifyFile: %v", scheme, err)
+		}
+		if err := os.WriteFile(path, append(data, '!'), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := rs.VerifyFile(scheme, crypto.SHA512, path, sigPath); err != errVerification {
+			t.Errorf("%v: modified file: %v, want errVerification", scheme, err)
+		}
+		os.WriteFile(path, data, 0644)
+	}
+
+	if err := rs.VerifyFile(SchemePSS, crypto.SHA256, filepath.Join(dir, "missing"), sigPath); err == nil {
+		t.Error("VerifyFile succeeded for a missing file")
+	}
+}
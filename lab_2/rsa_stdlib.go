@@ -0,0 +1,66 @@
+package main
+
+import (
+	"crypto/rsa"
+	"errors"
+	"math/big"
+)
+
+// ToStdlib преобразует открытый ключ в *rsa.PublicKey стандартной библиотеки
+func (pub *RSAPublicKey) ToStdlib() (*rsa.PublicKey, error) {
+	if !pub.E.IsInt64() || pub.E.Int64() > int64(^uint32(0)>>1) {
+		return nil, errors.New("public exponent too large for crypto/rsa")
+	}
+	return &rsa.PublicKey{N: new(big.Int).Set(pub.N), E: int(pub.E.Int64())}, nil
+}
+
+// ToStdlib преобразует закрытый ключ в *rsa.PrivateKey стандартной библиотеки
+func (priv *RSAPrivateKey) ToStdlib() (*rsa.PrivateKey, error) {
+	if priv.P == nil || priv.Q == nil {
+		return nil, errors.New("private key has no prime factors")
+	}
+
+	pub, err := priv.PublicKey.ToStdlib()
+	if err != nil {
+		return nil, err
+	}
+
+	key := &rsa.PrivateKey{
+		PublicKey: *pub,
+		D:         new(big.Int).Set(priv.D),
+	}
+	for _, r := range priv.Primes() {
+		key.Primes = append(key.Primes, new(big.Int).Set(r))
+	}
+	if err := key.Validate(); err != nil {
+		return nil, err
+	}
+	key.Precompute()
+	return key, nil
+}
+
+// RSAPublicKeyFromStdlib создает открытый ключ из *rsa.PublicKey
+func RSAPublicKeyFromStdlib(pub *rsa.PublicKey) *RSAPublicKey {
+	return &RSAPublicKey{N: new(big.Int).Set(pub.N), E: big.NewInt(int64(pub.E))}
+}
+
+// RSAPrivateKeyFromStdlib создает закрытый ключ из *rsa.PrivateKey
+func RSAPrivateKeyFromStdlib(priv *rsa.PrivateKey, ms *MathService) (*RSAPrivateKey, error) {
+	if len(priv.Primes) < 2 {
+		return nil, errors.New("private key has no prime factors")
+	}
+
+	key := &RSAPrivateKey{
+		PublicKey: RSAPublicKeyFromStdlib(&priv.PublicKey),
+		D:         new(big.Int).Set(priv.D),
+		P:         new(big.Int).Set(priv.Primes[0]),
+		Q:         new(big.Int).Set(priv.Primes[1]),
+	}
+	for _, r := range priv.Primes[2:] {
+		key.AdditionalPrimes = append(key.AdditionalPrimes, CRTPrime{Prime: new(big.Int).Set(r)})
+	}
+	if err := key.Precompute(ms); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"math"
+	"math/big"
+	"math/bits"
+	mrand "math/rand"
+)
+
+// siqsParameters размер факторной базы и полуширина интервала решета M
+// в зависимости от длины числа
+var siqsParameters = []struct {
+	bits      int
+	fbSize    int
+	halfWidth int
+}{
+	{80, 120, 1 << 15},
+	{100, 200, 1 << 15},
+	{120, 350, 1 << 16},
+	{140, 600, 1 << 16},
+	{160, 900, 3 << 15},
+	{180, 1300, 1 << 17},
+	{200, 2000, 1 << 17},
+}
+
+const (
+	siqsSkipBelow     = 30 // малые простые не просеиваются, только проверяются делением
+	siqsExtraRelation = 20 // соотношений сверх размера базы
+)
+
+// siqsPrime элемент факторной базы и его состояние для текущего A
+type siqsPrime struct {
+	p     int
+	sqrtN int  // корень из n по модулю p
+	logp  byte // округленный log2 p
+	inA   bool // p входит в A и не просеивается
+	ainv  int
+	root1 int // позиции корней Q(x) = 0 (mod p) в массиве решета
+	root2 int
+	bainv []int // 2 * B_l * A^(-1) mod p для смены полинома
+}
+
+// siqsRelation соотношение (Ax + B)^2 = A * Q(x) (mod n), где A * Q(x)
+// полностью раскладывается над факторной базой
+type siqsRelation struct {
+	square  *big.Int
+	factors map[int]int // индекс в базе -> показатель; индекс 0 - знак
+}
+
+// SIQS раскладывает n самоинициализирующимся квадратичным решетом: для
+// A = q_1...q_s из факторной базы перебираются 2^(s-1) полиномов
+// Q(x) = A x^2 + 2Bx + C с дешевым пересчетом корней (код Грея), значения
+// Q(x) на [-M, M) просеиваются логарифмами простых из базы, гладкие значения
+// дают соотношения, а зависимость между их векторами показателей по модулю 2
+// (метод Гаусса над GF(2)) - сравнение X^2 = Y^2 (mod n) и делитель НОД(X - Y, n).
+// n должно быть нечетным составным, не точной степенью и без малых делителей.
+func (fs *FactorizationService) SIQS(ctx context.Context, n *big.Int) (*big.Int, error) {
+	params := siqsParameters[len(siqsParameters)-1]
+	for _, p := range siqsParameters {
+		if n.BitLen() <= p.bits {
+			params = p
+			break
+		}
+	}
+	m := params.halfWidth
+
+	base, d, err := fs.siqsFactorBase(n, params.fbSize)
+	if err != nil || d != nil {
+		return d, err
+	}
+
+	// Порог: log2 max|Q(x)| ~ log2(M * sqrt(n/2)) минус запас на непросеянные
+	// малые простые и степени простых
+	pmax := base[len(base)-1].p
+	maxLog := math.Log2(float64(m)) + float64(n.BitLen())/2 - 0.5
+	threshold := byte(maxLog - 1.5*math.Log2(float64(pmax)))
+
+	target := new(big.Int).Lsh(n, 1)
+	target.Sqrt(target)
+	target.Quo(target, big.NewInt(int64(m)))
+
+	needed := len(base) + siqsExtraRelation
+	relations := make([]siqsRelation, 0, needed)
+	seen := make(map[string]bool)
+	usedA := make(map[string]bool)
+	sieve := make([]byte, 2*m)
+	rng := mrand.New(mrand.NewSource(n.Int64() ^ int64(n.BitLen())))
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		a, qs := siqsChooseA(base, target, usedA, rng)
+		if a == nil {
+			return nil, errors.New("siqs: could not choose polynomial coefficient A")
+		}
+		bl := fs.siqsInitA(n, a, qs, base, m)
+
+		b := new(big.Int)
+		for _, x := range bl {
+			b.Add(b, x)
+		}
+		signs := make([]int, len(bl))
+		for i := range signs {
+			signs[i] = 1
+		}
+
+		for i := 0; i < 1<<uint(len(bl)-1); i++ {
+			if i > 0 {
+				// Код Грея: меняется знак при B_l, l = (число младших нулей i) + 1
+				l := bits.TrailingZeros(uint(i)) + 1
+				signs[l] = -signs[l]
+				delta := new(big.Int).Lsh(bl[l], 1)
+				if signs[l] > 0 {
+					b.Add(b, delta)
+				} else {
+					b.Sub(b, delta)
+				}
+				for j := 2; j < len(base); j++ {
+					fp := &base[j]
+					if fp.inA {
+						continue
+					}
+					shift := fp.bainv[l]
+					if signs[l] < 0 {
+						shift = fp.p - shift
+					}
+					fp.root1 = (fp.root1 - shift + fp.p) % fp.p
+					fp.root2 = (fp.root2 - shift + fp.p) % fp.p
+				}
+			}
+
+			c := new(big.Int).Mul(b, b)
+			c.Sub(c, n).Quo(c, a)
+			relations = siqsSievePolynomial(a, b, c, base, qs, m, threshold, sieve, relations, seen)
+
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if len(relations) >= needed {
+				break
+			}
+		}
+
+		if len(relations) < needed {
+			continue
+		}
+		if d := siqsLinearAlgebra(n, base, relations); d != nil {
+			return d, nil
+		}
+		// Все зависимости дали тривиальный делитель: собираем еще соотношений
+		needed += siqsExtraRelation
+	}
+}
+
+// siqsFactorBase строит факторную базу: -1, 2 и нечетные простые p,
+// для которых n - квадратичный вычет. Если n делится на простое из базы,
+// возвращается этот делитель.
+func (fs *FactorizationService) siqsFactorBase(n *big.Int, size int) ([]siqsPrime, *big.Int, error) {
+	base := []siqsPrime{{p: -1}, {p: 2, logp: 1}}
+	pBig, r := new(big.Int), new(big.Int)
+
+	for limit := 1 << 12; len(base) < size; limit *= 2 {
+		base = base[:2]
+		for _, p := range primesUpTo(limit)[1:] {
+			pBig.SetInt64(int64(p))
+			if r.Mod(n, pBig).Sign() == 0 {
+				if n.Cmp(pBig) == 0 {
+					return nil, nil, errors.New("siqs: input is prime")
+				}
+				return nil, big.NewInt(int64(p)), nil
+			}
+			if fs.mathService.LegendreSymbol(r, pBig) != 1 {
+				continue
+			}
+			root, err := fs.mathService.TonelliShanks(r, pBig)
+			if err != nil {
+				return nil, nil, err
+			}
+			base = append(base, siqsPrime{
+				p:     p,
+				sqrtN: int(root.Int64()),
+				logp:  byte(math.Round(math.Log2(float64(p)))),
+			})
+			if len(base) == size {
+				break
+			}
+		}
+	}
+	return base, nil, nil
+}
+
+// siqsChooseA выбирает A = q_1...q_s из простых средней части базы так,
+// чтобы A было близко к target = sqrt(2n)/M; A не повторяются
+func siqsChooseA(base []siqsPrime, target *big.Int, usedA map[string]bool, rng *mrand.Rand) (*big.Int, []int) {
+	lo, hi := len(base)/3, 2*len(base)/3
+	if lo < 2 {
+		lo = 2
+	}
+	if hi-lo < 4 {
+		return nil, nil
+	}
+
+	logTarget := float64(target.BitLen())
+	mid := math.Log2(float64(base[(lo+hi)/2].p))
+	s := int(math.Round(logTarget / mid))
+	if s < 2 {
+		s = 2
+	}
+
+	for attempt := 0; attempt < 1000; attempt++ {
+		qs := make([]int, 0, s)
+		chosen := make(map[int]bool)
+		a := big.NewInt(1)
+		for len(qs) < s-1 {
+			idx := lo + rng.Intn(hi-lo)
+			if chosen[idx] {
+				continue
+			}
+			chosen[idx] = true
+			qs = append(qs, idx)
+			a.Mul(a, big.NewInt(int64(base[idx].p)))
+		}
+
+		// Последний множитель - простое базы, ближайшее к target / a
+		rest := new(big.Int).Quo(target, a)
+		best := -1
+		var bestDist *big.Int
+		for idx := 2; idx < len(base); idx++ {
+			if chosen[idx] {
+				continue
+			}
+			dist := new(big.Int).Sub(rest, big.NewInt(int64(base[idx].p)))
+			dist.Abs(dist)
+			if best < 0 || dist.Cmp(bestDist) < 0 {
+				best, bestDist = idx, dist
+			}
+		}
+		qs = append(qs, best)
+		a.Mul(a, big.NewInt(int64(base[best].p)))
+
+		key := a.String()
+		if !usedA[key] {
+			usedA[key] = true
+			return a, qs
+		}
+	}
+	return nil, nil
+}
+
+// siqsInitA подготавливает базу для нового A: числа B_l, обратные к A
+// по модулю простых базы и корни первого полинома
+func (fs *FactorizationService) siqsInitA(n, a *big.Int, qs []int, base []siqsPrime, m int) []*big.Int {
+	for j := range base {
+		base[j].inA = false
+	}
+
+	// B_l = (A/q_l) * gamma_l, gamma_l = sqrt(n) * (A/q_l)^(-1) mod q_l;
+	// тогда B = sum B_l удовлетворяет B^2 = n (mod A)
+	bl := make([]*big.Int, len(qs))
+	for l, idx := range qs {
+		fp := &base[idx]
+		fp.inA = true
+		q := big.NewInt(int64(fp.p))
+		aq := new(big.Int).Quo(a, q)
+		inv, _ := fs.mathService.ModInverse(aq, q)
+		gamma := inv.Mul(inv, big.NewInt(int64(fp.sqrtN))).Mod(inv, q).Int64()
+		if gamma > int64(fp.p/2) {
+			gamma = int64(fp.p) - gamma
+		}
+		bl[l] = aq.Mul(aq, big.NewInt(gamma))
+	}
+
+	b := new(big.Int)
+	for _, x := range bl {
+		b.Add(b, x)
+	}
+
+	pBig, t := new(big.Int), new(big.Int)
+	for j := 2; j < len(base); j++ {
+		fp := &base[j]
+		if fp.inA {
+			continue
+		}
+		p := fp.p
+		pBig.SetInt64(int64(p))
+		fp.ainv = modInverseInt(int(t.Mod(a, pBig).Int64()), p)
+
+		fp.bainv = make([]int, len(bl))
+		for l, x := range bl {
+			fp.bainv[l] = int(2 * t.Mod(x, pBig).Int64() * int64(fp.ainv) % int64(p))
+		}
+
+		// x = A^(-1) (+-sqrt(n) - B) mod p, позиция в решете x + M
+		bp := int(t.Mod(b, pBig).Int64())
+		r1 := int64(fp.ainv) * int64((fp.sqrtN-bp+p)%p) % int64(p)
+		r2 := int64(fp.ainv) * int64((2*p-fp.sqrtN-bp)%p) % int64(p)
+		fp.root1 = int((r1 + int64(m)) % int64(p))
+		fp.root2 = int((r2 + int64(m)) % int64(p))
+	}
+	return bl
+}
+
+// siqsSievePolynomial просеивает Q(x) = A x^2 + 2Bx + C на [-M, M) и добавляет
+// найденные соотношения
+func siqsSievePolynomial(a, b, c *big.Int, base []siqsPrime, qs []int, m int, threshold byte,
+	sieve []byte, relations []siqsRelation, seen map[string]bool) []siqsRelation {
+
+	for i := range sieve {
+		sieve[i] = 0
+	}
+	size := len(sieve)
+	for j := 2; j < len(base); j++ {
+		fp := &base[j]
+		if fp.inA || fp.p < siqsSkipBelow {
+			continue
+		}
+		for pos := fp.root1; pos < size; pos += fp.p {
+			sieve[pos] += fp.logp
+		}
+		if fp.root2 != fp.root1 {
+			for pos := fp.root2; pos < size; pos += fp.p {
+				sieve[pos] += fp.logp
+			}
+		}
+	}
+
+	xBig, value, q, r := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for i, v := range sieve {
+		if v < threshold {
+			continue
+		}
+
+		// value = (A x + 2B) x + C
+		x := i - m
+		xBig.SetInt64(int64(x))
+		value.Mul(a, xBig)
+		value.Add(value, b).Add(value, b)
+		value.Mul(value, xBig).Add(value, c)
+		if value.Sign() == 0 {
+			continue
+		}
+
+		factors := make(map[int]int)
+		if value.Sign() < 0 {
+			factors[0] = 1
+			value.Neg(value)
+		}
+		for _, idx := range qs {
+			factors[idx] = 1
+		}
+		if tz := value.TrailingZeroBits(); tz > 0 {
+			factors[1] = int(tz)
+			value.Rsh(value, tz)
+		}
+
+		for j := 2; j < len(base); j++ {
+			fp := &base[j]
+			if !fp.inA && (i-fp.root1)%fp.p != 0 && (i-fp.root2)%fp.p != 0 {
+				continue
+			}
+			pBig := big.NewInt(int64(fp.p))
+			for {
+				q.QuoRem(value, pBig, r)
+				if r.Sign() != 0 {
+					break
+				}
+				value.Set(q)
+				factors[j]++
+			}
+		}
+		if value.Cmp(big.NewInt(1)) != 0 {
+			continue
+		}
+
+		square := new(big.Int).Mul(a, xBig)
+		square.Add(square, b)
+		key := square.String()
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		relations = append(relations, siqsRelation{square: square, factors: factors})
+	}
+	return relations
+}
+
+// siqsLinearAlgebra ищет зависимости векторов показателей по модулю 2
+// методом Гаусса и проверяет каждую на нетривиальный делитель
+func siqsLinearAlgebra(n *big.Int, base []siqsPrime, relations []siqsRelation) *big.Int {
+	rows := len(relations)
+	cols := len(base)
+	colWords := (cols + 63) / 64
+	histWords := (rows + 63) / 64
+
+	matrix := make([][]uint64, rows)
+	history := make([][]uint64, rows)
+	for i, rel := range relations {
+		matrix[i] = make([]uint64, colWords)
+		for idx, e := range rel.factors {
+			if e%2 == 1 {
+				matrix[i][idx/64] |= 1 << uint(idx%64)
+			}
+		}
+		history[i] = make([]uint64, histWords)
+		history[i][i/64] |= 1 << uint(i%64)
+	}
+
+	rank := 0
+	for col := 0; col < cols && rank < rows; col++ {
+		word, bit := col/64, uint64(1)<<uint(col%64)
+		pivot := -1
+		for r := rank; r < rows; r++ {
+			if matrix[r][word]&bit != 0 {
+				pivot = r
+				break
+			}
+		}
+		if pivot < 0 {
+			continue
+		}
+		matrix[rank], matrix[pivot] = matrix[pivot], matrix[rank]
+		history[rank], history[pivot] = history[pivot], history[rank]
+
+		for r := 0; r < rows; r++ {
+			if r != rank && matrix[r][word]&bit != 0 {
+				for k := range matrix[r] {
+					matrix[r][k] ^= matrix[rank][k]
+				}
+				for k := range history[r] {
+					history[r][k] ^= history[rank][k]
+				}
+			}
+		}
+		rank++
+	}
+
+	one := big.NewInt(1)
+	for r := rank; r < rows; r++ {
+		x := big.NewInt(1)
+		exponents := make([]int, cols)
+		for i := 0; i < rows; i++ {
+			if history[r][i/64]&(1<<uint(i%64)) == 0 {
+				continue
+			}
+			x.Mul(x, relations[i].square).Mod(x, n)
+			for idx, e := range relations[i].factors {
+				exponents[idx] += e
+			}
+		}
+
+		y := big.NewInt(1)
+		for idx := 1; idx < cols; idx++ {
+			if exponents[idx] == 0 {
+				continue
+			}
+			pk := new(big.Int).Exp(big.NewInt(int64(base[idx].p)), big.NewInt(int64(exponents[idx]/2)), n)
+			y.Mul(y, pk).Mod(y, n)
+		}
+
+		g := new(big.Int).Sub(x, y)
+		g.GCD(nil, nil, g.Abs(g), n)
+		if g.Cmp(one) != 0 && g.Cmp(n) != 0 {
+			return g
+		}
+	}
+	return nil
+}
+
+// modInverseInt обратный элемент по простому модулю p для машинных чисел
+func modInverseInt(a, p int) int {
+	t, newT := 0, 1
+	r, newR := p, a%p
+	for newR != 0 {
+		q := r / newR
+		t, newT = newT, t-q*newT
+		r, newR = newR, r-q*newR
+	}
+	if t < 0 {
+		t += p
+	}
+	return t
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"math"
+	"math/big"
+	"math/bits"
+	"sort"
+	"time"
+)
+
+// tvlaThreshold порог статистики Уэлча, начиная с которого различие
+// распределений считается утечкой (методика TVLA)
+const tvlaThreshold = 4.5
+
+// cropPercentiles пороги отсечения выбросов, как в dudect: тест Уэлча
+// повторяется на измерениях не выше заданного перцентиля объединенной
+// выборки. Прерывания и планировщик дают длинный правый хвост, который
+// заглушает небольшую разницу средних; после отсечения она становится видна.
+var cropPercentiles = []int{100, 99, 95, 90, 80, 70, 50}
+
+// ModPowFunc операция возведения в степень, время которой измеряется
+type ModPowFunc func(base, exp, m *big.Int) (*big.Int, error)
+
+// TimingStats распределение времени выполнения одной операции
+type TimingStats struct {
+	Samples int
+	Mean    time.Duration
+	StdDev  time.Duration
+	Min     time.Duration
+	P10     time.Duration
+	Median  time.Duration
+	P90     time.Duration
+}
+
+// TimingLeakResult результат теста "фиксированный против случайного":
+// распределения времени для фиксированного показателя и для случайных
+// показателей той же длины и статистика t Уэлча для разности средних
+type TimingLeakResult struct {
+	Fixed          TimingStats
+	Random         TimingStats
+	FixedWeight    int     // число единичных бит фиксированного показателя
+	RandomWeight   float64 // среднее число единичных бит случайных показателей
+	TStatistic     float64 // наибольшее по модулю t среди отсечений
+	CropPercentile int     // перцентиль отсечения, на котором получено TStatistic
+	Leak           bool    // |t| > 4.5
+}
+
+type TimingAnalysisService struct {
+	samples int // измерений в каждом классе
+}
+
+func NewTimingAnalysisService(samples int) *TimingAnalysisService {
+	return &TimingAnalysisService{samples: samples}
+}
+
+// FixedVsRandom измеряет время op(base, exp, m) для фиксированного показателя
+// fixedExp и для случайных показателей длины m.BitLen(). Основания случайны
+// в обоих классах, порядок классов перемешивается, чтобы дрейф частоты
+// процессора и сборка мусора не давали систематической разницы. Если время
+// операции зависит от показателя, средние различаются и |t| велико; выбросы
+// отсекаются по перцентилям (cropPercentiles). Фиксированный показатель
+// стоит брать разреженным, чтобы его вес заметно отличался от случайного.
+func (ts *TimingAnalysisService) FixedVsRandom(m, fixedExp *big.Int, op ModPowFunc) (*TimingLeakResult, error) {
+	if ts.samples < 2 {
+		return nil, errors.New("at least two samples per class are required")
+	}
+	if fixedExp.Sign() < 0 || fixedExp.BitLen() > m.BitLen() {
+		return nil, errors.New("fixed exponent must fit into the modulus length")
+	}
+
+	expBits := m.BitLen()
+	order := make([]byte, 2*ts.samples)
+	if _, err := rand.Read(order); err != nil {
+		return nil, err
+	}
+
+	// Прогрев: кеш контекста Монтгомери и выделения памяти
+	for i := 0; i < 4; i++ {
+		if _, err := op(big.NewInt(3), fixedExp, m); err != nil {
+			return nil, err
+		}
+	}
+
+	fixed := make([]time.Duration, 0, ts.samples)
+	random := make([]time.Duration, 0, ts.samples)
+	randomWeight := 0
+	for _, b := range order {
+		useFixed := b&1 == 0
+		if useFixed && len(fixed) == ts.samples {
+			useFixed = false
+		} else if !useFixed && len(random) == ts.samples {
+			useFixed = true
+		}
+
+		base, err := rand.Int(rand.Reader, m)
+		if err != nil {
+			return nil, err
+		}
+		exp := fixedExp
+		if !useFixed {
+			if exp, err = randomExponent(expBits); err != nil {
+				return nil, err
+			}
+			randomWeight += hammingWeight(exp)
+		}
+
+		start := time.Now()
+		if _, err := op(base, exp, m); err != nil {
+			return nil, err
+		}
+		elapsed := time.Since(start)
+
+		if useFixed {
+			fixed = append(fixed, elapsed)
+		} else {
+			random = append(random, elapsed)
+		}
+	}
+
+	result := &TimingLeakResult{
+		Fixed:        timingStats(fixed),
+		Random:       timingStats(random),
+		FixedWeight:  hammingWeight(fixedExp),
+		RandomWeight: float64(randomWeight) / float64(len(random)),
+	}
+	result.TStatistic, result.CropPercentile = croppedWelchT(fixed, random)
+	result.Leak = math.Abs(result.TStatistic) > tvlaThreshold
+	return result, nil
+}
+
+// croppedWelchT вычисляет t Уэлча для каждого порога из cropPercentiles и
+// возвращает наибольшее по модулю значение вместе с его перцентилем
+func croppedWelchT(fixed, random []time.Duration) (float64, int) {
+	pooled := append(append([]time.Duration(nil), fixed...), random...)
+	sort.Slice(pooled, func(i, j int) bool { return pooled[i] < pooled[j] })
+
+	var best float64
+	bestPercentile := 100
+	for _, p := range cropPercentiles {
+		threshold := pooled[(len(pooled)-1)*p/100]
+		a, b := cropBelow(fixed, threshold), cropBelow(random, threshold)
+		if len(a) < 2 || len(b) < 2 {
+			continue
+		}
+		if t := welchT(a, b); math.Abs(t) > math.Abs(best) {
+			best, bestPercentile = t, p
+		}
+	}
+	return best, bestPercentile
+}
+
+// cropBelow оставляет измерения не больше threshold
+func cropBelow(samples []time.Duration, threshold time.Duration) []time.Duration {
+	cropped := make([]time.Duration, 0, len(samples))
+	for _, s := range samples {
+		if s <= threshold {
+			cropped = append(cropped, s)
+		}
+	}
+	return cropped
+}
+
+// hammingWeight число единичных бит неотрицательного x
+func hammingWeight(x *big.Int) int {
+	weight := 0
+	for _, w := range x.Bits() {
+		weight += bits.OnesCount(uint(w))
+	}
+	return weight
+}
+
+// randomExponent возвращает случайный показатель ровно из bits бит
+func randomExponent(bits int) (*big.Int, error) {
+	exp, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), uint(bits)))
+	if err != nil {
+		return nil, err
+	}
+	return exp.SetBit(exp, bits-1, 1), nil
+}
+
+func timingStats(samples []time.Duration) TimingStats {
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	mean, variance := meanVariance(samples)
+	percentile := func(p int) time.Duration {
+		return sorted[(len(sorted)-1)*p/100]
+	}
+	return TimingStats{
+		Samples: len(samples),
+		Mean:    time.Duration(mean),
+		StdDev:  time.Duration(math.Sqrt(variance)),
+		Min:     sorted[0],
+		P10:     percentile(10),
+		Median:  percentile(50),
+		P90:     percentile(90),
+	}
+}
+
+// meanVariance возвращает среднее и несмещенную дисперсию в наносекундах
+func meanVariance(samples []time.Duration) (float64, float64) {
+	var sum float64
+	for _, s := range samples {
+		sum += float64(s)
+	}
+	mean := sum / float64(len(samples))
+
+	var sq float64
+	for _, s := range samples {
+		d := float64(s) - mean
+		sq += d * d
+	}
+	return mean, sq / float64(len(samples)-1)
+}
+
+// welchT вычисляет статистику t Уэлча для разности средних двух выборок
+func welchT(a, b []time.Duration) float64 {
+	meanA, varA := meanVariance(a)
+	meanB, varB := meanVariance(b)
+	se := math.Sqrt(varA/float64(len(a)) + varB/float64(len(b)))
+	if se == 0 {
+		return 0
+	}
+	return (meanA - meanB) / se
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"math"
+	"math/rand/v2"
+	"testing"
+	"time"
+)
+
+// TestCroppedWelchTFindsShiftUnderOutliers: сдвиг 3% между классами тонет в
+// редких выбросах планировщика, но обнаруживается после отсечения хвоста
+func TestCroppedWelchTFindsShiftUnderOutliers(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	sample := func(mean float64) time.Duration {
+		x := mean + rng.NormFloat64()*5
+		if rng.IntN(50) == 0 {
+			x += 1e6 // прерывание
+		}
+		return time.Duration(x)
+	}
+
+	var fixed, random []time.Duration
+	for i := 0; i < 2000; i++ {
+		fixed = append(fixed, sample(1000))
+		random = append(random, sample(1030))
+	}
+
+	if raw := welchT(fixed, random); math.Abs(raw) > tvlaThreshold {
+		t.Fatalf("uncropped t = %.1f, expected the outliers to hide the shift", raw)
+	}
+	tStat, percentile := croppedWelchT(fixed, random)
+	if math.Abs(tStat) <= tvlaThreshold || percentile == 100 {
+		t.Fatalf("cropped t = %.1f at P%d, expected a leak", tStat, percentile)
+	}
+
+	// Одинаковые распределения не должны давать утечку
+	var same []time.Duration
+	for i := 0; i < 2000; i++ {
+		same = append(same, sample(1000))
+	}
+	if tStat, _ := croppedWelchT(fixed, same); math.Abs(tStat) > tvlaThreshold {
+		t.Fatalf("identical distributions flagged: t = %.1f", tStat)
+	}
+}
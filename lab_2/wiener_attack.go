@@ -2,11 +2,6 @@ package main
 
 import "math/big"
 
-type ContinuedFraction struct {
-	Numerator   *big.Int // k
-	Denominator *big.Int // d
-}
-
 type WienerAttackResult struct {
 	D                  *big.Int
 	Phi                *big.Int
@@ -24,42 +19,16 @@ func NewWienerAttackService() *WienerAttackService {
 	}
 }
 
-// цепная дробь
-func (was *WienerAttackService) continuedFractionExpansion(e, n *big.Int) []ContinuedFraction {
-	a := new(big.Int).Set(e)
-	b := new(big.Int).Set(n)
-
-	var convergents []ContinuedFraction
-
-	h0, h1 := big.NewInt(1), big.NewInt(0)
-	k0, k1 := big.NewInt(0), big.NewInt(1)
-
-	for b.Sign() != 0 {
-		q := new(big.Int).Div(a, b)
-
-		h := new(big.Int).Add(new(big.Int).Mul(q, h0), h1)
-		k := new(big.Int).Add(new(big.Int).Mul(q, k0), k1)
-
-		convergents = append(convergents, ContinuedFraction{
-			Numerator:   new(big.Int).Set(h),
-			Denominator: new(big.Int).Set(k),
-		})
-
-		h1, h0 = h0, h
-		k1, k0 = k0, k
-
-		a, b = b, new(big.Int).Mod(a, b)
-	}
-
-	return convergents
-}
-
 func (was *WienerAttackService) Attack(publicKey *RSAPublicKey) *WienerAttackResult {
 	result := &WienerAttackResult{
 		Success: false,
 	}
 
-	convergents := was.continuedFractionExpansion(publicKey.E, publicKey.N)
+	// подходящие дроби к e/n; среди них есть k/d при d < n^(1/4) / 3
+	convergents, err := was.mathService.RationalConvergents(publicKey.E, publicKey.N)
+	if err != nil {
+		return result
+	}
 	result.ContinuedFractions = convergents
 
 	for _, cf := range convergents {
@@ -1,13 +1,16 @@
 package main
 
 import (
+	"context"
 	"encoding/hex"
 	"fmt"
 	"log"
 	"math/big"
+	"time"
 
 	"lab_4/crypto"
 	"lab_4/dh"
+	"lab_4/primes"
 )
 
 func main() {
@@ -75,6 +78,54 @@ func main() {
 	if string(decrypted) == originalMessage {
 		fmt.Println("\nРасшифровка успешна")
 	}
+
+	demonstrateProvableParameters()
+	demonstrateParallelSearch()
+}
+
+func demonstrateProvableParameters() {
+	fmt.Println("\nДоказуемо простые параметры")
+
+	start := time.Now()
+	params, err := dh.NewProvableDHParameters(512)
+	if err != nil {
+		log.Fatalf("Ошибка генерации параметров: %v", err)
+	}
+
+	fmt.Printf("Простое число p: %s (%v)\n", formatBigInt(params.Prime, 60), time.Since(start))
+	fmt.Printf("Цепочка сертификата (%d звеньев):\n%s", params.Certificate.Depth(), params.Certificate)
+	fmt.Printf("Проверка сертификата: %v\n", params.VerifyPrime())
+
+	// Искаженный сертификат не проходит проверку
+	forged := *params.Certificate
+	forged.Witness = big.NewInt(1)
+	fmt.Printf("Искаженный свидетель: %v\n", primes.VerifyCertificate(params.Prime, &forged))
+
+	composite := new(big.Int).Add(params.Prime, big.NewInt(2))
+	forged = *params.Certificate
+	forged.N = composite
+	fmt.Printf("Чужое число: %v\n", primes.VerifyCertificate(composite, &forged))
+}
+
+func demonstrateParallelSearch() {
+	fmt.Println("\nПараллельный поиск безопасного простого (768 бит)")
+
+	for _, workers := range []int{1, 4} {
+		p, stats, err := dh.GenerateSafePrimeContext(context.Background(), 768, workers)
+		if err != nil {
+			log.Fatalf("Ошибка поиска: %v", err)
+		}
+		fmt.Printf("Потоков: %d, время: %v, кандидатов: %d, отсеяно: %d, тестов: %d\n",
+			stats.Workers, stats.Duration.Round(time.Millisecond), stats.Candidates, stats.SieveRejections, stats.TestCalls)
+		q := new(big.Int).Rsh(p, 1)
+		fmt.Printf("  p = %s, q = (p-1)/2 простое: %v\n", formatBigInt(p, 40), q.ProbablyPrime(20))
+	}
+
+	// Отмена по таймауту останавливает все потоки
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, stats, err := dh.GenerateSafePrimeContext(ctx, 4096, 0)
+	fmt.Printf("Поиск 4096 бит с таймаутом 50 мс: %v (кандидатов: %d)\n", err, stats.Candidates)
 }
 
 func formatBigInt(num *big.Int, maxLen int) string {
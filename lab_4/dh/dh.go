@@ -1,16 +1,22 @@
 package dh
 
 import (
+	"context"
 	"crypto/rand"
 	"errors"
 	"fmt"
 	"math/big"
+
+	"lab_4/primes"
 )
 
 type DHParameters struct {
 	Prime     *big.Int
 	Generator *big.Int
 	BitSize   int
+
+	// Сертификат простоты Поклингтона для Prime (только у доказуемых параметров)
+	Certificate *primes.Certificate
 }
 
 type KeyPair struct {
@@ -25,24 +31,29 @@ type Party struct {
 	SharedKey  *big.Int
 }
 
+// GenerateSafePrime ищет безопасное простое во всех доступных потоках
 func GenerateSafePrime(bits int) (*big.Int, error) {
+	prime, _, err := GenerateSafePrimeContext(context.Background(), bits, 0)
+	return prime, err
+}
+
+// GenerateSafePrimeContext ищет безопасное простое p = 2q + 1 параллельным
+// поиском в workers потоках (<= 0 - по числу процессоров); отмена ctx
+// прерывает поиск
+func GenerateSafePrimeContext(ctx context.Context, bits, workers int) (*big.Int, primes.SearchStats, error) {
 	if bits < 256 {
-		return nil, errors.New("размер ключа должен быть не менее 256 бит")
+		return nil, primes.SearchStats{}, errors.New("размер ключа должен быть не менее 256 бит")
 	}
 
-	for {
-		p, err := rand.Prime(rand.Reader, bits)
-		if err != nil {
-			return nil, fmt.Errorf("ошибка генерации простого числа: %w", err)
-		}
-
-		q := new(big.Int).Sub(p, big.NewInt(1))
-		q.Div(q, big.NewInt(2))
-
-		if q.ProbablyPrime(20) {
-			return p, nil
-		}
+	p, stats, err := primes.Search(ctx, primes.SearchConfig{
+		Bits:    bits,
+		Workers: workers,
+		Safe:    true,
+	})
+	if err != nil {
+		return nil, stats, fmt.Errorf("ошибка генерации простого числа: %w", err)
 	}
+	return p, stats, nil
 }
 
 func FindGenerator(prime *big.Int) (*big.Int, error) {
@@ -85,6 +96,48 @@ func NewDHParameters(bits int) (*DHParameters, error) {
 	}, nil
 }
 
+// NewProvableDHParameters создает параметры с доказуемо простым безопасным
+// числом p = 2q + 1 и сертификатом его простоты
+func NewProvableDHParameters(bits int) (*DHParameters, error) {
+	if bits < 256 {
+		return nil, errors.New("размер ключа должен быть не менее 256 бит")
+	}
+
+	prime, cert, err := primes.GenerateProvableSafePrime(bits)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации доказуемо простого числа: %w", err)
+	}
+
+	generator, err := FindGenerator(prime)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка поиска генератора: %w", err)
+	}
+
+	return &DHParameters{
+		Prime:       prime,
+		Generator:   generator,
+		BitSize:     bits,
+		Certificate: cert,
+	}, nil
+}
+
+// VerifyPrime проверяет сертификат простоты p и то, что p безопасное
+// (сертификат p опирается на q = (p-1)/2)
+func (params *DHParameters) VerifyPrime() error {
+	if params.Certificate == nil {
+		return errors.New("у параметров нет сертификата простоты")
+	}
+	if err := primes.VerifyCertificate(params.Prime, params.Certificate); err != nil {
+		return err
+	}
+
+	q := new(big.Int).Rsh(params.Prime, 1)
+	if params.Certificate.Factor == nil || params.Certificate.Factor.Cmp(q) != 0 {
+		return errors.New("простое число не является безопасным")
+	}
+	return nil
+}
+
 func (params *DHParameters) GeneratePrivateKey() (*big.Int, error) {
 	max := new(big.Int).Sub(params.Prime, big.NewInt(2))
 	privateKey, err := rand.Int(rand.Reader, max)
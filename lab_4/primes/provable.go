@@ -0,0 +1,281 @@
+package primes
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// Простые числа до smallPrimeBits бит доказываются пробным делением
+const smallPrimeBits = 32
+
+var (
+	one = big.NewInt(1)
+	two = big.NewInt(2)
+)
+
+// Certificate - сертификат простоты Поклингтона. Для N > 2^32 он утверждает,
+// что N - 1 = F * R, где F простое и F^2 > N, а свидетель a удовлетворяет
+// a^(N-1) = 1 (mod N) и НОД(a^((N-1)/F) - 1, N) = 1. Простота F подтверждается
+// вложенным сертификатом, так что получается цепочка до малого простого,
+// которое проверяется пробным делением.
+type Certificate struct {
+	N          *big.Int
+	Factor     *big.Int
+	Witness    *big.Int
+	FactorCert *Certificate
+}
+
+// Depth возвращает длину цепочки сертификата
+func (c *Certificate) Depth() int {
+	depth := 0
+	for ; c != nil; c = c.FactorCert {
+		depth++
+	}
+	return depth
+}
+
+func (c *Certificate) String() string {
+	var sb strings.Builder
+	for ; c != nil; c = c.FactorCert {
+		if c.Factor == nil {
+			fmt.Fprintf(&sb, "%d бит: пробное деление\n", c.N.BitLen())
+			continue
+		}
+		fmt.Fprintf(&sb, "%d бит: N-1 = F*R, F %d бит, свидетель a = %s\n",
+			c.N.BitLen(), c.Factor.BitLen(), c.Witness)
+	}
+	return sb.String()
+}
+
+// VerifyCertificate проверяет, что cert доказывает простоту n. Проверка
+// не использует вероятностных тестов.
+func VerifyCertificate(n *big.Int, cert *Certificate) error {
+	if n == nil || n.Cmp(two) < 0 {
+		return errors.New("простым может быть только число не меньше 2")
+	}
+	if cert == nil {
+		return errors.New("сертификат отсутствует")
+	}
+	if cert.N == nil || cert.N.Cmp(n) != 0 {
+		return errors.New("сертификат относится к другому числу")
+	}
+
+	if cert.Factor == nil {
+		if n.BitLen() > smallPrimeBits {
+			return errors.New("число слишком велико для проверки пробным делением")
+		}
+		if !isSmallPrime(n.Uint64()) {
+			return fmt.Errorf("%s не является простым", n)
+		}
+		return nil
+	}
+
+	// Сертификат может прийти извне: F < 2 ломает деление и не доказывает ничего
+	if cert.Factor.Cmp(two) < 0 {
+		return errors.New("множитель F должен быть не меньше 2")
+	}
+	nMinus1 := new(big.Int).Sub(n, one)
+	r, rem := new(big.Int).QuoRem(nMinus1, cert.Factor, new(big.Int))
+	if rem.Sign() != 0 {
+		return errors.New("F не делит N-1")
+	}
+	if new(big.Int).Mul(cert.Factor, cert.Factor).Cmp(n) <= 0 {
+		return errors.New("F^2 не превосходит N")
+	}
+	if cert.Witness == nil || cert.Witness.Cmp(two) < 0 || cert.Witness.Cmp(nMinus1) >= 0 {
+		return errors.New("некорректный свидетель")
+	}
+	if new(big.Int).Exp(cert.Witness, nMinus1, n).Cmp(one) != 0 {
+		return errors.New("нарушено условие a^(N-1) = 1 (mod N)")
+	}
+	if !pocklingtonGCD(n, cert.Witness, r) {
+		return errors.New("нарушено условие НОД(a^((N-1)/F) - 1, N) = 1")
+	}
+
+	if err := VerifyCertificate(cert.Factor, cert.FactorCert); err != nil {
+		return fmt.Errorf("сертификат множителя (%d бит): %w", cert.Factor.BitLen(), err)
+	}
+	return nil
+}
+
+// GenerateProvablePrime строит доказуемо простое число длины bits с двумя
+// старшими установленными битами (конструкция Маурера с F^2 > N):
+// рекурсивно строится простое F длины (bits+1)/2+1, после чего перебираются
+// N = 2*R*F + 1 со случайным R до выполнения условий Поклингтона.
+func GenerateProvablePrime(bits int) (*big.Int, *Certificate, error) {
+	if bits < 3 {
+		return nil, nil, errors.New("размер простого числа должен быть не менее 3 бит")
+	}
+
+	if bits <= smallPrimeBits {
+		for {
+			n, err := randomWithTopBits(bits)
+			if err != nil {
+				return nil, nil, err
+			}
+			if isSmallPrime(n.Uint64()) {
+				return n, &Certificate{N: n}, nil
+			}
+		}
+	}
+
+	factor, factorCert, err := GenerateProvablePrime((bits+1)/2 + 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// N принадлежит [3 * 2^(bits-2), 2^bits), значит R из [rMin, rMax]
+	lo := new(big.Int).Lsh(big.NewInt(3), uint(bits-2))
+	hi := new(big.Int).Lsh(one, uint(bits))
+	twoF := new(big.Int).Lsh(factor, 1)
+	rMin := new(big.Int).Sub(lo, one)
+	rMin.Add(rMin, twoF).Sub(rMin, one).Div(rMin, twoF)
+	rMax := new(big.Int).Sub(hi, two)
+	rMax.Div(rMax, twoF)
+	span := new(big.Int).Sub(rMax, rMin)
+	span.Add(span, one)
+
+	for {
+		r, err := rand.Int(rand.Reader, span)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ошибка генерации случайного числа: %w", err)
+		}
+		r.Add(r, rMin)
+
+		n := new(big.Int).Mul(twoF, r)
+		n.Add(n, one)
+		if hasSmallFactor(n) {
+			continue
+		}
+
+		if cert, ok := pocklingtonCertificate(n, factor, factorCert); ok {
+			return n, cert, nil
+		}
+	}
+}
+
+// GenerateProvableSafePrime строит доказуемо простое безопасное число
+// p = 2q + 1 длины bits: q = 2*R*F + 1 перебирается по конструкции Маурера,
+// и для найденной пары строятся сертификаты q (через F) и p (через q).
+func GenerateProvableSafePrime(bits int) (*big.Int, *Certificate, error) {
+	if bits <= smallPrimeBits+2 {
+		return nil, nil, fmt.Errorf("размер безопасного простого должен быть больше %d бит", smallPrimeBits+2)
+	}
+
+	qBits := bits - 1
+	factor, factorCert, err := GenerateProvablePrime((qBits+1)/2 + 1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// q из [2^(qBits-1), 2^qBits), тогда p = 2q + 1 имеет ровно bits бит
+	lo := new(big.Int).Lsh(one, uint(qBits-1))
+	twoF := new(big.Int).Lsh(factor, 1)
+	rMin := new(big.Int).Add(lo, twoF)
+	rMin.Sub(rMin, two).Div(rMin, twoF)
+	rMax := new(big.Int).Sub(new(big.Int).Lsh(lo, 1), two)
+	rMax.Div(rMax, twoF)
+	span := new(big.Int).Sub(rMax, rMin)
+	span.Add(span, one)
+
+	for {
+		r, err := rand.Int(rand.Reader, span)
+		if err != nil {
+			return nil, nil, fmt.Errorf("ошибка генерации случайного числа: %w", err)
+		}
+		r.Add(r, rMin)
+
+		q := new(big.Int).Mul(twoF, r)
+		q.Add(q, one)
+		p := new(big.Int).Lsh(q, 1)
+		p.Add(p, one)
+
+		// Дешевый отсев: малые делители и вероятностный тест для обоих чисел
+		if hasSmallFactor(q) || hasSmallFactor(p) || !q.ProbablyPrime(0) || !p.ProbablyPrime(0) {
+			continue
+		}
+
+		qCert, ok := pocklingtonCertificate(q, factor, factorCert)
+		if !ok {
+			continue
+		}
+		if pCert, ok := pocklingtonCertificate(p, q, qCert); ok {
+			return p, pCert, nil
+		}
+	}
+}
+
+// pocklingtonCertificate ищет свидетеля Поклингтона для n = F*R + 1.
+// ok = false означает, что n составное или свидетель не найден.
+func pocklingtonCertificate(n, factor *big.Int, factorCert *Certificate) (*Certificate, bool) {
+	nMinus1 := new(big.Int).Sub(n, one)
+	r := new(big.Int).Div(nMinus1, factor)
+
+	for a := int64(2); a < 50; a++ {
+		witness := big.NewInt(a)
+		if new(big.Int).Exp(witness, nMinus1, n).Cmp(one) != 0 {
+			// Нарушена малая теорема Ферма - n составное
+			return nil, false
+		}
+		if pocklingtonGCD(n, witness, r) {
+			return &Certificate{N: n, Factor: factor, Witness: witness, FactorCert: factorCert}, true
+		}
+	}
+	return nil, false
+}
+
+// pocklingtonGCD проверяет НОД(a^r - 1, n) = 1
+func pocklingtonGCD(n, a, r *big.Int) bool {
+	x := new(big.Int).Exp(a, r, n)
+	x.Sub(x, one)
+	if x.Sign() < 0 {
+		x.Add(x, n)
+	}
+	return new(big.Int).GCD(nil, nil, x, n).Cmp(one) == 0
+}
+
+func randomWithTopBits(bits int) (*big.Int, error) {
+	limit := new(big.Int).Lsh(one, uint(bits-2))
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка генерации случайного числа: %w", err)
+	}
+	n.SetBit(n, bits-1, 1)
+	n.SetBit(n, bits-2, 1)
+	n.SetBit(n, 0, 1)
+	return n, nil
+}
+
+func isSmallPrime(n uint64) bool {
+	if n < 2 {
+		return false
+	}
+	if n%2 == 0 {
+		return n == 2
+	}
+	for d := uint64(3); d*d <= n; d += 2 {
+		if n%d == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+var smallPrimeProduct = func() *big.Int {
+	product := big.NewInt(1)
+	for p := uint64(3); p < 1000; p += 2 {
+		if isSmallPrime(p) {
+			product.Mul(product, new(big.Int).SetUint64(p))
+		}
+	}
+	return product
+}()
+
+// hasSmallFactor проверяет наличие нечетного простого делителя меньше 1000
+// у большого числа n
+func hasSmallFactor(n *big.Int) bool {
+	return new(big.Int).GCD(nil, nil, n, smallPrimeProduct).Cmp(one) != 0
+}
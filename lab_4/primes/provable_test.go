@@ -0,0 +1,105 @@
+package primes
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestProvablePrimeCertificates(t *testing.T) {
+	for _, bits := range []int{16, 64, 256} {
+		n, cert, err := GenerateProvablePrime(bits)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n.BitLen() != bits || !n.ProbablyPrime(20) {
+			t.Fatalf("GenerateProvablePrime(%d) = %s", bits, n)
+		}
+		if err := VerifyCertificate(n, cert); err != nil {
+			t.Errorf("%d bits: valid certificate rejected: %v", bits, err)
+		}
+	}
+
+	p, cert, err := GenerateProvableSafePrime(96)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := new(big.Int).Rsh(p, 1)
+	if p.BitLen() != 96 || !q.ProbablyPrime(20) {
+		t.Fatalf("GenerateProvableSafePrime(96) = %s", p)
+	}
+	if err := VerifyCertificate(p, cert); err != nil {
+		t.Errorf("safe prime certificate rejected: %v", err)
+	}
+	if cert.Factor.Cmp(q) != 0 {
+		t.Errorf("safe prime certificate factor = %s, want q = %s", cert.Factor, q)
+	}
+}
+
+func TestVerifyCertificateRejectsTampering(t *testing.T) {
+	n, cert, err := GenerateProvablePrime(128)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nMinus1 := new(big.Int).Sub(n, one)
+
+	tampered := func(change func(c *Certificate)) *Certificate {
+		c := *cert
+		change(&c)
+		return &c
+	}
+	// a = w^F: a^((N-1)/F) = w^(N-1) = 1, условие на НОД нарушено
+	gcdWitness := new(big.Int).Exp(cert.Witness, cert.Factor, n)
+
+	tests := []struct {
+		name string
+		n    *big.Int
+		cert *Certificate
+	}{
+		{"nil certificate", n, nil},
+		{"wrong N", new(big.Int).Add(n, two), tampered(func(c *Certificate) {})},
+		{"F^2 <= N", n, tampered(func(c *Certificate) { c.Factor = big.NewInt(2); c.FactorCert = &Certificate{N: big.NewInt(2)} })},
+		{"F does not divide N-1", n, tampered(func(c *Certificate) { c.Factor = new(big.Int).Add(c.Factor, two) })},
+		{"zero factor", n, tampered(func(c *Certificate) { c.Factor = big.NewInt(0) })},
+		{"negative factor", n, tampered(func(c *Certificate) { c.Factor = big.NewInt(-3) })},
+		{"factor one", n, tampered(func(c *Certificate) { c.Factor = big.NewInt(1) })},
+		{"missing witness", n, tampered(func(c *Certificate) { c.Witness = nil })},
+		{"witness 1", n, tampered(func(c *Certificate) { c.Witness = big.NewInt(1) })},
+		{"witness N-1", n, tampered(func(c *Certificate) { c.Witness = nMinus1 })},
+		{"witness fails gcd", n, tampered(func(c *Certificate) { c.Witness = gcdWitness })},
+		{"missing factor certificate", n, tampered(func(c *Certificate) { c.FactorCert = nil })},
+		{"trial division of large N", n, &Certificate{N: n}},
+	}
+	for _, tt := range tests {
+		if err := VerifyCertificate(tt.n, tt.cert); err == nil {
+			t.Errorf("%s: tampered certificate accepted", tt.name)
+		}
+	}
+}
+
+func TestVerifyCertificateSmallNumbers(t *testing.T) {
+	for _, p := range []int64{2, 3, 97, 4294967291} {
+		n := big.NewInt(p)
+		if err := VerifyCertificate(n, &Certificate{N: n}); err != nil {
+			t.Errorf("%d: %v", p, err)
+		}
+	}
+	for _, c := range []int64{-7, -2, -1, 0, 1, 561, 4294967295} {
+		n := big.NewInt(c)
+		if err := VerifyCertificate(n, &Certificate{N: n}); err == nil {
+			t.Errorf("%d proved prime", c)
+		}
+	}
+	if err := VerifyCertificate(nil, &Certificate{}); err == nil {
+		t.Error("nil number proved prime")
+	}
+
+	// Составное 561 = 3 * 11 * 17 со "свидетелем" для F = 5 (F^2 <= N) и F = 35
+	composite := big.NewInt(561)
+	for _, f := range []int64{5, 35} {
+		forged := &Certificate{N: composite, Factor: big.NewInt(f), Witness: big.NewInt(2),
+			FactorCert: &Certificate{N: big.NewInt(f)}}
+		if err := VerifyCertificate(composite, forged); err == nil {
+			t.Errorf("561 proved prime with F = %d", f)
+		}
+	}
+}
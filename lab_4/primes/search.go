@@ -0,0 +1,290 @@
+package primes
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	sieveBound    = 4096    // граница малых простых для просеивания кандидатов
+	maxWindowSpan = 1 << 20 // после такого сдвига от базы поток берет новую случайную базу
+	maxWorkers    = 64
+	minSplitBits  = 16 // для меньших чисел поиск ведется в одном потоке
+)
+
+// sievePrimes нечетные простые меньше sieveBound
+var sievePrimes = func() []uint32 {
+	var list []uint32
+	for p := uint64(3); p < sieveBound; p += 2 {
+		if isSmallPrime(p) {
+			list = append(list, uint32(p))
+		}
+	}
+	return list
+}()
+
+// SearchConfig параметры параллельного поиска простого числа
+type SearchConfig struct {
+	Bits       int
+	Workers    int                          // число потоков; <= 0 - по числу процессоров
+	TopTwoBits bool                         // установить два старших бита (произведение двух таких чисел имеет ровно 2*Bits бит)
+	Safe       bool                         // искать безопасное простое p = 2q + 1 с простым q
+	IsPrime    func(*big.Int) (bool, error) // тест простоты; nil - ProbablyPrime(20)
+	Random     io.Reader                    // источник случайности; nil - crypto/rand
+}
+
+// SearchStats статистика поиска по всем потокам
+type SearchStats struct {
+	Workers         int
+	Bases           int64 // случайных баз, с которых начинались окна поиска
+	Candidates      int64 // рассмотрено кандидатов
+	SieveRejections int64 // отброшено просеиванием малыми простыми
+	TestCalls       int64 // вызовы теста простоты
+	Duration        time.Duration
+}
+
+// Search ищет простое число длины cfg.Bits в нескольких потоках. Поток i
+// перебирает только кандидатов из своего класса вычетов по модулю
+// M = step * 2^k (step = 2, для безопасных простых step = 4, так как p = 3 mod 4),
+// поэтому потоки никогда не проверяют одно и то же число. Внутри окна остатки
+// по малым простым обновляются инкрементально, а тест простоты вызывается
+// только для непросеянных кандидатов. Побеждает первый найденный результат,
+// остальные потоки останавливаются; отмена ctx прерывает поиск.
+func Search(ctx context.Context, cfg SearchConfig) (*big.Int, SearchStats, error) {
+	stats := SearchStats{}
+	start := time.Now()
+
+	minBits := 3
+	if cfg.Safe {
+		minBits = 4
+	}
+	if cfg.Bits < minBits {
+		return nil, stats, fmt.Errorf("размер простого числа должен быть не менее %d бит", minBits)
+	}
+
+	workers := cfg.Workers
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > maxWorkers {
+		workers = maxWorkers
+	}
+	if cfg.Bits < minSplitBits {
+		workers = 1
+	}
+	stats.Workers = workers
+
+	isPrime := cfg.IsPrime
+	if isPrime == nil {
+		isPrime = func(n *big.Int) (bool, error) { return n.ProbablyPrime(20), nil }
+	}
+	random := cfg.Random
+	if random == nil {
+		random = rand.Reader
+	}
+
+	// Классы вычетов потоков: offset_i = step*i + (step - 1) по модулю step * 2^k >= step * workers
+	step := int64(2)
+	if cfg.Safe {
+		step = 4
+	}
+	modulus := step
+	for modulus < step*int64(workers) {
+		modulus *= 2
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		once     sync.Once
+		result   *big.Int
+		firstErr error
+		wg       sync.WaitGroup
+		randMu   sync.Mutex
+		counters struct{ bases, candidates, rejections, tests atomic.Int64 }
+	)
+	finish := func(p *big.Int, err error) {
+		once.Do(func() {
+			result, firstErr = p, err
+			cancel()
+		})
+	}
+
+	for i := 0; i < workers; i++ {
+		offset := step*int64(i) + step - 1
+		w := &searchWorker{
+			cfg:     cfg,
+			isPrime: isPrime,
+			modulus: modulus,
+			offset:  offset,
+			primes:  sievePrimesBelow(cfg.Bits),
+			readBase: func(buf []byte) error {
+				randMu.Lock()
+				defer randMu.Unlock()
+				_, err := io.ReadFull(random, buf)
+				return err
+			},
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := w.run(ctx, &counters.bases, &counters.candidates, &counters.rejections, &counters.tests)
+			if p != nil || (err != nil && ctx.Err() == nil) {
+				finish(p, err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	stats.Bases = counters.bases.Load()
+	stats.Candidates = counters.candidates.Load()
+	stats.SieveRejections = counters.rejections.Load()
+	stats.TestCalls = counters.tests.Load()
+	stats.Duration = time.Since(start)
+
+	if result == nil && firstErr == nil {
+		firstErr = ctx.Err()
+		if firstErr == nil {
+			firstErr = errors.New("поиск остановлен без результата")
+		}
+	}
+	return result, stats, firstErr
+}
+
+// sievePrimesBelow возвращает малые простые меньше 2^(bits-2): любой кандидат
+// (и (p-1)/2 для безопасных простых) заведомо больше них
+func sievePrimesBelow(bits int) []uint32 {
+	if bits-2 >= 12 {
+		return sievePrimes
+	}
+	bound := uint32(1) << uint(bits-2)
+	for i, p := range sievePrimes {
+		if p >= bound {
+			return sievePrimes[:i]
+		}
+	}
+	return sievePrimes
+}
+
+// searchWorker один поток поиска в классе вычетов offset по модулю modulus
+type searchWorker struct {
+	cfg      SearchConfig
+	isPrime  func(*big.Int) (bool, error)
+	modulus  int64
+	offset   int64
+	primes   []uint32
+	readBase func([]byte) error
+}
+
+func (w *searchWorker) run(ctx context.Context, bases, candidates, rejections, tests *atomic.Int64) (*big.Int, error) {
+	bits := w.cfg.Bits
+	residues := make([]uint32, len(w.primes))
+	stepResidues := make([]uint32, len(w.primes))
+	word, small := new(big.Int), new(big.Int)
+	modBig := big.NewInt(w.modulus)
+	for i, p := range w.primes {
+		stepResidues[i] = uint32(w.modulus % int64(p))
+	}
+
+	for {
+		base, err := w.randomBase(bits)
+		if err != nil {
+			return nil, fmt.Errorf("ошибка генерации случайного числа: %w", err)
+		}
+		// Перенос базы в свой класс вычетов
+		rem := new(big.Int).Mod(base, modBig).Int64()
+		base.Add(base, big.NewInt((w.offset-rem+w.modulus)%w.modulus))
+		if base.BitLen() > bits {
+			continue
+		}
+		bases.Add(1)
+
+		for i, p := range w.primes {
+			residues[i] = uint32(word.Mod(base, small.SetUint64(uint64(p))).Uint64())
+		}
+
+		candidate := base
+		for delta := int64(0); delta < maxWindowSpan; delta += w.modulus {
+			if delta > 0 {
+				candidate.Add(candidate, modBig)
+				if candidate.BitLen() > bits {
+					break
+				}
+				for i, p := range w.primes {
+					r := residues[i] + stepResidues[i]
+					if r >= p {
+						r -= p
+					}
+					residues[i] = r
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			default:
+			}
+			candidates.Add(1)
+
+			if w.sieved(residues) {
+				rejections.Add(1)
+				continue
+			}
+
+			tests.Add(1)
+			ok, err := w.accept(candidate)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				return new(big.Int).Set(candidate), nil
+			}
+		}
+	}
+}
+
+// sieved сообщает, делится ли кандидат (или (p-1)/2 для безопасного простого)
+// на одно из малых простых
+func (w *searchWorker) sieved(residues []uint32) bool {
+	for _, r := range residues {
+		// p = 1 (mod r) означает, что r делит (p-1)/2
+		if r == 0 || (w.cfg.Safe && r == 1) {
+			return true
+		}
+	}
+	return false
+}
+
+func (w *searchWorker) accept(candidate *big.Int) (bool, error) {
+	ok, err := w.isPrime(candidate)
+	if err != nil || !ok || !w.cfg.Safe {
+		return ok, err
+	}
+	q := new(big.Int).Rsh(candidate, 1)
+	return w.isPrime(q)
+}
+
+// randomBase случайное число длины bits с одним или двумя старшими битами
+func (w *searchWorker) randomBase(bits int) (*big.Int, error) {
+	buf := make([]byte, (bits+7)/8)
+	if err := w.readBase(buf); err != nil {
+		return nil, err
+	}
+	buf[0] &= 0xff >> uint(len(buf)*8-bits)
+
+	base := new(big.Int).SetBytes(buf)
+	base.SetBit(base, bits-1, 1)
+	if w.cfg.TopTwoBits {
+		base.SetBit(base, bits-2, 1)
+	}
+	return base, nil
+}